@@ -43,6 +43,8 @@ type CmdHandler interface {
 
 	SuggestVarNames(partial string) [][]rune
 
+	SuggestTasks(partial string) [][]rune
+
 	HandleRootCmd(ctx context.Context, tokens []string) (context.Context, error)
 
 	HandleCmd(ctx context.Context, tokens []string) (context.Context, error)
@@ -57,6 +59,10 @@ type CmdHandler interface {
 
 	ListSequences()
 
+	CancelTask(id string) error
+
+	GetTaskStatus(id string) (TaskStatus, error)
+
 	printf(formatStr string, a ...any)
 
 	println(string)
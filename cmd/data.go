@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shubm-quodes/repl-reqs/util"
+)
+
+const playDataFlag = "--data"
+
+/*
+Rows of a '$play --data' file, each of which the sequence is played for with its columns available
+as variables. The header of '.csv' files names the columns, '.json' files hold an array of objects
+(values that aren't strings are json encoded).
+*/
+func loadDataRows(path string) ([]map[string]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data file: %w", err)
+	}
+
+	var rows []map[string]string
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		rows, err = csvRows(raw)
+	case ".json":
+		rows, err = jsonRows(raw)
+	default:
+		return nil, fmt.Errorf("unsupported data file '%s', expected a .csv or .json file", path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid data file '%s': %w", path, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("data file '%s' has no rows", path)
+	}
+	return rows, nil
+}
+
+func csvRows(raw []byte) ([]map[string]string, error) {
+	records, err := csv.NewReader(strings.NewReader(string(raw))).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("missing header")
+	}
+
+	header := util.MapSlice(records[0], func(col string, _ int) string {
+		return strings.TrimSpace(col)
+	})
+
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, col := range header {
+			row[col] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func jsonRows(raw []byte) ([]map[string]string, error) {
+	var objects []map[string]any
+	if err := json.Unmarshal(raw, &objects); err != nil {
+		return nil, fmt.Errorf("expected an array of objects: %w", err)
+	}
+
+	rows := make([]map[string]string, 0, len(objects))
+	for _, obj := range objects {
+		row := make(map[string]string, len(obj))
+		for key, val := range obj {
+			row[key] = util.StringifyQueryResults([]any{val})
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Removes '--data <file>' from the tokens
+func extractDataFlag(tokens []string) ([]string, string, error) {
+	for i, token := range tokens {
+		if token != playDataFlag {
+			continue
+		}
+
+		if i+1 >= len(tokens) {
+			return nil, "", fmt.Errorf("please specify a file for '%s'", playDataFlag)
+		}
+		rest := append(append([]string{}, tokens[:i]...), tokens[i+2:]...)
+		return rest, tokens[i+1], nil
+	}
+	return tokens, "", nil
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeDataFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write data file: %v", err)
+	}
+	return path
+}
+
+func TestLoadDataRows(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		wantRows []map[string]string
+		wantErr  string
+	}{
+		{
+			name:    "csv",
+			file:    "users.csv",
+			content: "id, role\n1,admin\n2,\"guest, read only\"\n",
+			wantRows: []map[string]string{
+				{"id": "1", "role": "admin"},
+				{"id": "2", "role": "guest, read only"},
+			},
+		},
+		{
+			name:    "csv header only",
+			file:    "users.csv",
+			content: "id,role\n",
+			wantErr: "has no rows",
+		},
+		{
+			name:    "empty csv",
+			file:    "users.csv",
+			content: "",
+			wantErr: "missing header",
+		},
+		{
+			name:    "ragged csv",
+			file:    "users.csv",
+			content: "id,role\n1,admin,extra\n",
+			wantErr: "wrong number of fields",
+		},
+		{
+			name:    "json",
+			file:    "users.JSON",
+			content: `[{"id": 1, "name": "ann", "tags": ["a", "b"], "admin": true, "boss": null}]`,
+			wantRows: []map[string]string{
+				{"id": "1", "name": "ann", "tags": `["a","b"]`, "admin": "true", "boss": "null"},
+			},
+		},
+		{
+			name:    "json object",
+			file:    "users.json",
+			content: `{"id": 1}`,
+			wantErr: "expected an array of objects",
+		},
+		{
+			name:    "json array of values",
+			file:    "users.json",
+			content: `[1, 2]`,
+			wantErr: "expected an array of objects",
+		},
+		{
+			name:    "empty json array",
+			file:    "users.json",
+			content: `[]`,
+			wantErr: "has no rows",
+		},
+		{
+			name:    "unsupported",
+			file:    "users.yaml",
+			content: "- id: 1",
+			wantErr: "unsupported data file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := loadDataRows(writeDataFile(t, tt.file, tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadDataRows() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("loadDataRows() error = %v", err)
+			}
+			if !slices.EqualFunc(rows, tt.wantRows, maps.Equal) {
+				t.Errorf("loadDataRows() = %v, want %v", rows, tt.wantRows)
+			}
+		})
+	}
+
+	if _, err := loadDataRows(filepath.Join(t.TempDir(), "missing.csv")); err == nil ||
+		!strings.Contains(err.Error(), "failed to read data file") {
+		t.Errorf("expected a missing file to fail, got %v", err)
+	}
+}
+
+func TestExtractDataFlag(t *testing.T) {
+	tests := []struct {
+		name       string
+		tokens     []string
+		wantTokens []string
+		wantPath   string
+		wantErr    bool
+	}{
+		{"none", []string{"login"}, []string{"login"}, "", false},
+		{"after the sequence", []string{"login", "--data", "u.csv"}, []string{"login"}, "u.csv", false},
+		{"before the sequence", []string{"--data", "u.csv", "login"}, []string{"login"}, "u.csv", false},
+		{"no file", []string{"login", "--data"}, nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, path, err := extractDataFlag(tt.tokens)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("extractDataFlag() expected an error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("extractDataFlag() error = %v", err)
+			}
+			if !slices.Equal(tokens, tt.wantTokens) || path != tt.wantPath {
+				t.Errorf(
+					"extractDataFlag() = %q, %q, want %q, %q",
+					tokens,
+					path,
+					tt.wantTokens,
+					tt.wantPath,
+				)
+			}
+		})
+	}
+
+	// The original tokens are left as they were
+	tokens := []string{"--data", "u.csv", "login"}
+	extractDataFlag(tokens)
+	if !slices.Equal(tokens, []string{"--data", "u.csv", "login"}) {
+		t.Errorf("extractDataFlag() modified its tokens: %q", tokens)
+	}
+}
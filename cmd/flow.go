@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shubm-quodes/repl-reqs/network"
+)
+
+// Control flow steps, recorded as blocks closed by '$end' (see CmdRec)
+const (
+	CmdIfName      = "$if"
+	CmdElseName    = "$else"
+	CmdRepeatName  = "$repeat"
+	CmdForeachName = "$foreach"
+	CmdUntilName   = "$until"
+	CmdEndName     = "$end"
+
+	defaultUntilMax = 100
+)
+
+var (
+	flowCmdNames = []string{CmdIfName, CmdRepeatName, CmdForeachName, CmdUntilName}
+	falsyValues  = []string{"", "false", "0", "null"}
+)
+
+// Operators of '$if' & '$until' comparisons, longer ones come first so that '>=' isn't taken as '>'
+var comparisonOps = []struct {
+	token string
+	op    network.Operator
+}{
+	{"==", network.OpEq},
+	{"!=", network.OpNotEq},
+	{">=", network.OpGte},
+	{"<=", network.OpLte},
+	{"~=", network.OpMatches},
+	{" contains ", network.OpContains},
+	{"=", network.OpEq},
+	{">", network.OpGt},
+	{"<", network.OpLt},
+}
+
+/*
+A parsed control flow step:
+  - '$if <condition>' runs its body if the condition holds, its '$else' branch otherwise
+  - '$repeat <n>' runs its body n times
+  - '$foreach <var> in <items>' runs its body once per item, exposing it as '{{var}}'
+  - '$until <condition> [--max n] [--interval d]' runs its body until the condition holds
+*/
+type flow struct {
+	kind     string
+	cond     string
+	count    string
+	varName  string
+	items    string
+	max      int
+	interval time.Duration
+}
+
+// Steps in the order they're numbered in, steps of control flow steps follow them. '{{$N}}' refers
+// to the Nth step of the flattened sequence.
+func (seq Sequence) Flatten() Sequence {
+	var flat Sequence
+	for _, s := range seq {
+		flat = append(flat, s)
+		flat = append(flat, s.Body.Flatten()...)
+		flat = append(flat, s.Else.Flatten()...)
+	}
+	return flat
+}
+
+func (s *Step) isFlow() bool {
+	return len(s.Cmd) > 0 && slices.Contains(flowCmdNames, s.Cmd[0])
+}
+
+func parseFlow(cmd []string) (*flow, error) {
+	if len(cmd) == 0 {
+		return nil, errors.New("empty control flow step")
+	}
+
+	f := &flow{kind: cmd[0]}
+	args := cmd[1:]
+
+	switch f.kind {
+	case CmdIfName:
+		if len(args) == 0 {
+			return nil, fmt.Errorf(
+				"'%s' requires a condition, for instance '%s {{$1.role}}=admin'",
+				CmdIfName,
+				CmdIfName,
+			)
+		}
+		f.cond = strings.Join(args, " ")
+	case CmdRepeatName:
+		if len(args) != 1 {
+			return nil, fmt.Errorf(
+				"'%s' requires the number of times to repeat, for instance '%s 3'",
+				CmdRepeatName,
+				CmdRepeatName,
+			)
+		}
+		f.count = args[0]
+		if !expansionRegex.MatchString(f.count) {
+			if _, err := parseCount(f.count); err != nil {
+				return nil, err
+			}
+		}
+	case CmdForeachName:
+		if len(args) < 3 || args[1] != "in" {
+			return nil, fmt.Errorf(
+				"'%s' requires a variable & the items, for instance '%s id in {{$1.ids}}'",
+				CmdForeachName,
+				CmdForeachName,
+			)
+		}
+		f.varName, f.items = args[0], strings.Join(args[2:], " ")
+	case CmdUntilName:
+		return parseUntil(f, args)
+	default:
+		return nil, fmt.Errorf("'%s' isn't a control flow step", f.kind)
+	}
+	return f, nil
+}
+
+func parseUntil(f *flow, args []string) (*flow, error) {
+	f.max = defaultUntilMax
+
+	var cond []string
+	for i := 0; i < len(args); i++ {
+		if args[i] != "--max" && args[i] != "--interval" {
+			cond = append(cond, args[i])
+			continue
+		}
+
+		if i+1 >= len(args) {
+			return nil, fmt.Errorf("please specify a value for '%s'", args[i])
+		}
+
+		var err error
+		switch flag, val := args[i], args[i+1]; flag {
+		case "--max":
+			if f.max, err = strconv.Atoi(val); err != nil || f.max < 1 {
+				return nil, fmt.Errorf("invalid '--max' value '%s'", val)
+			}
+		case "--interval":
+			if f.interval, err = time.ParseDuration(val); err != nil {
+				return nil, fmt.Errorf("invalid '--interval' value '%s': %w", val, err)
+			}
+		}
+		i++
+	}
+
+	if len(cond) == 0 {
+		return nil, fmt.Errorf(
+			"'%s' requires a condition, for instance '%s $body.status=done --interval 2s'",
+			CmdUntilName,
+			CmdUntilName,
+		)
+	}
+	f.cond = strings.Join(cond, " ")
+	return f, nil
+}
+
+func parseCount(raw string) (int, error) {
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid number of times to repeat '%s'", raw)
+	}
+	return n, nil
+}
+
+// Splits 'left op right' on the first operator that isn't within a '{{...}}' expansion
+func splitComparison(expr string) (string, network.Operator, string, bool) {
+	depth := 0
+	for i := 0; i < len(expr); i++ {
+		switch {
+		case strings.HasPrefix(expr[i:], "{{"):
+			depth++
+			i++
+			continue
+		case strings.HasPrefix(expr[i:], "}}") && depth > 0:
+			depth--
+			i++
+			continue
+		case depth > 0:
+			continue
+		}
+
+		for _, c := range comparisonOps {
+			if strings.HasPrefix(expr[i:], c.token) {
+				left := strings.TrimSpace(expr[:i])
+				right := strings.TrimSpace(expr[i+len(c.token):])
+				return left, c.op, right, true
+			}
+		}
+	}
+	return expr, "", "", false
+}
+
+// Values without a comparison hold unless they're empty, 'false', '0' or 'null'
+func isTruthy(val string) bool {
+	return !slices.Contains(falsyValues, strings.ToLower(strings.TrimSpace(val)))
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shubm-quodes/repl-reqs/network"
+)
+
+func TestParseFlow(t *testing.T) {
+	tests := []struct {
+		cmd     []string
+		want    flow
+		wantErr string
+	}{
+		{
+			cmd:  []string{CmdIfName, "{{$1.role}}", "=", "admin"},
+			want: flow{kind: CmdIfName, cond: "{{$1.role}} = admin"},
+		},
+		{cmd: []string{CmdIfName}, wantErr: "requires a condition"},
+		{cmd: []string{CmdRepeatName, "3"}, want: flow{kind: CmdRepeatName, count: "3"}},
+		{
+			cmd:  []string{CmdRepeatName, "{{$1.count}}"},
+			want: flow{kind: CmdRepeatName, count: "{{$1.count}}"},
+		},
+		{cmd: []string{CmdRepeatName, "-1"}, wantErr: "invalid number of times"},
+		{cmd: []string{CmdRepeatName, "1", "2"}, wantErr: "requires the number of times"},
+		{
+			cmd:  []string{CmdForeachName, "id", "in", "{{$1.ids}}"},
+			want: flow{kind: CmdForeachName, varName: "id", items: "{{$1.ids}}"},
+		},
+		{cmd: []string{CmdForeachName, "id", "of", "1,2"}, wantErr: "requires a variable"},
+		{
+			cmd:  []string{CmdUntilName, "$body.state=done"},
+			want: flow{kind: CmdUntilName, cond: "$body.state=done", max: defaultUntilMax},
+		},
+		{
+			cmd: []string{CmdUntilName, "$body.n", ">", "5", "--max", "3", "--interval", "2s"},
+			want: flow{
+				kind:     CmdUntilName,
+				cond:     "$body.n > 5",
+				max:      3,
+				interval: 2 * time.Second,
+			},
+		},
+		{cmd: []string{CmdUntilName, "--max", "3"}, wantErr: "requires a condition"},
+		{cmd: []string{CmdUntilName, "ok", "--max", "0"}, wantErr: "invalid '--max'"},
+		{cmd: []string{CmdUntilName, "ok", "--interval"}, wantErr: "please specify a value"},
+		{cmd: []string{CmdUntilName, "ok", "--interval", "soon"}, wantErr: "invalid '--interval'"},
+		{cmd: []string{CmdEndName}, wantErr: "isn't a control flow step"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.cmd, " "), func(t *testing.T) {
+			got, err := parseFlow(tt.cmd)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseFlow() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseFlow() error = %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("parseFlow() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitComparison(t *testing.T) {
+	tests := []struct {
+		expr      string
+		wantLeft  string
+		wantOp    network.Operator
+		wantRight string
+		wantOk    bool
+	}{
+		{"{{$1.role}}=admin", "{{$1.role}}", network.OpEq, "admin", true},
+		{"{{$1.count}} > 5", "{{$1.count}}", network.OpGt, "5", true},
+		{"{{$1.count}}>=5", "{{$1.count}}", network.OpGte, "5", true},
+		{"{{$1.count}}<=5", "{{$1.count}}", network.OpLte, "5", true},
+		{"{{$1.role}}!=admin", "{{$1.role}}", network.OpNotEq, "admin", true},
+		{"{{$1.role}}==admin", "{{$1.role}}", network.OpEq, "admin", true},
+		{"{{$1.v}}~=^v1", "{{$1.v}}", network.OpMatches, "^v1", true},
+		{"{{$1.tags}} contains beta", "{{$1.tags}}", network.OpContains, "beta", true},
+		{"{{$1.items | length}}>=3", "{{$1.items | length}}", network.OpGte, "3", true},
+		{"{{$1.items[] | select(.n > 1)}}", "{{$1.items[] | select(.n > 1)}}", "", "", false},
+		{"{{ready}}", "{{ready}}", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			left, op, right, ok := splitComparison(tt.expr)
+			if left != tt.wantLeft || op != tt.wantOp || right != tt.wantRight || ok != tt.wantOk {
+				t.Errorf(
+					"splitComparison() = %q %q %q %v, want %q %q %q %v",
+					left, op, right, ok,
+					tt.wantLeft, tt.wantOp, tt.wantRight, tt.wantOk,
+				)
+			}
+		})
+	}
+}
+
+func TestIsTruthy(t *testing.T) {
+	tests := map[string]bool{
+		"":      false,
+		"false": false,
+		"FALSE": false,
+		" 0 ":   false,
+		"null":  false,
+		"true":  true,
+		"1":     true,
+		"no":    true,
+		"[]":    true,
+	}
+
+	for val, want := range tests {
+		if got := isTruthy(val); got != want {
+			t.Errorf("isTruthy(%q) = %v, want %v", val, got, want)
+		}
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	seq := Sequence{
+		{Name: "step #1", Cmd: []string{"get", "users"}},
+		{
+			Name: "step #2",
+			Cmd:  []string{CmdForeachName, "u", "in", "{{$1}}"},
+			Body: Sequence{
+				{
+					Name: "step #3",
+					Cmd:  []string{CmdIfName, "{{u.admin}}"},
+					Body: Sequence{{Name: "step #4", Cmd: []string{"get", "admin"}}},
+					Else: Sequence{{Name: "step #5", Cmd: []string{"get", "guest"}}},
+				},
+			},
+		},
+		{Name: "step #6", Cmd: []string{"get", "done"}},
+	}
+
+	names := make([]string, 0, 6)
+	for _, step := range seq.Flatten() {
+		names = append(names, step.Name)
+	}
+
+	want := []string{"step #1", "step #2", "step #3", "step #4", "step #5", "step #6"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Flatten() = %q, want %q", names, want)
+	}
+
+	if !seq[1].isFlow() || seq[0].isFlow() {
+		t.Errorf("expected only control flow steps to be flows")
+	}
+}
@@ -95,7 +95,10 @@ type ReplCmdHandler struct {
 	cmdRegistry           *CmdRegistry
 	natvieCmdRegistry     *CmdRegistry
 	listeners             KeyListenerRegistry
-	rl                    *readline.Instance
+	bootstrapHooks        []func()
+	rl                    *readline.Instance // nil for headless handlers
+	out                   io.Writer          // Headless handlers write here instead of the shell
+	errOut                io.Writer
 	modes                 []*CmdMode
 	mu                    sync.Mutex
 	pauseSuggestions      bool
@@ -113,12 +116,8 @@ type ReplCmdHandler struct {
 	bgTaskIdChan          chan string
 }
 
-func NewCmdHandler(
-	appCfg *config.AppCfg,
-	rlCfg *readline.Config,
-	reg *CmdRegistry,
-) (*ReplCmdHandler, error) {
-	cmh := &ReplCmdHandler{
+func newCmdHandler(appCfg *config.AppCfg, reg *CmdRegistry) *ReplCmdHandler {
+	return &ReplCmdHandler{
 		defaultCtx:   context.WithValue(context.Background(), CmdCtxIdKey, uuid.NewString()),
 		appCfg:       appCfg,
 		modes:        make([]*CmdMode, 0),
@@ -130,6 +129,14 @@ func NewCmdHandler(
 		cmdRegistry:  reg,
 		spinner:      spinner.New(spinner.CharSets[14], 100*time.Millisecond),
 	}
+}
+
+func NewCmdHandler(
+	appCfg *config.AppCfg,
+	rlCfg *readline.Config,
+	reg *CmdRegistry,
+) (*ReplCmdHandler, error) {
+	cmh := newCmdHandler(appCfg, reg)
 
 	rlCfg.KeyListeners = make(map[rune]readline.FuncKeypressHandler)
 	rlCfg.KeyListeners[0x06] = func() bool {
@@ -151,6 +158,22 @@ func NewCmdHandler(
 	}
 }
 
+// Handler for running cmds without a terminal (see RunScript), there's no shell, prompt or spinner.
+// Output goes to out & errors to errOut.
+func NewHeadlessCmdHandler(
+	appCfg *config.AppCfg,
+	reg *CmdRegistry,
+	out, errOut io.Writer,
+) *ReplCmdHandler {
+	cmh := newCmdHandler(appCfg, reg)
+	cmh.out, cmh.errOut = out, errOut
+	return cmh
+}
+
+func (h *ReplCmdHandler) isHeadless() bool {
+	return h.rl == nil
+}
+
 func NewCmdCtx(ctx context.Context, tokens []string, taskUpdater TaskUpdater) *CmdCtx {
 	return &CmdCtx{
 		Ctx:       ctx,
@@ -316,6 +339,26 @@ func (h *ReplCmdHandler) SuggestSequences(partial string) [][]rune {
 	return util.GetMatchingMapKeysAsRunes(criteria)
 }
 
+// Suggests ids of the tasks that are still running
+func (h *ReplCmdHandler) SuggestTasks(partial string) [][]rune {
+	h.mu.Lock()
+	running := make(map[string]*Task)
+	for id, task := range h.tasks {
+		if status := task.GetStatus(); !status.Done && status.Error == nil {
+			running[id] = task
+		}
+	}
+	h.mu.Unlock()
+
+	criteria := &util.MatchCriteria[*Task]{
+		Search:     partial,
+		SuffixWith: " ",
+		M:          running,
+	}
+
+	return util.GetMatchingMapKeysAsRunes(criteria)
+}
+
 func (h *ReplCmdHandler) Suggest(tokens [][]rune) ([][]rune, int) {
 	if len(tokens) == 0 {
 		return nil, 0
@@ -436,7 +479,11 @@ func (h *ReplCmdHandler) isCmdEligibleForMode(cmd Cmd, args []string) bool {
 
 func (h *ReplCmdHandler) HandleSyncCmdResult(cmdCtx *CmdCtx, err error) {
 	if err != nil {
-		h.Out(cmdCtx, color.HiRedString(err.Error()))
+		// Errors make it to the terminal even when the output is redirected, headless handlers leave
+		// them to whoever runs the cmd (see RunScript)
+		if h.isPrintable(cmdCtx) && !h.isHeadless() {
+			h.println(color.HiRedString(err.Error()))
+		}
 	} else if strings.Trim(cmdCtx.Task.GetOutput(), "") != "" {
 		h.Out(cmdCtx, cmdCtx.Task.GetOutput())
 	}
@@ -472,12 +519,72 @@ func (h *ReplCmdHandler) HandleCmd(
 	ctx context.Context,
 	tokens []string,
 ) (context.Context, error) {
+	tokens, r := splitRedirect(tokens, h.exprStart)
 	rootCmd, remainingTokens, err := h.GetRootCmd(tokens)
 	if err != nil {
 		return ctx, err
 	}
 
-	return h.executeCommand(ctx, rootCmd, remainingTokens)
+	if r == nil {
+		return h.executeCommand(ctx, rootCmd, remainingTokens)
+	}
+	return h.executeRedirectedCommand(ctx, rootCmd, remainingTokens, r)
+}
+
+// Index of the token the cmd's expression starts at, -1 if it doesn't take one (see splitRedirect)
+func (h *ReplCmdHandler) exprStart(tokens []string) int {
+	if partial, ok := h.GetCurrentModeCmd().(PartialExprCmd); ok {
+		return partial.ExprStart(tokens)
+	}
+
+	cmd := h.GetCurrModesInModeCmdByName(tokens[0])
+	if cmd == nil {
+		cmd = h.GetCmdByName(tokens[0])
+	}
+
+	if exprCmd, ok := cmd.(ExprCmd); ok && exprCmd.TakesExprs() {
+		return 1
+	}
+
+	finalCmd, args := h.ResolveCommand(cmd, tokens[1:])
+	if partial, ok := finalCmd.(PartialExprCmd); ok {
+		if start := partial.ExprStart(args); start >= 0 {
+			return len(tokens) - len(args) + start
+		}
+	}
+	return -1
+}
+
+// Output of sync cmds is captured & redirected right away, async cmds are redirected once their
+// task completes (see handleTaskUpdate)
+func (h *ReplCmdHandler) executeRedirectedCommand(
+	ctx context.Context,
+	rootCmd Cmd,
+	remainingTokens []string,
+	r *redirect,
+) (context.Context, error) {
+	ctx, err := h.executeCommand(context.WithValue(ctx, redirectKey, r), rootCmd, remainingTokens)
+
+	// Contexts returned by cmds are passed on to the next ones (in sequences), they mustn't be
+	// redirected as well
+	ctx = context.WithValue(ctx, redirectKey, (*redirect)(nil))
+	if err != nil || r.isAsync {
+		return ctx, err
+	}
+
+	if err := h.applyRedirect(r, r.capturedOutput()); err != nil {
+		h.println(color.HiRedString(err.Error()))
+		return ctx, err
+	}
+	return ctx, nil
+}
+
+func (h *ReplCmdHandler) applyRedirect(r *redirect, output string) error {
+	printed, err := r.apply(output)
+	if printed != "" {
+		h.print(printed)
+	}
+	return err
 }
 
 func (h *ReplCmdHandler) HandleRootCmd(
@@ -527,26 +634,95 @@ func (h *ReplCmdHandler) HandleAsyncCmd(
 ) (context.Context, error) {
 	task := h.CreateTask(TaskStatusInitiated+" 🕙", cmd.GetFullyQualifiedName())
 	taskCtx, cancel := context.WithCancel(ctx)
-	defer cancel()
+	task.setCancelFunc(cancel)
 
 	cmdCtx := NewCmdCtx(taskCtx, tokens, task)
 	cmdCtx.ExpandedTokens = tokens
 
-	h.rl.SaveHistory(cmd.GetFullyQualifiedName() + " " + strings.Join(tokens, " "))
+	if !h.isHeadless() {
+		h.rl.SaveHistory(cmd.GetFullyQualifiedName() + " " + strings.Join(tokens, " "))
+	}
+
+	r, isRedirected := redirectFromCtx(ctx)
+	if isRedirected {
+		r.isAsync = true
+	}
+
 	if h.isSeqStepCtx(ctx) {
 		h.HandleAsyncSeqStep(cmd, cmdCtx)
+		cancel()
+
+		if step, ok := StepFromCtx(ctx); ok && isRedirected && !step.HasFailed {
+			output := taskOutputForRedirect(&TaskStatus{
+				Result: step.Task.GetResult(),
+				Output: step.Task.GetOutput(),
+			})
+			if err := h.applyRedirect(r, output); err != nil {
+				return ctx, err
+			}
+		}
+	} else if h.isHeadless() {
+		// There's no shell to get back to, so the task is awaited and its failure reported
+		task.redirect = r
+		h.currFgTaskId = task.status.ID
+		go func() {
+			defer cancel()
+			cmd.ExecuteAsync(cmdCtx)
+		}()
+
+		if status := task.wait(); status.Error != nil {
+			return ctx, fmt.Errorf("task %s (%s) failed", status.ID, status.Cmd)
+		}
 	} else {
+		task.redirect = r
 		h.currFgTaskId = task.status.ID
 		h.spinner.Start()
 		h.spinner.Suffix = task.status.Message
 		go func() {
 			defer h.spinner.Stop()
+			defer cancel()
 
 			cmd.ExecuteAsync(cmdCtx)
 		}()
 	}
 
-	return taskCtx, nil
+	// The task's ctx is only meant for the task itself, it gets cancelled as soon as the task ends.
+	return ctx, nil
+}
+
+func (h *ReplCmdHandler) getTask(id string) (*Task, error) {
+	if !strings.HasPrefix(id, "#") {
+		id = "#" + id
+	}
+
+	h.mu.Lock()
+	task, exists := h.tasks[id]
+	h.mu.Unlock()
+
+	if !exists {
+		return nil, fmt.Errorf("task '%s' not found", id)
+	}
+	return task, nil
+}
+
+func (h *ReplCmdHandler) GetTaskStatus(id string) (TaskStatus, error) {
+	task, err := h.getTask(id)
+	if err != nil {
+		return TaskStatus{}, err
+	}
+	return task.GetStatus(), nil
+}
+
+func (h *ReplCmdHandler) CancelTask(id string) error {
+	task, err := h.getTask(id)
+	if err != nil {
+		return err
+	}
+
+	if !task.Cancel() {
+		return fmt.Errorf("task '%s' isn't running anymore", id)
+	}
+	return nil
 }
 
 func (h *ReplCmdHandler) handleUpdateChanClose() {
@@ -563,12 +739,38 @@ func (h *ReplCmdHandler) handleTaskUpdate(statusUpdate *TaskStatus) {
 	defer h.mu.Unlock()
 
 	task := h.findOrCreateTask(statusUpdate.ID)
+	task.mu.Lock() // The task's cmd may still be updating it, as headless runs await it
 	h.updateTaskStatus(&task.status, statusUpdate)
+	task.mu.Unlock()
+
+	if r := task.redirect; r != nil && statusUpdate.Done && statusUpdate.Error == nil {
+		task.redirect = nil
+		output := taskOutputForRedirect(statusUpdate)
+		statusUpdate.Output = "output " + r.describe()
+
+		apply := func() {
+			if err := h.applyRedirect(r, output); err != nil {
+				h.errorln(color.HiRedString(err.Error()))
+			}
+			h.RefreshPrompt()
+		}
+
+		// Headless runs may end as soon as the task does, so the output has to be in place by then
+		if h.isHeadless() {
+			apply()
+		} else {
+			go apply()
+		}
+	}
 	h.handleTaskCompletionOrError(statusUpdate)
 
 	if h.currFgTaskId != "" {
 		h.updateSpinnerMsg(&task.status)
 	}
+
+	if statusUpdate.Done || statusUpdate.Error != nil {
+		task.markDone()
+	}
 }
 
 func (h *ReplCmdHandler) findOrCreateTask(id string) *Task {
@@ -602,6 +804,10 @@ func (h *ReplCmdHandler) handleSuccessTaskStatus(status *TaskStatus) {
 	const lineClear = "                                                                                " // 80 spaces
 
 	duration := FormatDuration(time.Since(status.CreatedAt))
+	if h.isHeadless() {
+		h.printf("✅ Task completed (in: %s)\n %s\n", duration, status.Output)
+		return
+	}
 	h.printf("\r%s\r✅ Task completed (in: %s)\n %s\n", lineClear, duration, status.Output)
 	h.RefreshPrompt()
 }
@@ -609,14 +815,21 @@ func (h *ReplCmdHandler) handleSuccessTaskStatus(status *TaskStatus) {
 func (h *ReplCmdHandler) handleFailedTaskStatus(task *TaskStatus) {
 	h.resetTaskState()
 
-	h.println("❌ Task failed")
+	h.errorln("❌ Task failed")
 	msg := task.Error.Error()
 
 	if task.Output != "" {
 		msg = task.Output
 	}
 
-	h.println(color.HiRedString(msg))
+	h.errorln(color.HiRedString(msg))
+	h.RefreshPrompt()
+}
+
+func (h *ReplCmdHandler) handleCancelledTaskStatus(status *TaskStatus) {
+	h.resetTaskState()
+
+	h.printf("🚫 Task %s %s\n", status.ID, TaskStatusCancelled)
 	h.RefreshPrompt()
 }
 
@@ -628,10 +841,12 @@ func (h *ReplCmdHandler) handleTaskCompletionOrError(status *TaskStatus) {
 	if h.currFgTaskId == status.ID {
 		if status.Error == nil {
 			h.handleSuccessTaskStatus(status)
+		} else if status.Cancelled {
+			h.handleCancelledTaskStatus(status)
 		} else {
 			h.handleFailedTaskStatus(status)
 		}
-		h.rl.Refresh()
+		h.RefreshPrompt()
 	}
 }
 
@@ -693,7 +908,9 @@ func (h *ReplCmdHandler) ListTasks() {
 
 func (h *ReplCmdHandler) PrintFormattedTaskStatus(status *TaskStatus) {
 	formatStr := "\n%s %s ~ %s"
-	if status.Error != nil {
+	if status.Cancelled {
+		formatStr = formatStr + "🚫"
+	} else if status.Error != nil {
 		formatStr = formatStr + "❌"
 	} else if status.Done {
 		formatStr = formatStr + "✅"
@@ -721,7 +938,7 @@ func (h *ReplCmdHandler) sendTaskToBg() {
 		h.currFgTaskId = ""
 		h.spinner.Stop()
 		h.printf("task '%s' sent to background\n", taskId)
-		h.rl.Refresh()
+		h.RefreshPrompt()
 	}
 }
 
@@ -827,7 +1044,7 @@ func (h *ReplCmdHandler) refreshPersistedSequences() error {
 func (h *ReplCmdHandler) SaveSequenceStep(seqName string, s *Step) error {
 	if seq, exists := h.sequenceRegistry[seqName]; exists {
 		if s.Name == "" {
-			s.Name = fmt.Sprintf("step #%d", len(seq)+1)
+			s.Name = fmt.Sprintf("step #%d", len(seq.Flatten())+1)
 		}
 		seq = append(seq, s)
 		h.sequenceRegistry[seqName] = seq
@@ -847,7 +1064,9 @@ func (h *ReplCmdHandler) GetSequence(name string) (Sequence, error) {
 }
 
 func (h *ReplCmdHandler) SetPrompt(newPrompt string, mascot string) {
-	h.rl.SetPrompt(FormatPrompt(newPrompt, mascot))
+	if !h.isHeadless() {
+		h.rl.SetPrompt(FormatPrompt(newPrompt, mascot))
+	}
 }
 
 func (h *ReplCmdHandler) SetIsRecMode(is bool) {
@@ -855,12 +1074,13 @@ func (h *ReplCmdHandler) SetIsRecMode(is bool) {
 }
 
 func (h *ReplCmdHandler) RefreshPrompt() {
-	h.rl.Refresh()
+	if !h.isHeadless() {
+		h.rl.Refresh()
+	}
 }
 
 func (h *ReplCmdHandler) UpdatePromptEnv() {
-	def := h.appCfg.GetPrompt()
-	h.rl.SetPrompt(FormatPrompt(def, ""))
+	h.SetPrompt(h.appCfg.GetPrompt(), "")
 }
 
 func (h *ReplCmdHandler) ExitCmdMode() (quitShell bool) {
@@ -892,7 +1112,11 @@ func (h *ReplCmdHandler) repl() {
 		line, err := h.rl.Readline()
 
 		if err == readline.ErrInterrupt {
-			h.bgTaskIdChan <- h.currFgTaskId
+			if h.currFgTaskId != "" {
+				h.CancelTask(h.currFgTaskId)
+			} else {
+				h.bgTaskIdChan <- h.currFgTaskId
+			}
 		} else if err == io.EOF {
 			quitShell := h.ExitCmdMode()
 			if quitShell {
@@ -929,6 +1153,10 @@ func (h *ReplCmdHandler) injectIntoCmds(reg map[string]Cmd) {
 }
 
 func (h *ReplCmdHandler) activateListeners() {
+	if h.isHeadless() {
+		return
+	}
+
 	for _, lsnr := range h.listeners {
 		rlCfg := h.rl.Config
 		if rlCfg.KeyListeners == nil {
@@ -938,47 +1166,87 @@ func (h *ReplCmdHandler) activateListeners() {
 	}
 }
 
+func (h *ReplCmdHandler) write(b []byte) {
+	if h.isHeadless() {
+		// Highlighted output is of no use when piped or logged
+		if color.NoColor {
+			b = []byte(util.StripAnsi(string(b)))
+		}
+		h.out.Write(b)
+	} else {
+		h.rl.Write(b)
+	}
+}
+
 func (h *ReplCmdHandler) printf(formatStr string, a ...any) {
-	s := fmt.Sprintf(formatStr, a...)
-	h.rl.Write([]byte(s))
+	h.write([]byte(fmt.Sprintf(formatStr, a...)))
 }
 
 func (h *ReplCmdHandler) print(s string) {
-	h.rl.Write([]byte(s))
+	h.write([]byte(s))
 }
 
-func (h *ReplCmdHandler) Out(cmdCtx *CmdCtx, str string) {
-	isPrintable := h.GetDefaultCtxId() == cmdCtx.ID() ||
-		h.currFgTaskId == cmdCtx.Task.GetId()
+func (h *ReplCmdHandler) isPrintable(cmdCtx *CmdCtx) bool {
+	return h.GetDefaultCtxId() == cmdCtx.ID() || h.currFgTaskId == cmdCtx.Task.GetId()
+}
 
-	if isPrintable {
+func (h *ReplCmdHandler) Out(cmdCtx *CmdCtx, str string) {
+	if r, ok := redirectFromCtx(cmdCtx.Ctx); ok {
+		r.capture(str + "\n")
+	} else if h.isPrintable(cmdCtx) {
 		h.println(str)
 	}
 }
 
 func (h *ReplCmdHandler) OutF(cmdCtx *CmdCtx, formatStr string, a ...any) {
-	isPrintable := h.GetDefaultCtxId() == cmdCtx.ID() ||
-		h.currFgTaskId == cmdCtx.Task.GetId()
-
-	if isPrintable {
+	if r, ok := redirectFromCtx(cmdCtx.Ctx); ok {
+		r.capture(fmt.Sprintf(formatStr, a...))
+	} else if h.isPrintable(cmdCtx) {
 		h.printf(formatStr, a...)
 	}
 }
 
 func (h *ReplCmdHandler) println(s string) {
-	h.rl.Write(append([]byte(s), '\n'))
+	h.write(append([]byte(s), '\n'))
 }
 
-func (h *ReplCmdHandler) Bootstrap(omitSysCmds bool) {
+// Same as println, except that headless handlers write to errOut
+func (h *ReplCmdHandler) errorln(s string) {
+	if h.isHeadless() {
+		fmt.Fprintln(h.errOut, s)
+	} else {
+		h.println(s)
+	}
+}
+
+func (h *ReplCmdHandler) setup(omitSysCmds bool) {
 	if !omitSysCmds {
 		h.registerNativeCmds()
 	}
 	h.injectIntoReg()
 	h.activateListeners()
 	h.loadSequences()
+	for _, hook := range h.bootstrapHooks {
+		hook()
+	}
+}
+
+func (h *ReplCmdHandler) Bootstrap(omitSysCmds bool) {
+	h.setup(omitSysCmds)
 	h.repl()
 }
 
+// Gets a headless handler ready for RunScript
+func (h *ReplCmdHandler) BootstrapHeadless(omitSysCmds bool) {
+	h.setup(omitSysCmds)
+	go h.listenForTaskUpdates()
+}
+
+// Hooks run once everything's in place, right before the shell starts reading input
+func (h *ReplCmdHandler) OnBootstrap(hook func()) {
+	h.bootstrapHooks = append(h.bootstrapHooks, hook)
+}
+
 func (h *ReplCmdHandler) injectIntoReg() {
 	if h.cmdRegistry == nil {
 		panic("injection failed, handler registery not initialized")
@@ -1017,7 +1285,13 @@ func (h *ReplCmdHandler) registerNativeCmds() {
 
 	rec.AddInModeCmd(&CmdIsEq{NewBaseCmd(CmdIsEqName, "")}).
 		AddInModeCmd(&CmdPlayStep{NewBaseCmd(CmdPlayStepName, "")}).
-		AddInModeCmd(&CmdFinalizeRec{NewBaseCmd(CmdFinalizeRecName, "")})
+		AddInModeCmd(&CmdFinalizeRec{NewBaseCmd(CmdFinalizeRecName, "")}).
+		AddInModeCmd(&CmdFlow{NewBaseNonModeCmd(CmdIfName, "")}).
+		AddInModeCmd(&CmdFlow{NewBaseNonModeCmd(CmdRepeatName, "")}).
+		AddInModeCmd(&CmdFlow{NewBaseNonModeCmd(CmdForeachName, "")}).
+		AddInModeCmd(&CmdFlow{NewBaseNonModeCmd(CmdUntilName, "")}).
+		AddInModeCmd(&CmdElse{NewBaseNonModeCmd(CmdElseName, "")}).
+		AddInModeCmd(&CmdEnd{NewBaseNonModeCmd(CmdEndName, "")})
 
 	play := &CmdPlay{BaseCmd: NewBaseCmd(CmdPlayName, "")}
 	h.GetCmdRegistry().RegisterCmd(rec, play)
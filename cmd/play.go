@@ -4,9 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/shubm-quodes/repl-reqs/config"
+	"github.com/shubm-quodes/repl-reqs/network"
 	"github.com/shubm-quodes/repl-reqs/util"
 )
 
@@ -26,8 +31,12 @@ type CmdPlay struct {
 func (pl *CmdPlay) ExecuteAsync(cmdCtx *CmdCtx) {
 	hdlr := pl.GetCmdHandler()
 	task := cmdCtx.Task
-	tokens := cmdCtx.ExpandedTokens
-	stepUChan := make(chan TaskStatus, 1)
+
+	tokens, dataPath, err := extractDataFlag(cmdCtx.ExpandedTokens)
+	if err != nil {
+		task.Fail(err)
+		return
+	}
 
 	if len(tokens) == 0 {
 		task.Fail(errors.New("please specify sequence name"))
@@ -42,76 +51,457 @@ func (pl *CmdPlay) ExecuteAsync(cmdCtx *CmdCtx) {
 		return
 	}
 
+	if dataPath != "" {
+		pl.playRows(cmdCtx, sequenceName, seq, dataPath)
+		return
+	}
+
+	execErr := pl.playSequence(cmdCtx, seq, nil, "")
+	seqReportFromCtx(cmdCtx.Ctx).finish()
+
+	if errors.Is(execErr, context.Canceled) {
+		task.Fail(fmt.Errorf("sequence '%s' cancelled: %w", sequenceName, execErr))
+		return
+	} else if execErr != nil {
+		task.Fail(
+			fmt.Errorf("sequence '%s' failed at step: %w", sequenceName, execErr),
+		)
+		return
+	}
+
+	task.AppendOutput(fmt.Sprintf("sequence '%s' successfully completed\n", sequenceName))
+	task.Complete(nil)
+}
+
+// Plays the sequence once per row of the data file, a failing row doesn't stop the rest of them
+func (pl *CmdPlay) playRows(cmdCtx *CmdCtx, sequenceName string, seq Sequence, dataPath string) {
+	task := cmdCtx.Task
+	rows, err := loadDataRows(dataPath)
+	if err != nil {
+		task.Fail(err)
+		return
+	}
+
+	report := seqReportFromCtx(cmdCtx.Ctx)
+	defer report.finish()
+
+	var failed int
+	for idx, row := range rows {
+		label := fmt.Sprintf("row %d", idx+1)
+		execErr := pl.playSequence(cmdCtx, seq, row, label)
+		report.recordRow(idx+1, execErr)
+
+		if err := cmdCtx.Ctx.Err(); err != nil {
+			task.Fail(fmt.Errorf("sequence '%s' cancelled at %s: %w", sequenceName, label, err))
+			return
+		}
+
+		if execErr != nil {
+			failed++
+			task.AppendOutput(fmt.Sprintf("❌ %s: %s", label, execErr))
+		} else {
+			task.AppendOutput(fmt.Sprintf("✅ %s", label))
+		}
+	}
+
+	if failed > 0 {
+		task.Fail(
+			fmt.Errorf("sequence '%s' failed for %d of %d row(s)", sequenceName, failed, len(rows)),
+		)
+		return
+	}
+
+	task.AppendOutput(
+		fmt.Sprintf("sequence '%s' successfully completed for %d row(s)\n", sequenceName, len(rows)),
+	)
+	task.Complete(nil)
+}
+
+// Plays the sequence through, the row's columns are available as variables to its steps
+func (pl *CmdPlay) playSequence(
+	cmdCtx *CmdCtx,
+	seq Sequence,
+	row map[string]string,
+	rowLabel string,
+) error {
+	report := seqReportFromCtx(cmdCtx.Ctx)
 	errChan := make(chan error)
 	go func() {
 		defer close(errChan)
 
-		var execErr error
-		seqCtx := context.Background()
+		// Steps chain their own contexts, cancelling '$play' stops the sequence and its current step.
+		seqCtx, stopSeq := context.WithCancel(context.Background())
+		defer stopSeq()
+		stop := context.AfterFunc(cmdCtx.Ctx, stopSeq)
+		defer stop()
+
 		seqCtx = context.WithValue(seqCtx, SeqModeIndicatorKey, true)
-		stepCtx := seqCtx
-		seq = pl.cloneSequence(seq)
-		for idx, step := range seq {
-			step.uChan = stepUChan
-			step.Task = NewTask(
-				fmt.Sprintf("%v #step", idx),
-				strings.Join(step.Cmd, " "),
-				stepUChan,
-			)
-			step.sequenceErrChan = errChan
-			stepCtx = context.WithValue(stepCtx, StepKey, step)
-			var expandedCmd []string
-			if expandedCmd, execErr = step.ExpandTokens(seq, config.GetEnvManager().GetActiveEnvVars()); execErr != nil {
-				break
-			}
+		p := newSeqPlayer(pl.GetCmdHandler(), cmdCtx.Task, seqCtx, seq, report)
+		p.uChan, p.errChan = make(chan TaskStatus, 1), errChan
+		p.row = rowLabel
+		maps.Copy(p.vars, row)
+
+		execErr := p.run(seq)
+		if execErr != nil {
+			report.recordSkipped(p.unplayed())
+		}
+		if errors.Is(execErr, errStepFailed) {
+			execErr = nil // Failing async steps report through errChan themselves
+		}
+		errChan <- execErr
+	}()
+
+	execErr := <-errChan
+	// Failing async steps report through errChan as well, in which case the sequence's own result
+	// is still to come. Draining it also makes sure the report is complete.
+	for range errChan {
+	}
+	return execErr
+}
+
+// Signals an async step's failure, which the step reports on its own (see Step.watchForUpdates)
+var errStepFailed = errors.New("step failed")
+
+/*
+Plays a sequence's steps along with those of its control flow steps. Every run of a step gets a
+fresh copy of the recorded step, '{{$N}}' refers to the latest run of the Nth (flattened) step.
+Steps run within loops are named after their iteration, as in 'step #3 [2]'.
+*/
+type seqPlayer struct {
+	hdlr    CmdHandler
+	task    TaskUpdater
+	ctx     context.Context
+	stepCtx context.Context
+	report  *SequenceReport
+	uChan   chan TaskStatus
+	errChan chan error
+
+	steps      Sequence      // Recorded steps, flattened
+	latest     Sequence      // Latest run of each of the steps
+	index      map[*Step]int // Position of the recorded steps in steps
+	played     []bool
+	current    int
+	prev       *Step
+	vars       map[string]string // Data row & loop variables, on top of the active env's
+	iterations []int
+	row        string // Data row being played, if any (see CmdPlay.playRows)
+}
+
+func newSeqPlayer(
+	hdlr CmdHandler,
+	task TaskUpdater,
+	ctx context.Context,
+	seq Sequence,
+	report *SequenceReport,
+) *seqPlayer {
+	steps := seq.Flatten()
+	p := &seqPlayer{
+		hdlr:    hdlr,
+		task:    task,
+		ctx:     ctx,
+		stepCtx: ctx,
+		report:  report,
+		steps:   steps,
+		latest:  slices.Clone(steps),
+		index:   make(map[*Step]int, len(steps)),
+		played:  make([]bool, len(steps)),
+		vars:    make(map[string]string),
+	}
+	for idx, step := range steps {
+		p.index[step] = idx
+	}
+	return p
+}
+
+func (p *seqPlayer) run(seq Sequence) error {
+	for _, step := range seq {
+		if err := p.ctx.Err(); err != nil {
+			return err
+		}
+
+		var err error
+		if step.isFlow() {
+			err = p.runFlowStep(step)
+		} else {
+			err = p.runStep(step)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-			task.UpdateMessage(
-				fmt.Sprintf(
-					"step %d: %s",
-					idx+1,
-					util.GetTruncatedStr(strings.Join(expandedCmd, " ")),
-				),
-			)
+func (p *seqPlayer) runStep(recorded *Step) error {
+	idx := p.enter(recorded)
+	step := &Step{
+		Name:            p.label(recorded.Name),
+		Cmd:             recorded.Cmd,
+		uChan:           p.uChan,
+		sequenceErrChan: p.errChan,
+	}
+	step.Task = NewTask(fmt.Sprintf("%v #step", idx), strings.Join(step.Cmd, " "), p.uChan)
+	p.latest[idx] = step
+	p.stepCtx = context.WithValue(p.stepCtx, StepKey, step)
+
+	expandedCmd, err := step.ExpandTokens(p.latest, p.variables())
+	if err != nil {
+		p.report.recordStep(step, 0, err)
+		return err
+	}
+
+	p.task.UpdateMessage(
+		fmt.Sprintf("step %d: %s", idx+1, util.GetTruncatedStr(strings.Join(expandedCmd, " "))),
+	)
+
+	step.ParentStep = p.prev
+	p.prev = step
+
+	start := time.Now()
+	p.stepCtx, err = p.hdlr.HandleCmd(p.stepCtx, expandedCmd)
+	p.report.recordStep(step, time.Since(start), err)
+	if err == nil && step.HasFailed { // Has failed checks for async cmds
+		err = errStepFailed
+	}
+	return err
+}
 
-			if idx > 0 {
-				step.ParentStep = seq[idx-1]
+func (p *seqPlayer) runFlowStep(recorded *Step) error {
+	idx := p.enter(recorded)
+	p.task.UpdateMessage(
+		fmt.Sprintf("step %d: %s", idx+1, util.GetTruncatedStr(strings.Join(recorded.Cmd, " "))),
+	)
+
+	f, err := parseFlow(recorded.Cmd)
+	if err != nil {
+		return p.flowFailed(recorded, err)
+	}
+
+	switch f.kind {
+	case CmdIfName:
+		holds, err := p.evalCondition(f.cond)
+		if err != nil {
+			return p.flowFailed(recorded, err)
+		}
+		if holds {
+			return p.run(recorded.Body)
+		}
+		return p.run(recorded.Else)
+	case CmdRepeatName:
+		count, err := p.expand(f.count)
+		if err == nil {
+			var n int
+			if n, err = parseCount(count); err == nil {
+				return p.loop(n, recorded.Body, nil)
 			}
+		}
+		return p.flowFailed(recorded, err)
+	case CmdForeachName:
+		items, err := p.loopItems(f.items)
+		if err != nil {
+			return p.flowFailed(recorded, err)
+		}
+		return p.loop(len(items), recorded.Body, func(i int) {
+			p.setLoopVars(f.varName, items[i])
+		})
+	default:
+		return p.runUntil(recorded, f)
+	}
+}
 
-			stepCtx, execErr = hdlr.HandleCmd(stepCtx, expandedCmd)
-			if execErr != nil || step.HasFailed { // Has failed checks for async cmds
-				break
+// Runs the body until the condition holds, checking it after every iteration
+func (p *seqPlayer) runUntil(recorded *Step, f *flow) error {
+	for i := range f.max {
+		if i > 0 && f.interval > 0 {
+			select {
+			case <-p.ctx.Done():
+				return p.ctx.Err()
+			case <-time.After(f.interval):
 			}
 		}
-		errChan <- execErr
-	}()
 
-	execErr := <-errChan
+		p.iterations = append(p.iterations, i+1)
+		err := p.run(recorded.Body)
+		var holds bool
+		if err == nil {
+			if holds, err = p.evalCondition(f.cond); err != nil {
+				err = p.flowFailed(recorded, err)
+			}
+		}
+		p.iterations = p.iterations[:len(p.iterations)-1]
 
-	if execErr != nil {
-		task.Fail(
-			fmt.Errorf("sequence '%s' failed at step: %w", sequenceName, execErr),
-		)
-		return
+		if err != nil || holds {
+			return err
+		}
 	}
 
-	task.AppendOutput(fmt.Sprintf("sequence '%s' successfully completed\n", sequenceName))
-	task.Complete(nil)
+	return p.flowFailed(
+		recorded,
+		fmt.Errorf("condition '%s' wasn't met after %d iteration(s)", f.cond, f.max),
+	)
+}
+
+// Runs the body n times, before each iteration is called with its index
+func (p *seqPlayer) loop(n int, body Sequence, before func(i int)) error {
+	saved := p.vars
+	defer func() { p.vars = saved }()
+
+	for i := range n {
+		p.vars = maps.Clone(saved)
+		if before != nil {
+			before(i)
+		}
+
+		p.iterations = append(p.iterations, i+1)
+		err := p.run(body)
+		p.iterations = p.iterations[:len(p.iterations)-1]
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (pl *CmdPlay) cloneSequence(originalSeq []*Step) []*Step {
-	clonedSeq := make([]*Step, len(originalSeq))
+/*
+Items to iterate over, a sole '{{$N...}}' reference yields its values as they are (the items of an
+array, objects included). Anything else is expanded & split by ','.
+*/
+func (p *seqPlayer) loopItems(expr string) ([]any, error) {
+	if m := expansionRegex.FindStringSubmatch(expr); m != nil && m[0] == expr &&
+		stepExpansionRegex.MatchString(m[1]) {
+		return (&Step{}).stepValues(m[1], p.latest)
+	}
+
+	expanded, err := p.expand(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []any
+	for _, item := range strings.Split(expanded, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
 
-	for idx, step := range originalSeq {
-		clonedSeq[idx] = pl.cloneStep(step)
+// The item is available as '{{name}}', fields of objects as '{{name.field}}'
+func (p *seqPlayer) setLoopVars(name string, item any) {
+	p.vars[name] = util.StringifyQueryResults([]any{item})
+	if obj, ok := item.(map[string]any); ok {
+		for key, val := range obj {
+			p.vars[name+"."+key] = util.StringifyQueryResults([]any{val})
+		}
 	}
-	return clonedSeq
 }
 
-func (pl *CmdPlay) cloneStep(originalStep *Step) *Step {
-	return &Step{
-		Name: originalStep.Name,
-		Cmd:  originalStep.Cmd,
+/*
+Conditions are either checked against the preceding response, using the syntax of assertions (as in
+'$status=200 && $body.done=true', see network.NewAssertions), or compare values as in
+'{{$1.role}}=admin'. Values that aren't compared hold unless they're empty, 'false', '0' or 'null'.
+*/
+func (p *seqPlayer) evalCondition(cond string) (bool, error) {
+	if network.IsAssertionExpr(cond) {
+		return p.checkResponse(cond)
+	}
+
+	left, op, right, isComparison := splitComparison(cond)
+	actual, err := p.expand(left)
+	if err != nil || !isComparison {
+		return isTruthy(actual), err
+	}
+
+	expected, err := p.expand(right)
+	if err != nil {
+		return false, err
+	}
+	return network.CompareValues(op, actual, expected), nil
+}
+
+func (p *seqPlayer) checkResponse(cond string) (bool, error) {
+	expanded, err := p.expand(cond)
+	if err != nil {
+		return false, err
+	}
+
+	assertions, err := network.NewAssertions(expanded)
+	if err != nil {
+		return false, err
+	}
+
+	resp, ok := (&Step{ParentStep: p.prev}).PrecedingResponse()
+	if !ok {
+		return false, fmt.Errorf("there's no response to check '%s' against", cond)
+	}
+
+	for _, a := range assertions {
+		if strings.HasPrefix(a.Raw, "$time") {
+			return false, fmt.Errorf("'%s' can't be used in conditions, use '$assert'", a.Raw)
+		}
+		if !a.Check(resp, 0).Passed {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (p *seqPlayer) expand(expr string) (string, error) {
+	expanded, err := (&Step{Cmd: []string{expr}}).ExpandTokens(p.latest, p.variables())
+	if err != nil {
+		return "", err
+	}
+	return expanded[0], nil
+}
+
+// Variables of the active env are looked up for every step, as steps may set them
+func (p *seqPlayer) variables() map[string]string {
+	vars := maps.Clone(config.GetEnvManager().GetActiveEnvVars())
+	if vars == nil {
+		vars = make(map[string]string, len(p.vars))
+	}
+	maps.Copy(vars, p.vars)
+	return vars
+}
+
+func (p *seqPlayer) enter(recorded *Step) int {
+	idx := p.index[recorded]
+	p.current, p.played[idx] = idx, true
+	return idx
+}
+
+// As in 'row 2: step #4 [3]'
+func (p *seqPlayer) label(name string) string {
+	if len(p.iterations) > 0 {
+		iterations := util.MapSlice(
+			p.iterations,
+			func(i int, _ int) string { return strconv.Itoa(i) },
+		)
+		name = fmt.Sprintf("%s [%s]", name, strings.Join(iterations, "."))
+	}
+
+	if p.row != "" {
+		name = p.row + ": " + name
+	}
+	return name
+}
+
+// Control flow steps are only reported when they fail
+func (p *seqPlayer) flowFailed(recorded *Step, err error) error {
+	step := &Step{Name: p.label(recorded.Name), Cmd: recorded.Cmd}
+	p.report.recordStep(step, 0, err)
+	return fmt.Errorf("%s: %w", step.Name, err)
+}
+
+// Steps after the current one that were never played, control flow steps aside
+func (p *seqPlayer) unplayed() []*Step {
+	var steps []*Step
+	for idx := p.current + 1; idx < len(p.steps); idx++ {
+		if step := p.steps[idx]; !p.played[idx] && !step.isFlow() {
+			steps = append(steps, &Step{Name: p.label(step.Name), Cmd: step.Cmd})
+		}
 	}
+	return steps
 }
 
 func (pl *CmdPlay) GetSuggestions(tokens [][]rune) ([][]rune, int) {
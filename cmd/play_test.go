@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Runs cmds by recording them (cmds named 'boom' fail), holds a single sequence
+type stubCmdHandler struct {
+	CmdHandler
+	cmds []string
+	seq  Sequence
+}
+
+func (h *stubCmdHandler) GetSequence(name string) (Sequence, error) {
+	return h.seq, nil
+}
+
+func (h *stubCmdHandler) SaveSequenceStep(name string, step *Step) error {
+	step.Name = fmt.Sprintf("step #%d", len(h.seq.Flatten())+1)
+	h.seq = append(h.seq, step)
+	return nil
+}
+
+func (h *stubCmdHandler) SetPrompt(prompt, mascot string) {}
+
+func (h *stubCmdHandler) printf(formatStr string, a ...any) {}
+
+func (h *stubCmdHandler) GetDefaultCtx() context.Context {
+	return context.Background()
+}
+
+func (h *stubCmdHandler) HandleRootCmd(
+	ctx context.Context,
+	tokens []string,
+) (context.Context, error) {
+	return h.HandleCmd(ctx, tokens)
+}
+
+func (h *stubCmdHandler) HandleCmd(ctx context.Context, tokens []string) (context.Context, error) {
+	cmd := strings.Join(tokens, " ")
+	h.cmds = append(h.cmds, cmd)
+	if tokens[0] == "boom" {
+		return ctx, errors.New("boom")
+	}
+	return ctx, nil
+}
+
+func playStub(seq Sequence) (*stubCmdHandler, *SequenceReport, error) {
+	hdlr := &stubCmdHandler{}
+	report := NewSequenceReport("test", "")
+	task := NewTask("play", CmdPlayName, nil)
+
+	p := newSeqPlayer(hdlr, task, context.Background(), seq, report)
+	err := p.run(seq)
+	if err != nil {
+		report.recordSkipped(p.unplayed())
+	}
+	return hdlr, report, err
+}
+
+func stepNames(report *SequenceReport, outcome StepOutcome) []string {
+	var names []string
+	for _, s := range report.Steps {
+		if s.Outcome == outcome {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+func TestPlayFlow(t *testing.T) {
+	seq := Sequence{
+		{
+			Name: "step #1",
+			Cmd:  []string{CmdForeachName, "n", "in", "a,b"},
+			Body: Sequence{
+				{
+					Name: "step #2",
+					Cmd:  []string{CmdIfName, "{{n}}", "=", "b"},
+					Body: Sequence{{Name: "step #3", Cmd: []string{"hit", "{{n}}"}}},
+					Else: Sequence{{Name: "step #4", Cmd: []string{"miss", "{{n}}"}}},
+				},
+			},
+		},
+		{
+			Name: "step #5",
+			Cmd:  []string{CmdRepeatName, "2"},
+			Body: Sequence{{Name: "step #6", Cmd: []string{"again"}}},
+		},
+		{
+			Name: "step #7",
+			Cmd:  []string{CmdUntilName, "yes"},
+			Body: Sequence{{Name: "step #8", Cmd: []string{"once"}}},
+		},
+	}
+
+	hdlr, report, err := playStub(seq)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantCmds := []string{"miss a", "hit b", "again", "again", "once"}
+	if !slices.Equal(hdlr.cmds, wantCmds) {
+		t.Errorf("played %q, want %q", hdlr.cmds, wantCmds)
+	}
+
+	wantNames := []string{
+		"step #4 [1]",
+		"step #3 [2]",
+		"step #6 [1]",
+		"step #6 [2]",
+		"step #8 [1]",
+	}
+	if got := stepNames(report, StepPassed); !slices.Equal(got, wantNames) {
+		t.Errorf("reported %q, want %q", got, wantNames)
+	}
+
+	// Reports hold the recorded cmds, expanded values (tokens & the like) are kept out of them
+	if got := report.Steps[0].Cmd; got != "miss {{n}}" {
+		t.Errorf("reported cmd %q, want %q", got, "miss {{n}}")
+	}
+}
+
+func TestPlayNestedIterationLabels(t *testing.T) {
+	seq := Sequence{
+		{
+			Name: "step #1",
+			Cmd:  []string{CmdRepeatName, "2"},
+			Body: Sequence{
+				{
+					Name: "step #2",
+					Cmd:  []string{CmdForeachName, "x", "in", "a,b"},
+					Body: Sequence{{Name: "step #3", Cmd: []string{"get", "{{x}}"}}},
+				},
+			},
+		},
+	}
+
+	_, report, err := playStub(seq)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"step #3 [1.1]", "step #3 [1.2]", "step #3 [2.1]", "step #3 [2.2]"}
+	if got := stepNames(report, StepPassed); !slices.Equal(got, want) {
+		t.Errorf("reported %q, want %q", got, want)
+	}
+}
+
+func TestPlayUntilMax(t *testing.T) {
+	seq := Sequence{
+		{
+			Name: "step #1",
+			Cmd:  []string{CmdUntilName, "false", "--max", "3"},
+			Body: Sequence{{Name: "step #2", Cmd: []string{"check"}}},
+		},
+		{Name: "step #3", Cmd: []string{"after"}},
+	}
+
+	hdlr, report, err := playStub(seq)
+	if err == nil || !strings.Contains(err.Error(), "wasn't met after 3 iteration(s)") {
+		t.Fatalf("expected '$until' to give up after 3 iterations, got %v", err)
+	}
+	if len(hdlr.cmds) != 3 {
+		t.Errorf("expected the body to be played 3 times, got %q", hdlr.cmds)
+	}
+
+	if got := stepNames(report, StepFailed); !slices.Equal(got, []string{"step #1"}) {
+		t.Errorf("expected the '$until' step to fail, got %q", got)
+	}
+	if got := stepNames(report, StepSkipped); !slices.Equal(got, []string{"step #3"}) {
+		t.Errorf("expected the following step to be skipped, got %q", got)
+	}
+}
+
+func TestPlayStepFailure(t *testing.T) {
+	seq := Sequence{
+		{Name: "step #1", Cmd: []string{"get"}},
+		{
+			Name: "step #2",
+			Cmd:  []string{CmdIfName, "yes"},
+			Body: Sequence{{Name: "step #3", Cmd: []string{"boom"}}},
+			Else: Sequence{{Name: "step #4", Cmd: []string{"never"}}},
+		},
+		{Name: "step #5", Cmd: []string{"after"}},
+	}
+
+	_, report, err := playStub(seq)
+	if err == nil {
+		t.Fatalf("expected the sequence to fail")
+	}
+
+	if got := stepNames(report, StepFailed); !slices.Equal(got, []string{"step #3"}) {
+		t.Errorf("failed steps = %q, want [step #3]", got)
+	}
+
+	// The '$else' branch wasn't taken, it's reported as skipped along with the rest
+	want := []string{"step #4", "step #5"}
+	if got := stepNames(report, StepSkipped); !slices.Equal(got, want) {
+		t.Errorf("skipped steps = %q, want %q", got, want)
+	}
+	if report.Passed() {
+		t.Errorf("expected the report not to pass")
+	}
+}
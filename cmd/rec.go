@@ -17,12 +17,38 @@ type CmdRec struct {
 	isFinalized       bool
 	isLiveModeEnabled bool
 	currSequenceName  string
+	blocks            []*recBlock // Control flow steps being recorded, innermost last
 }
 
+// A control flow step that's still open, steps go to its body (or its '$else' branch) until '$end'
+type recBlock struct {
+	step   *Step
+	inElse bool
+}
+
+// As in 'rec(login) 🔴 $foreach › $if › step #4'
 func (cr *CmdRec) updatePromptStep() {
 	hdlr := cr.GetCmdHandler()
 	seq, _ := hdlr.GetSequence(cr.currSequenceName)
-	hdlr.SetPrompt(fmt.Sprintf("rec(%s) 🔴 step #%d", cr.currSequenceName, len(seq)+1), "")
+
+	var path strings.Builder
+	for _, b := range cr.blocks {
+		name := b.step.Cmd[0]
+		if b.inElse {
+			name = CmdElseName
+		}
+		path.WriteString(name + " › ")
+	}
+
+	hdlr.SetPrompt(
+		fmt.Sprintf(
+			"rec(%s) 🔴 %sstep #%d",
+			cr.currSequenceName,
+			path.String(),
+			len(seq.Flatten())+1,
+		),
+		"",
+	)
 }
 
 func (cr *CmdRec) Execute(cmdCtx *CmdCtx) (context.Context, error) {
@@ -64,6 +90,7 @@ func (cr *CmdRec) enableRecMode(tokens []string) error {
 		cr.isLiveModeEnabled = false // If previously it was enabled in live mode.. this will take care of it.
 		sequenceName = strings.Join(tokens, " ")
 	}
+	cr.blocks = nil
 
 	if err := cr.registerNewSequence(sequenceName); err != nil {
 		return err
@@ -80,20 +107,94 @@ func (cr *CmdRec) AllowRootCmdsWhileInMode() bool {
 	return true
 }
 
+// In live mode, steps are run as they're recorded. Steps within blocks aren't, whether they'd run
+// (and how many times) depends on the block, which only '$play' evaluates.
 func (cr *CmdRec) handleSequenceCmd(tokens []string) error {
 	hdlr := cr.GetCmdHandler()
 
-	if cr.isLiveModeEnabled {
+	if cr.isLiveModeEnabled && len(cr.blocks) == 0 {
 		if _, err := hdlr.HandleRootCmd(hdlr.GetDefaultCtx(), tokens); err != nil {
 			return err
 		}
 	}
 
-	return hdlr.SaveSequenceStep(cr.currSequenceName, &Step{
+	return cr.saveStep(&Step{
 		Cmd: tokens,
 	})
 }
 
+// Steps go to the innermost open block, if there's one
+func (cr *CmdRec) saveStep(step *Step) error {
+	hdlr := cr.GetCmdHandler()
+	if len(cr.blocks) == 0 {
+		return hdlr.SaveSequenceStep(cr.currSequenceName, step)
+	}
+
+	seq, err := hdlr.GetSequence(cr.currSequenceName)
+	if err != nil {
+		return err
+	}
+	step.Name = fmt.Sprintf("step #%d", len(seq.Flatten())+1)
+
+	b := cr.blocks[len(cr.blocks)-1]
+	if b.inElse {
+		b.step.Else = append(b.step.Else, step)
+	} else {
+		b.step.Body = append(b.step.Body, step)
+	}
+	return nil
+}
+
+func (cr *CmdRec) openBlock(step *Step) error {
+	if err := cr.saveStep(step); err != nil {
+		return err
+	}
+
+	if cr.isLiveModeEnabled && len(cr.blocks) == 0 {
+		cr.GetCmdHandler().printf(
+			"steps within '%s' are recorded without being run, play the sequence to run them\n",
+			step.Cmd[0],
+		)
+	}
+
+	cr.blocks = append(cr.blocks, &recBlock{step: step})
+	cr.updatePromptStep()
+	return nil
+}
+
+func (cr *CmdRec) openElse() error {
+	if len(cr.blocks) == 0 {
+		return fmt.Errorf("'%s' requires an open '%s' block", CmdElseName, CmdIfName)
+	}
+
+	b := cr.blocks[len(cr.blocks)-1]
+	if kind := b.step.Cmd[0]; kind != CmdIfName {
+		return fmt.Errorf("'%s' goes with '%s', not '%s'", CmdElseName, CmdIfName, kind)
+	}
+	if b.inElse {
+		return fmt.Errorf("'%s' block already has an '%s' branch", CmdIfName, CmdElseName)
+	}
+
+	b.inElse = true
+	cr.updatePromptStep()
+	return nil
+}
+
+func (cr *CmdRec) closeBlock() error {
+	if len(cr.blocks) == 0 {
+		return fmt.Errorf("there's no open block to '%s'", CmdEndName)
+	}
+
+	b := cr.blocks[len(cr.blocks)-1]
+	if len(b.step.Body) == 0 && len(b.step.Else) == 0 {
+		return fmt.Errorf("'%s' block has no steps", b.step.Cmd[0])
+	}
+
+	cr.blocks = cr.blocks[:len(cr.blocks)-1]
+	cr.updatePromptStep()
+	return nil
+}
+
 func (cr *CmdRec) cleanup() {
 	if cr.isFinalized {
 		return
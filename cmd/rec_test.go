@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newStubRec() (*CmdRec, *stubCmdHandler) {
+	hdlr := &stubCmdHandler{}
+	rec := &CmdRec{BaseCmd: NewBaseCmd(CmdRecName, ""), currSequenceName: "test"}
+	rec.handler = hdlr
+	return rec, hdlr
+}
+
+func TestRecBlocks(t *testing.T) {
+	rec, hdlr := newStubRec()
+
+	steps := []func() error{
+		func() error { return rec.saveStep(&Step{Cmd: []string{"get", "users"}}) },
+		func() error { return rec.openBlock(&Step{Cmd: []string{CmdIfName, "{{$1.ok}}"}}) },
+		func() error { return rec.saveStep(&Step{Cmd: []string{"get", "ok"}}) },
+		func() error { return rec.openElse() },
+		func() error { return rec.openBlock(&Step{Cmd: []string{CmdRepeatName, "2"}}) },
+		func() error { return rec.saveStep(&Step{Cmd: []string{"get", "retry"}}) },
+		func() error { return rec.closeBlock() },
+		func() error { return rec.closeBlock() },
+		func() error { return rec.saveStep(&Step{Cmd: []string{"get", "done"}}) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d: unexpected error: %v", i+1, err)
+		}
+	}
+
+	if len(rec.blocks) != 0 {
+		t.Errorf("expected all blocks to be closed, %d are open", len(rec.blocks))
+	}
+
+	seq := hdlr.seq
+	if len(seq) != 3 || len(seq[1].Body) != 1 || len(seq[1].Else) != 1 {
+		t.Fatalf("unexpected sequence structure %+v", seq)
+	}
+	if got := seq[1].Else[0].Body[0].Cmd; !slices.Equal(got, []string{"get", "retry"}) {
+		t.Errorf("expected the nested step in the '$else' branch, got %q", got)
+	}
+
+	var names []string
+	for _, step := range seq.Flatten() {
+		names = append(names, step.Name+": "+strings.Join(step.Cmd, " "))
+	}
+	want := []string{
+		"step #1: get users",
+		"step #2: $if {{$1.ok}}",
+		"step #3: get ok",
+		"step #4: $repeat 2",
+		"step #5: get retry",
+		"step #6: get done",
+	}
+	if !slices.Equal(names, want) {
+		t.Errorf("recorded %q, want %q", names, want)
+	}
+}
+
+func TestRecLiveModeWithinBlocks(t *testing.T) {
+	rec, hdlr := newStubRec()
+	rec.isLiveModeEnabled = true
+
+	steps := []func() error{
+		func() error { return rec.handleSequenceCmd([]string{"get", "users"}) },
+		func() error { return rec.openBlock(&Step{Cmd: []string{CmdIfName, "{{$1.ok}}"}}) },
+		func() error { return rec.handleSequenceCmd([]string{"get", "ok"}) },
+		func() error { return rec.openElse() },
+		func() error { return rec.handleSequenceCmd([]string{"get", "{{item}}"}) },
+		func() error { return rec.closeBlock() },
+		func() error { return rec.handleSequenceCmd([]string{"get", "done"}) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d: unexpected error: %v", i+1, err)
+		}
+	}
+
+	// Only steps outside of blocks are run as they're recorded, all of them are recorded
+	if want := []string{"get users", "get done"}; !slices.Equal(hdlr.cmds, want) {
+		t.Errorf("ran %q, want %q", hdlr.cmds, want)
+	}
+	if got := len(hdlr.seq.Flatten()); got != 5 {
+		t.Errorf("expected 5 recorded steps, got %d", got)
+	}
+}
+
+func TestRecBlockErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		record  func(rec *CmdRec) error
+		wantErr string
+	}{
+		{
+			name:    "else without a block",
+			record:  func(rec *CmdRec) error { return rec.openElse() },
+			wantErr: "requires an open '$if' block",
+		},
+		{
+			name: "else of a loop",
+			record: func(rec *CmdRec) error {
+				rec.openBlock(&Step{Cmd: []string{CmdRepeatName, "2"}})
+				return rec.openElse()
+			},
+			wantErr: "goes with '$if'",
+		},
+		{
+			name: "second else",
+			record: func(rec *CmdRec) error {
+				rec.openBlock(&Step{Cmd: []string{CmdIfName, "yes"}})
+				rec.openElse()
+				return rec.openElse()
+			},
+			wantErr: "already has an '$else' branch",
+		},
+		{
+			name:    "end without a block",
+			record:  func(rec *CmdRec) error { return rec.closeBlock() },
+			wantErr: "no open block",
+		},
+		{
+			name: "empty block",
+			record: func(rec *CmdRec) error {
+				rec.openBlock(&Step{Cmd: []string{CmdIfName, "yes"}})
+				return rec.closeBlock()
+			},
+			wantErr: "has no steps",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, _ := newStubRec()
+			if err := tt.record(rec); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
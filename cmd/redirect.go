@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/shubm-quodes/repl-reqs/util"
+)
+
+type redirectKind int
+
+const (
+	redirectWrite  redirectKind = iota // cmd > file
+	redirectAppend                     // cmd >> file
+	redirectPipe                       // cmd | shell-cmd
+)
+
+const redirectKey CmdCtxID = "redirect"
+
+// Output of a command headed to a file or a shell command instead of the terminal
+type redirect struct {
+	kind   redirectKind
+	target string // File path or shell command
+
+	mu       sync.Mutex
+	captured strings.Builder
+	isAsync  bool // Async cmds are redirected once their task completes
+}
+
+// Cmds whose args are conditions or queries, in which '>' & '|' are comparisons & jq pipes rather
+// than redirects
+type ExprCmd interface {
+	Cmd
+
+	TakesExprs() bool
+}
+
+// Cmds whose args only turn into an expression part way through, as in
+// '$set var <name> --query <expr>'
+type PartialExprCmd interface {
+	ExprCmd
+
+	// Index of the arg the expression starts at, -1 if the args don't have one
+	ExprStart(args []string) int
+}
+
+// Ends the expression of cmds taking expressions, as in '$query .items -- > items.json'
+const exprEnd = "--"
+
+/*
+Splits off a trailing '> file', '>> file' or '| <shell cmd>'. Operators have to be tokens of their
+own outside of '{{..}}', brackets, parentheses & quotes, '>' is only a redirect when it's followed by
+nothing but the file path. Escaped operators ('\>', '\>>' & '\|') are passed on as they are, without
+the '\'.
+
+Operators in expressions (see ExprCmd & PartialExprCmd) are left alone, so that '$assert
+$body.total > 5' & '$query .items | sort' work as expected. exprStart gives the index of the token
+the cmd's expression starts at, -1 if there's none. Their output is redirected by ending the
+expression with '--' first, as in '$query .items -- | jq .'.
+*/
+func splitRedirect(tokens []string, exprStart func(tokens []string) int) ([]string, *redirect) {
+	if len(tokens) == 0 {
+		return tokens, nil
+	}
+
+	depth, inQuotes, start := 0, false, exprStart(tokens)
+	args := make([]string, 0, len(tokens))
+
+	for i, token := range tokens {
+		inExpr := start > 0 && i >= start
+		if depth == 0 && !inQuotes && i > 0 {
+			switch {
+			case inExpr && token == exprEnd && i+1 < len(tokens):
+				if r := parseRedirect(tokens[i+1], tokens[i+2:]); r != nil {
+					return args, r
+				}
+			case inExpr:
+			case token == `\>` || token == `\>>` || token == `\|`:
+				token = token[1:]
+			default:
+				if r := parseRedirect(token, tokens[i+1:]); r != nil {
+					return args, r
+				}
+			}
+		}
+		args = append(args, token)
+
+		for _, r := range token {
+			switch {
+			case r == '"':
+				inQuotes = !inQuotes
+			case inQuotes:
+			case r == '(' || r == '[' || r == '{':
+				depth++
+			case r == ')' || r == ']' || r == '}':
+				depth--
+			}
+		}
+	}
+	return args, nil
+}
+
+// A redirect if op is one & it's followed by the file path alone (or the shell cmd, for pipes)
+func parseRedirect(op string, rest []string) *redirect {
+	switch {
+	case (op == ">" || op == ">>") && len(rest) == 1:
+		kind := redirectWrite
+		if op == ">>" {
+			kind = redirectAppend
+		}
+		return &redirect{kind: kind, target: rest[0]}
+	case op == "|" && len(rest) > 0:
+		return &redirect{kind: redirectPipe, target: strings.Join(rest, " ")}
+	}
+	return nil
+}
+
+func redirectFromCtx(ctx context.Context) (*redirect, bool) {
+	r, ok := ctx.Value(redirectKey).(*redirect)
+	return r, ok && r != nil
+}
+
+func (r *redirect) capture(s string) {
+	r.mu.Lock()
+	r.captured.WriteString(s)
+	r.mu.Unlock()
+}
+
+func (r *redirect) capturedOutput() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return util.StripAnsi(r.captured.String())
+}
+
+// Responses are redirected as they were received, anything else as the (un-highlighted) output
+func taskOutputForRedirect(status *TaskStatus) string {
+	if resp, ok := status.Result.(*http.Response); ok && resp != nil && resp.Body != nil {
+		if body, err := util.ReadAndResetIoCloser(&resp.Body); err == nil {
+			return string(body)
+		}
+	}
+	return util.StripAnsi(status.Output)
+}
+
+// Writes the output to the file or feeds it to the shell command, returning what the latter printed
+func (r *redirect) apply(output string) (string, error) {
+	if r.kind == redirectPipe {
+		var c *exec.Cmd
+		if util.OsIsUnixLike() {
+			c = exec.Command("sh", "-c", r.target)
+		} else {
+			c = exec.Command("cmd", "/C", r.target)
+		}
+		c.Stdin = strings.NewReader(output)
+
+		printed, err := c.CombinedOutput()
+		if err != nil {
+			return string(printed), fmt.Errorf("'%s' failed: %w", r.target, err)
+		}
+		return string(printed), nil
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if r.kind == redirectAppend {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(r.target, flags, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := io.WriteString(file, output); err != nil {
+		return "", err
+	}
+	return "", nil
+}
+
+func (r *redirect) describe() string {
+	switch r.kind {
+	case redirectPipe:
+		return fmt.Sprintf("piped to '%s'", r.target)
+	case redirectAppend:
+		return fmt.Sprintf("appended to '%s'", r.target)
+	default:
+		return fmt.Sprintf("written to '%s'", r.target)
+	}
+}
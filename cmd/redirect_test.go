@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/shubm-quodes/repl-reqs/config"
+)
+
+func TestSplitRedirect(t *testing.T) {
+	// As in '$set var <name> --query <expr>'
+	exprStart := func(tokens []string) int {
+		switch {
+		case slices.Contains([]string{"$query", "$assert", CmdIfName, CmdUntilName}, tokens[0]):
+			return 1
+		case len(tokens) > 4 && tokens[0] == "$set" && tokens[3] == "--query":
+			return 4
+		}
+		return -1
+	}
+
+	tests := []struct {
+		name       string
+		tokens     []string
+		wantArgs   []string
+		wantKind   redirectKind
+		wantTarget string
+	}{
+		{
+			name:       "write",
+			tokens:     []string{"list", "users", ">", "users.json"},
+			wantArgs:   []string{"list", "users"},
+			wantKind:   redirectWrite,
+			wantTarget: "users.json",
+		},
+		{
+			name:       "append",
+			tokens:     []string{"$history", ">>", "history.log"},
+			wantArgs:   []string{"$history"},
+			wantKind:   redirectAppend,
+			wantTarget: "history.log",
+		},
+		{
+			name:       "pipe",
+			tokens:     []string{"list", "users", "|", "jq", "'.[].email'"},
+			wantArgs:   []string{"list", "users"},
+			wantKind:   redirectPipe,
+			wantTarget: "jq '.[].email'",
+		},
+		{
+			name:     "not followed by the path alone",
+			tokens:   []string{"$set", "var", "cmp", ">", "a", "b"},
+			wantArgs: []string{"$set", "var", "cmp", ">", "a", "b"},
+		},
+		{
+			name:     "escaped",
+			tokens:   []string{"$set", "var", "cmp", `\>`, "5"},
+			wantArgs: []string{"$set", "var", "cmp", ">", "5"},
+		},
+		{
+			name:     "escaped pipe",
+			tokens:   []string{"$set", "var", "sep", `\|`, "sort"},
+			wantArgs: []string{"$set", "var", "sep", "|", "sort"},
+		},
+		{
+			name:     "within an expansion",
+			tokens:   []string{"$set", "var", "n", "{{$1", "|", "length}}"},
+			wantArgs: []string{"$set", "var", "n", "{{$1", "|", "length}}"},
+		},
+		{
+			name:     "assertion",
+			tokens:   []string{"$assert", "$body.total", ">", "5"},
+			wantArgs: []string{"$assert", "$body.total", ">", "5"},
+		},
+		{
+			name:     "condition",
+			tokens:   []string{CmdIfName, "{{$1.count}}", ">", "5"},
+			wantArgs: []string{CmdIfName, "{{$1.count}}", ">", "5"},
+		},
+		{
+			name:     "until",
+			tokens:   []string{CmdUntilName, "$body.n", ">", "5"},
+			wantArgs: []string{CmdUntilName, "$body.n", ">", "5"},
+		},
+		{
+			name:     "query",
+			tokens:   []string{"$query", ".page.total", ">", "2"},
+			wantArgs: []string{"$query", ".page.total", ">", "2"},
+		},
+		{
+			name:     "query pipe",
+			tokens:   []string{"$query", ".items", "|", "sort"},
+			wantArgs: []string{"$query", ".items", "|", "sort"},
+		},
+		{
+			name:       "query written",
+			tokens:     []string{"$query", ".items", "--", ">", "items.json"},
+			wantArgs:   []string{"$query", ".items"},
+			wantKind:   redirectWrite,
+			wantTarget: "items.json",
+		},
+		{
+			name:       "query appended",
+			tokens:     []string{"$query", ".page.total", ">", "2", "--", ">>", "totals.log"},
+			wantArgs:   []string{"$query", ".page.total", ">", "2"},
+			wantKind:   redirectAppend,
+			wantTarget: "totals.log",
+		},
+		{
+			name:       "query piped",
+			tokens:     []string{"$query", ".items", "|", "length", "--", "|", "jq", "."},
+			wantArgs:   []string{"$query", ".items", "|", "length"},
+			wantKind:   redirectPipe,
+			wantTarget: "jq .",
+		},
+		{
+			name:       "assertion piped",
+			tokens:     []string{"$assert", "$body.total", ">", "5", "--", "|", "tee", "a.log"},
+			wantArgs:   []string{"$assert", "$body.total", ">", "5"},
+			wantKind:   redirectPipe,
+			wantTarget: "tee a.log",
+		},
+		{
+			name:     "expression end without a redirect",
+			tokens:   []string{"$query", ".items", "--", "x"},
+			wantArgs: []string{"$query", ".items", "--", "x"},
+		},
+		{
+			name:       "query flag written",
+			tokens:     []string{"$set", "var", "n", "--query", ".items", "|", "length", "--", ">", "n"},
+			wantArgs:   []string{"$set", "var", "n", "--query", ".items", "|", "length"},
+			wantKind:   redirectWrite,
+			wantTarget: "n",
+		},
+		{
+			name:     "query flag",
+			tokens:   []string{"$set", "var", "ids", "--query", ".items", "|", "join"},
+			wantArgs: []string{"$set", "var", "ids", "--query", ".items", "|", "join"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, r := splitRedirect(tt.tokens, exprStart)
+			if !slices.Equal(args, tt.wantArgs) {
+				t.Errorf("splitRedirect() args = %q, want %q", args, tt.wantArgs)
+			}
+
+			if tt.wantTarget == "" {
+				if r != nil {
+					t.Errorf("splitRedirect() redirect = %q, want none", r.target)
+				}
+				return
+			}
+
+			if r == nil {
+				t.Fatalf("splitRedirect() redirect = nil, want %q", tt.wantTarget)
+			}
+			if r.kind != tt.wantKind || r.target != tt.wantTarget {
+				t.Errorf(
+					"splitRedirect() redirect = %v %q, want %v %q",
+					r.kind,
+					r.target,
+					tt.wantKind,
+					tt.wantTarget,
+				)
+			}
+		})
+	}
+}
+
+// Expression starts at '--query', the arg after the name
+type stubPartialExprCmd struct {
+	*BaseCmd
+}
+
+func (c *stubPartialExprCmd) Execute(cmdCtx *CmdCtx) (context.Context, error) {
+	return cmdCtx.Ctx, nil
+}
+
+func (c *stubPartialExprCmd) TakesExprs() bool {
+	return false
+}
+
+func (c *stubPartialExprCmd) ExprStart(args []string) int {
+	if len(args) > 2 && args[1] == "--query" {
+		return 2
+	}
+	return -1
+}
+
+func TestExprStart(t *testing.T) {
+	set := NewBaseCmd("$set", "")
+	set.AddSubCmd(&stubPartialExprCmd{NewBaseCmd("var", "")})
+
+	reg := NewCmdRegistry()
+	reg.RegisterCmd(set)
+	h := NewHeadlessCmdHandler(config.NewAppCfg(), reg, io.Discard, io.Discard)
+
+	tests := []struct {
+		tokens []string
+		want   int
+	}{
+		{[]string{"$set", "var", "ids", "--query", ".items", "|", "join"}, 4},
+		{[]string{"$set", "var", "ids", "a", "|", "b"}, -1},
+		{[]string{"unknown", "--query", ".items"}, -1},
+	}
+
+	for _, tt := range tests {
+		if got := h.exprStart(tt.tokens); got != tt.want {
+			t.Errorf("exprStart(%q) = %d, want %d", tt.tokens, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type StepOutcome string
+
+const (
+	StepPassed  StepOutcome = "passed"
+	StepFailed  StepOutcome = "failed"
+	StepSkipped StepOutcome = "skipped"
+)
+
+const seqReportKey CmdCtxID = "seqReport"
+
+// Outcome of a single check made by a step, like '$is_eq'
+type AssertionResult struct {
+	Assertion string `json:"assertion"`
+	Passed    bool   `json:"passed"`
+	Expected  string `json:"expected,omitempty"`
+	Actual    string `json:"actual,omitempty"`
+}
+
+type StepResult struct {
+	Name       string            `json:"name"`
+	Cmd        string            `json:"cmd"`
+	Outcome    StepOutcome       `json:"outcome"`
+	Duration   time.Duration     `json:"-"`
+	DurationMs float64           `json:"durationMs"`
+	StatusCode int               `json:"statusCode,omitempty"`
+	Assertions []AssertionResult `json:"assertions,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// Outcome of playing the sequence for a row of a '$play --data' file. Rows are only referred to by
+// their number, their values (often test accounts & their credentials) are kept out of reports.
+type RowResult struct {
+	Row    int    `json:"row"`
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+}
+
+/*
+What happened while playing a sequence, step by step. '$play' records into the report found in its
+context (see WithSequenceReport), steps that weren't reached are recorded as skipped. Reports can
+be written as JSON or as JUnit XML, for CI servers to pick up.
+*/
+type SequenceReport struct {
+	Sequence  string        `json:"sequence"`
+	Env       string        `json:"env"`
+	StartedAt time.Time     `json:"startedAt"`
+	Duration  time.Duration `json:"-"`
+	Steps     []*StepResult `json:"steps"`
+	Rows      []*RowResult  `json:"rows,omitempty"`
+
+	mu sync.Mutex
+}
+
+func NewSequenceReport(sequence, env string) *SequenceReport {
+	return &SequenceReport{Sequence: sequence, Env: env, StartedAt: time.Now()}
+}
+
+func WithSequenceReport(ctx context.Context, report *SequenceReport) context.Context {
+	return context.WithValue(ctx, seqReportKey, report)
+}
+
+func seqReportFromCtx(ctx context.Context) *SequenceReport {
+	report, _ := ctx.Value(seqReportKey).(*SequenceReport)
+	return report
+}
+
+// Records a step that was run, err being whatever the step (or its expansion) failed with. The cmd
+// is reported as recorded, placeholders aren't expanded so that env values don't end up in reports.
+func (r *SequenceReport) recordStep(step *Step, d time.Duration, err error) {
+	if r == nil {
+		return
+	}
+
+	result := &StepResult{
+		Name:       step.GetName(),
+		Cmd:        strings.Join(step.Cmd, " "),
+		Outcome:    StepPassed,
+		Duration:   d,
+		DurationMs: float64(d.Microseconds()) / 1000,
+		Assertions: step.assertions,
+	}
+
+	if step.Task != nil {
+		if resp, ok := step.Task.GetResult().(*http.Response); ok && resp != nil {
+			result.StatusCode = resp.StatusCode
+		}
+	}
+
+	if err == nil {
+		err = step.err
+	}
+	if err != nil || step.HasFailed {
+		result.Outcome = StepFailed
+		if err != nil {
+			result.Error = err.Error()
+		}
+	}
+
+	r.mu.Lock()
+	r.Steps = append(r.Steps, result)
+	r.mu.Unlock()
+}
+
+// Records steps that weren't reached as skipped
+func (r *SequenceReport) recordSkipped(steps []*Step) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, step := range steps {
+		r.Steps = append(r.Steps, &StepResult{
+			Name:    step.GetName(),
+			Cmd:     strings.Join(step.Cmd, " "),
+			Outcome: StepSkipped,
+		})
+	}
+}
+
+func (r *SequenceReport) recordRow(row int, err error) {
+	if r == nil {
+		return
+	}
+
+	result := &RowResult{Row: row, Passed: err == nil}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	r.Rows = append(r.Rows, result)
+	r.mu.Unlock()
+}
+
+func (r *SequenceReport) finish() {
+	if r != nil {
+		r.Duration = time.Since(r.StartedAt)
+	}
+}
+
+// Number of steps per outcome
+func (r *SequenceReport) Count(outcome StepOutcome) int {
+	var count int
+	for _, s := range r.Steps {
+		if s.Outcome == outcome {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *SequenceReport) Passed() bool {
+	return len(r.Steps) > 0 && r.Count(StepFailed) == 0 && r.Count(StepSkipped) == 0
+}
+
+// Runs with a data file are summarized per row as well, as in '3 row(s): 2 passed, 1 failed; ...'
+func (r *SequenceReport) Summary() string {
+	var rows string
+	if len(r.Rows) > 0 {
+		var failed int
+		for _, row := range r.Rows {
+			if !row.Passed {
+				failed++
+			}
+		}
+		rows = fmt.Sprintf(
+			"%d row(s): %d passed, %d failed; ",
+			len(r.Rows),
+			len(r.Rows)-failed,
+			failed,
+		)
+	}
+
+	return rows + fmt.Sprintf(
+		"%d step(s): %d passed, %d failed, %d skipped (in: %s)",
+		len(r.Steps),
+		r.Count(StepPassed),
+		r.Count(StepFailed),
+		r.Count(StepSkipped),
+		FormatDuration(r.Duration),
+	)
+}
+
+func (r *SequenceReport) WriteJSON(w io.Writer) error {
+	type jsonReport struct {
+		*SequenceReport
+		Passed     bool    `json:"passed"`
+		DurationMs float64 `json:"durationMs"`
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false) // Cmds are full of '&&', '<' & '>'
+	return encoder.Encode(jsonReport{
+		SequenceReport: r,
+		Passed:         r.Passed(),
+		DurationMs:     float64(r.Duration.Microseconds()) / 1000,
+	})
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Details string `xml:",chardata"`
+}
+
+// One test suite for the sequence, with a test case per step
+func (r *SequenceReport) WriteJUnit(w io.Writer) error {
+	suite := junitTestSuite{
+		Name:      r.Sequence,
+		Tests:     len(r.Steps),
+		Failures:  r.Count(StepFailed),
+		Skipped:   r.Count(StepSkipped),
+		Time:      junitSeconds(r.Duration),
+		Timestamp: r.StartedAt.Format(time.RFC3339),
+	}
+	if r.Env != "" {
+		suite.Properties = []junitProperty{{Name: "env", Value: r.Env}}
+	}
+
+	for _, s := range r.Steps {
+		testCase := junitTestCase{
+			Name:      s.Name,
+			ClassName: r.Sequence,
+			Time:      junitSeconds(s.Duration),
+			SystemOut: s.describe(),
+		}
+
+		switch s.Outcome {
+		case StepFailed:
+			testCase.Failure = &junitFailure{Message: s.failureMessage(), Details: s.describe()}
+		case StepSkipped:
+			testCase.Skipped = &struct{}{}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err := encoder.Encode(junitTestSuites{
+		Name:     "repl-reqs",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func (s *StepResult) failureMessage() string {
+	if s.Error != "" {
+		return s.Error
+	}
+	for _, a := range s.Assertions {
+		if !a.Passed {
+			return "assertion failed: " + a.Assertion
+		}
+	}
+	return "step failed"
+}
+
+// Cmd, status code & assertions, one per line
+func (s *StepResult) describe() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "cmd: %s\n", s.Cmd)
+	if s.StatusCode != 0 {
+		fmt.Fprintf(&sb, "status: %d\n", s.StatusCode)
+	}
+
+	for _, a := range s.Assertions {
+		mark := "✅"
+		if !a.Passed {
+			mark = "❌"
+		}
+		fmt.Fprintf(&sb, "%s %s", mark, a.Assertion)
+		if !a.Passed {
+			fmt.Fprintf(&sb, " (expected: %s, actual: %s)", a.Expected, a.Actual)
+		}
+		sb.WriteString("\n")
+	}
+
+	if s.Error != "" {
+		fmt.Fprintf(&sb, "error: %s\n", s.Error)
+	}
+	return sb.String()
+}
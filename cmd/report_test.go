@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestReport() *SequenceReport {
+	report := NewSequenceReport("login", "staging")
+
+	passed := &Step{
+		Name:       "step #1",
+		Cmd:        []string{"$send"},
+		assertions: []AssertionResult{{Assertion: "$status=200", Passed: true}},
+	}
+	report.recordStep(passed, 120*time.Millisecond, nil)
+
+	failed := &Step{
+		Name: "step #2",
+		Cmd:  []string{"$assert", "$body.n>1", "&&", "$time<500"},
+		assertions: []AssertionResult{
+			{Assertion: "$status=200", Passed: true},
+			{Assertion: "$body.n>1 && $time<500", Expected: "> 1", Actual: "0"},
+		},
+		HasFailed: true,
+	}
+	report.recordStep(failed, 0, nil)
+
+	report.recordSkipped([]*Step{{Name: "step #3", Cmd: []string{"$send"}}})
+	report.Duration = 1500 * time.Millisecond
+	return report
+}
+
+func TestReportOutcomes(t *testing.T) {
+	report := newTestReport()
+	if report.Count(StepPassed) != 1 || report.Count(StepFailed) != 1 ||
+		report.Count(StepSkipped) != 1 {
+		t.Errorf("unexpected counts in %s", report.Summary())
+	}
+	if report.Passed() {
+		t.Errorf("expected a report with failed steps not to pass")
+	}
+
+	// Steps that weren't reached fail the report as well
+	skipped := NewSequenceReport("login", "")
+	skipped.recordStep(&Step{Name: "step #1", Cmd: []string{"$send"}}, 0, nil)
+	skipped.recordSkipped([]*Step{{Name: "step #2", Cmd: []string{"$send"}}})
+	if skipped.Passed() {
+		t.Errorf("expected a report with skipped steps not to pass")
+	}
+
+	passed := NewSequenceReport("login", "")
+	if passed.Passed() {
+		t.Errorf("expected an empty report not to pass")
+	}
+	passed.recordStep(&Step{Name: "step #1", Cmd: []string{"$send"}}, 0, nil)
+	if !passed.Passed() {
+		t.Errorf("expected a report without failures to pass")
+	}
+}
+
+func TestReportSkipsUnplayedSteps(t *testing.T) {
+	seq := Sequence{
+		{Name: "step #1", Cmd: []string{"get"}},
+		{Name: "step #2", Cmd: []string{"boom"}},
+		{
+			Name: "step #3",
+			Cmd:  []string{CmdRepeatName, "2"},
+			Body: Sequence{{Name: "step #4", Cmd: []string{"get"}}},
+		},
+		{Name: "step #5", Cmd: []string{"get"}},
+	}
+
+	_, report, err := playStub(seq)
+	if err == nil {
+		t.Fatalf("expected the sequence to fail")
+	}
+
+	var got []string
+	for _, s := range report.Steps {
+		got = append(got, s.Name+" "+string(s.Outcome))
+	}
+
+	// Control flow steps that weren't reached aren't reported, their steps are
+	want := "step #1 passed,step #2 failed,step #4 skipped,step #5 skipped"
+	if strings.Join(got, ",") != want {
+		t.Errorf("reported %q, want %q", strings.Join(got, ","), want)
+	}
+	if report.Steps[1].Error != "boom" {
+		t.Errorf("expected the step's error to be reported, got %q", report.Steps[1].Error)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newTestReport().WriteJUnit(&buf); err != nil {
+		t.Fatalf("WriteJUnit() error = %v", err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, xml.Header) {
+		t.Errorf("expected an xml header")
+	}
+	if !strings.Contains(out, "$body.n&gt;1 &amp;&amp; $time&lt;500") {
+		t.Errorf("expected '&&', '<' & '>' to be escaped:\n%s", out)
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+		t.Fatalf("failed to parse the report: %v\n%s", err, out)
+	}
+
+	if suites.Tests != 3 || suites.Failures != 1 || suites.Skipped != 1 || suites.Time != "1.500" {
+		t.Errorf("unexpected totals %+v", suites)
+	}
+	if len(suites.Suites) != 1 {
+		t.Fatalf("expected a single test suite, got %d", len(suites.Suites))
+	}
+
+	suite := suites.Suites[0]
+	if suite.Name != "login" || len(suite.Properties) != 1 || suite.Properties[0].Value != "staging" {
+		t.Errorf("unexpected suite %q with properties %+v", suite.Name, suite.Properties)
+	}
+	if len(suite.Cases) != 3 {
+		t.Fatalf("expected 3 test cases, got %d", len(suite.Cases))
+	}
+
+	passed, failed, skipped := suite.Cases[0], suite.Cases[1], suite.Cases[2]
+	if passed.Failure != nil || passed.Skipped != nil || passed.Time != "0.120" {
+		t.Errorf("unexpected passing case %+v", passed)
+	}
+	if failed.Failure == nil ||
+		failed.Failure.Message != "assertion failed: $body.n>1 && $time<500" {
+		t.Errorf("expected the failed assertion as the failure message, got %+v", failed.Failure)
+	}
+	if !strings.Contains(failed.Failure.Details, "(expected: > 1, actual: 0)") {
+		t.Errorf("expected the failure's details, got %q", failed.Failure.Details)
+	}
+	if skipped.Skipped == nil || skipped.Failure != nil {
+		t.Errorf("expected a skipped case, got %+v", skipped)
+	}
+	if !strings.Contains(out, "<skipped></skipped>") {
+		t.Errorf("expected a skipped element:\n%s", out)
+	}
+}
+
+func TestJUnitFailureMessage(t *testing.T) {
+	tests := []struct {
+		step *StepResult
+		want string
+	}{
+		{&StepResult{Error: "connection refused"}, "connection refused"},
+		{
+			&StepResult{Assertions: []AssertionResult{{Assertion: "$status=200"}}},
+			"assertion failed: $status=200",
+		},
+		{&StepResult{}, "step failed"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.step.failureMessage(); got != tt.want {
+			t.Errorf("failureMessage() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	report := newTestReport()
+	report.recordRow(1, nil)
+	report.recordRow(2, errors.New("step #2 failed"))
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "$body.n>1 && $time<500") {
+		t.Errorf("expected cmds not to be html escaped:\n%s", buf.String())
+	}
+
+	var got struct {
+		Sequence   string  `json:"sequence"`
+		Env        string  `json:"env"`
+		Passed     bool    `json:"passed"`
+		DurationMs float64 `json:"durationMs"`
+		Steps      []struct {
+			Name       string            `json:"name"`
+			Outcome    StepOutcome       `json:"outcome"`
+			DurationMs float64           `json:"durationMs"`
+			Assertions []AssertionResult `json:"assertions"`
+		} `json:"steps"`
+		Rows []RowResult `json:"rows"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("failed to parse the report: %v", err)
+	}
+
+	if got.Sequence != "login" || got.Env != "staging" || got.Passed || got.DurationMs != 1500 {
+		t.Errorf("unexpected report %+v", got)
+	}
+	if len(got.Steps) != 3 {
+		t.Fatalf("expected 3 steps, got %d", len(got.Steps))
+	}
+
+	outcomes := []StepOutcome{got.Steps[0].Outcome, got.Steps[1].Outcome, got.Steps[2].Outcome}
+	if outcomes[0] != StepPassed || outcomes[1] != StepFailed || outcomes[2] != StepSkipped {
+		t.Errorf("unexpected outcomes %v", outcomes)
+	}
+	if got.Steps[0].DurationMs != 120 || len(got.Steps[1].Assertions) != 2 {
+		t.Errorf("unexpected steps %+v", got.Steps)
+	}
+	if len(got.Rows) != 2 || !got.Rows[0].Passed || got.Rows[1].Error != "step #2 failed" {
+		t.Errorf("unexpected rows %+v", got.Rows)
+	}
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Marks the rest of a script line as a comment
+const scriptCommentPrefix = "#"
+
+/*
+Runs the cmds read from r, one per line, as if they were typed into the shell. Blank lines & lines
+starting with '#' are skipped. Async cmds are awaited, the first cmd that fails stops the script
+and its error is returned along with the line it's on (name is the script's name in errors). Just
+like the end of input in the shell, modes entered by the script are left once it's done.
+*/
+func (h *ReplCmdHandler) RunScript(name string, r io.Reader) error {
+	defer func() {
+		for !h.ExitCmdMode() {
+		}
+	}()
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, scriptCommentPrefix) {
+			continue
+		}
+
+		if _, err := h.HandleCmd(h.defaultCtx, strings.Fields(line)); err != nil {
+			return fmt.Errorf("%s:%d: %w", name, lineNo, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read '%s': %w", name, err)
+	}
+	return nil
+}
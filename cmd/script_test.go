@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/shubm-quodes/repl-reqs/config"
+)
+
+// 'ping <arg>' runs right away, 'async <arg>' as a task. Either fails when its arg is 'fail'
+type stubScriptCmd struct {
+	*BaseCmd
+	ran *[]string
+}
+
+func (c *stubScriptCmd) Execute(cmdCtx *CmdCtx) (context.Context, error) {
+	*c.ran = append(*c.ran, c.Name()+" "+strings.Join(cmdCtx.ExpandedTokens, " "))
+	if cmdCtx.ExpandedTokens[0] == "fail" {
+		return cmdCtx.Ctx, errors.New("ping failed")
+	}
+	cmdCtx.Task.AppendOutput("pong")
+	return cmdCtx.Ctx, nil
+}
+
+type stubAsyncScriptCmd struct {
+	stubScriptCmd
+}
+
+func (c *stubAsyncScriptCmd) ExecuteAsync(cmdCtx *CmdCtx) {
+	*c.ran = append(*c.ran, c.Name()+" "+strings.Join(cmdCtx.ExpandedTokens, " "))
+	if cmdCtx.ExpandedTokens[0] == "fail" {
+		cmdCtx.Task.Fail(errors.New("async failed"))
+		return
+	}
+	cmdCtx.Task.AppendOutput("async pong")
+	cmdCtx.Task.Complete(nil)
+}
+
+func newStubHeadlessHandler(ran *[]string) (*ReplCmdHandler, *bytes.Buffer, *bytes.Buffer) {
+	reg := NewCmdRegistry()
+	reg.RegisterCmd(
+		&stubScriptCmd{BaseCmd: NewBaseCmd("ping", ""), ran: ran},
+		&stubAsyncScriptCmd{stubScriptCmd{BaseCmd: NewBaseCmd("async", ""), ran: ran}},
+	)
+
+	var out, errOut bytes.Buffer
+	h := NewHeadlessCmdHandler(config.NewAppCfg(), reg, &out, &errOut)
+	h.injectIntoReg()
+	go h.listenForTaskUpdates()
+	return h, &out, &errOut
+}
+
+func TestRunScript(t *testing.T) {
+	tests := []struct {
+		name       string
+		script     string
+		wantRan    []string
+		wantErr    string
+		wantOut    string
+		wantErrOut string
+	}{
+		{
+			name:    "passes",
+			script:  "# comment\n\nping a\n  async b  \nping c\n",
+			wantRan: []string{"ping a", "async b", "ping c"},
+			wantOut: "async pong",
+		},
+		{
+			name:    "sync cmd fails",
+			script:  "ping a\n\nping fail\nping never\n",
+			wantRan: []string{"ping a", "ping fail"},
+			wantErr: "script:3: ping failed",
+		},
+		{
+			// Async cmds are awaited, their failure ends the script just the same
+			name:       "async cmd fails",
+			script:     "async fail\nping never\n",
+			wantRan:    []string{"async fail"},
+			wantErr:    "script:1: task #1 (async) failed",
+			wantErrOut: "async failed",
+		},
+		{
+			name:    "unknown cmd",
+			script:  "ping a\nnope\n",
+			wantRan: []string{"ping a"},
+			wantErr: "script:2: invalid command 'nope'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran []string
+			h, out, errOut := newStubHeadlessHandler(&ran)
+
+			err := h.RunScript("script", strings.NewReader(tt.script))
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+
+			if !slices.Equal(ran, tt.wantRan) {
+				t.Errorf("ran %q, want %q", ran, tt.wantRan)
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("expected %q in the output, got %q", tt.wantOut, out.String())
+			}
+
+			// Failures go to errOut only
+			if !strings.Contains(errOut.String(), tt.wantErrOut) {
+				t.Errorf("expected %q in the errors, got %q", tt.wantErrOut, errOut.String())
+			}
+			if tt.wantErrOut != "" && strings.Contains(out.String(), tt.wantErrOut) {
+				t.Errorf("expected %q not to be in the output", tt.wantErrOut)
+			}
+		})
+	}
+}
@@ -28,6 +28,19 @@ type CmdFinalizeRec struct {
 	*BaseCmd
 }
 
+// '$if', '$repeat', '$foreach' & '$until', which open a block of steps (see parseFlow)
+type CmdFlow struct {
+	*BaseNonModeCmd
+}
+
+type CmdElse struct {
+	*BaseNonModeCmd
+}
+
+type CmdEnd struct {
+	*BaseNonModeCmd
+}
+
 func (eq *CmdIsEq) Execute(cmdCtx *CmdCtx) (context.Context, error) {
 	ctx, tokens := cmdCtx.Ctx, cmdCtx.ExpandedTokens
 	if len(tokens) < 2 {
@@ -35,6 +48,15 @@ func (eq *CmdIsEq) Execute(cmdCtx *CmdCtx) (context.Context, error) {
 	}
 
 	val1, val2 := tokens[0], tokens[1]
+	if step, ok := StepFromCtx(ctx); ok {
+		step.RecordAssertion(AssertionResult{
+			Assertion: fmt.Sprintf("%s %s %s", CmdIsEqName, val1, val2),
+			Passed:    val1 == val2,
+			Expected:  val2,
+			Actual:    val1,
+		})
+	}
+
 	if val1 != val2 {
 		return ctx, fmt.Errorf("inequivalent values '%s' and '%s'", val1, val2)
 	}
@@ -46,6 +68,11 @@ func (sv *CmdFinalizeRec) Execute(cmdCtx *CmdCtx) (context.Context, error) {
 	rec := hdlr.GetCurrentModeCmd().(*CmdRec)
 	seqName := rec.currSequenceName
 
+	if len(rec.blocks) > 0 {
+		open := rec.blocks[len(rec.blocks)-1].step.Cmd[0]
+		return cmdCtx.Ctx, fmt.Errorf("'%s' block isn't closed, '%s' it first", open, CmdEndName)
+	}
+
 	if err := hdlr.FinalizeSequence(seqName); err != nil {
 		return cmdCtx.Ctx, fmt.Errorf("failed to save sequence '%s'", seqName)
 	} else {
@@ -60,6 +87,31 @@ func (fr *CmdFinalizeRec) AllowInModeWithoutArgs() bool {
 	return false
 }
 
+// Control flow steps are validated as they're recorded, they aren't run until the sequence is played
+func (cf *CmdFlow) Execute(cmdCtx *CmdCtx) (context.Context, error) {
+	step := &Step{Cmd: append([]string{cf.Name()}, cmdCtx.ExpandedTokens...)}
+	if _, err := parseFlow(step.Cmd); err != nil {
+		return cmdCtx.Ctx, err
+	}
+	return cmdCtx.Ctx, recModeCmd(cf.GetCmdHandler()).openBlock(step)
+}
+
+func (cf *CmdFlow) TakesExprs() bool {
+	return true
+}
+
+func (ce *CmdElse) Execute(cmdCtx *CmdCtx) (context.Context, error) {
+	return cmdCtx.Ctx, recModeCmd(ce.GetCmdHandler()).openElse()
+}
+
+func (ce *CmdEnd) Execute(cmdCtx *CmdCtx) (context.Context, error) {
+	return cmdCtx.Ctx, recModeCmd(ce.GetCmdHandler()).closeBlock()
+}
+
+func recModeCmd(hdlr CmdHandler) *CmdRec {
+	return hdlr.GetCurrentModeCmd().(*CmdRec)
+}
+
 func (sv *CmdFinalizeRec) saveSequence(seq Sequence, name string) error {
 	sequenceCfg, err := sv.loadPreExistingSequences()
 	if err != nil {
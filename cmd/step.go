@@ -1,36 +1,41 @@
 package cmd
 
 import (
-	"encoding/json"
-	"encoding/xml"
+	"context"
 	"fmt"
 	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/shubm-quodes/repl-reqs/network"
 	"github.com/shubm-quodes/repl-reqs/util"
 )
 
 type Step struct {
 	Name            string   `json:"name"`
 	Cmd             []string `json:"cmd"`
+	Body            Sequence `json:"body,omitempty"` // Steps of control flow steps, like '$if'
+	Else            Sequence `json:"else,omitempty"` // Steps of an '$if' step's '$else' branch
 	sequenceErrChan chan error
 	uChan           chan TaskStatus
 	Task            TaskUpdater
 	ParentStep      *Step
 	HasFailed       bool
+	err             error             // What the step's task failed with, if it did
+	assertions      []AssertionResult // Checks made by the step, for reports
 }
 
 var (
 	expansionRegex     = regexp.MustCompile(`\{\{([^}]+)\}\}`)
-	stepExpansionRegex = regexp.MustCompile(`^\$(\d+)\.(.+)$`)
+	stepExpansionRegex = regexp.MustCompile(`^\$(\d+)([.\[|\s].*)?$`)
 )
 
 func (s *Step) ExpandTokens(seq Sequence, variables map[string]string) ([]string, error) {
-	expandedCmd := make([]string, len(s.Cmd))
+	tokens := joinSplitExpansions(s.Cmd)
+	expandedCmd := make([]string, len(tokens))
 
-	for i, token := range s.Cmd {
+	for i, token := range tokens {
 		expanded, err := s.expandToken(token, seq, variables)
 		if err != nil {
 			return nil, fmt.Errorf("failed to expand token '%s': %w", token, err)
@@ -41,6 +46,24 @@ func (s *Step) ExpandTokens(seq Sequence, variables map[string]string) ([]string
 	return expandedCmd, nil
 }
 
+// Queries with spaces in them ('{{$1 | length}}') end up split across tokens, they're joined back.
+func joinSplitExpansions(tokens []string) []string {
+	joined := make([]string, 0, len(tokens))
+	open := false
+
+	for _, token := range tokens {
+		if open {
+			joined[len(joined)-1] += " " + token
+		} else {
+			joined = append(joined, token)
+		}
+
+		current := joined[len(joined)-1]
+		open = strings.LastIndex(current, "{{") > strings.LastIndex(current, "}}")
+	}
+	return joined
+}
+
 func (s *Step) expandToken(
 	token string,
 	seq Sequence,
@@ -84,38 +107,129 @@ func (s *Step) expandVariable(varName string, variables map[string]string) (stri
 }
 
 func (s *Step) expandStepBased(content string, seq Sequence) (string, error) {
+	resp, path, err := s.stepResponse(content, seq)
+	if err != nil {
+		return "", err
+	}
+
+	// Plain dotted paths keep their original semantics, anything else is a query (util.CompileQuery)
+	if legacyPath, ok := strings.CutPrefix(path, "."); ok && util.IsPlainPath(legacyPath) {
+		// Check if there's a filter condition (contains '=')
+		if strings.Contains(legacyPath, "=") {
+			return s.expandWithFilter(resp, legacyPath)
+		}
+
+		// Simple value extraction
+		return s.extractValue(resp, legacyPath)
+	}
+
+	return s.queryValue(resp, path)
+}
+
+// Values a '$N...' reference yields, without joining them. A single array is taken as its items.
+func (s *Step) stepValues(content string, seq Sequence) ([]any, error) {
+	resp, path, err := s.stepResponse(content, seq)
+	if err != nil {
+		return nil, err
+	}
+
+	legacyPath, isLegacy := strings.CutPrefix(path, ".")
+	if isLegacy && util.IsPlainPath(legacyPath) && strings.Contains(legacyPath, "=") {
+		joined, err := s.expandWithFilter(resp, legacyPath)
+		if err != nil {
+			return nil, err
+		}
+		return util.MapSlice(
+			strings.Split(joined, ","),
+			func(v string, _ int) any { return v },
+		), nil
+	}
+
+	expr := strings.TrimSpace(path)
+	if isLegacy && util.IsPlainPath(legacyPath) {
+		expr = legacyPath
+	} else if !strings.HasPrefix(expr, ".") {
+		expr = "." + expr
+	}
+
+	data, err := decodeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := util.QueryVal(data, expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 1 {
+		if items, ok := values[0].([]any); ok {
+			return items, nil
+		}
+	}
+	return values, nil
+}
+
+// The response of the step a '$N...' reference refers to, along with the path that follows it
+func (s *Step) stepResponse(content string, seq Sequence) (*http.Response, string, error) {
 	submatches := stepExpansionRegex.FindStringSubmatch(content)
 	if len(submatches) != 3 {
-		return "", fmt.Errorf("invalid step expansion format: %s", content)
+		return nil, "", fmt.Errorf("invalid step expansion format: %s", content)
 	}
 
 	stepNum, err := strconv.Atoi(submatches[1])
 	if err != nil {
-		return "", fmt.Errorf("invalid step number: %s", submatches[1])
+		return nil, "", fmt.Errorf("invalid step number: %s", submatches[1])
 	}
 
 	// Convert to 0-based index
 	stepIndex := stepNum - 1
 
 	if stepIndex < 0 || stepIndex >= len(seq) {
-		return "", fmt.Errorf("step %d is out of range (sequence has %d steps)", stepNum, len(seq))
+		return nil, "", fmt.Errorf(
+			"step %d is out of range (sequence has %d steps)",
+			stepNum,
+			len(seq),
+		)
 	}
 
 	targetStep := seq[stepIndex]
 
 	if targetStep.Task == nil || targetStep.Task.GetResult() == nil {
-		return "", fmt.Errorf("step %d has no result available", stepNum)
+		return nil, "", fmt.Errorf("step %d has no result available", stepNum)
 	}
 
-	path := submatches[2]
+	resp, ok := targetStep.Task.GetResult().(*http.Response)
+	if !ok || resp == nil {
+		return nil, "", fmt.Errorf("step %d has no response to refer to", stepNum)
+	}
+	return resp, submatches[2], nil
+}
+
+// Results of '{{$1 | .items[].id}}' style queries, multiple results are joined by ','
+func (s *Step) queryValue(resp *http.Response, expr string) (string, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, ".") {
+		expr = "." + expr
+	}
+
+	q, err := util.CompileQuery(expr)
+	if err != nil {
+		return "", err
+	}
 
-	// Check if there's a filter condition (contains '=')
-	if strings.Contains(path, "=") {
-		return s.expandWithFilter(targetStep.Task.GetResult().(*http.Response), path)
+	data, err := decodeResponse(resp)
+	if err != nil {
+		return "", err
 	}
 
-	// Simple value extraction
-	return s.extractValue(targetStep.Task.GetResult().(*http.Response), path)
+	results, err := q.Run(data)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return "", fmt.Errorf("query '%s' yielded no results", expr)
+	}
+	return util.StringifyQueryResults(results), nil
 }
 
 func (s *Step) expandWithFilter(resp *http.Response, path string) (string, error) {
@@ -186,26 +300,14 @@ func (s *Step) extractValue(resp *http.Response, path string) (string, error) {
 	return fmt.Sprintf("%v", current), nil
 }
 
+// Decodes the body as per it's content type (see network.DecodeBody), the body is left intact so
+// that the response can be referred to more than once.
 func decodeResponse(resp *http.Response) (any, error) {
-	defer resp.Body.Close()
-
-	contentType := resp.Header.Get("Content-Type")
-
-	var data any
-
-	if strings.Contains(contentType, "application/json") {
-		if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-			return nil, fmt.Errorf("failed to decode JSON: %w", err)
-		}
-	} else if strings.Contains(contentType, "application/xml") || strings.Contains(contentType, "text/xml") {
-		if err := xml.NewDecoder(resp.Body).Decode(&data); err != nil {
-			return nil, fmt.Errorf("failed to decode XML: %w", err)
-		}
-	} else {
-		return nil, fmt.Errorf("unsupported content type: %s", contentType)
+	raw, err := util.ReadAndResetIoCloser(&resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
-
-	return data, nil
+	return network.DecodeBody(resp.Header.Get("Content-Type"), raw)
 }
 
 func formatResult(data any) string {
@@ -276,6 +378,30 @@ func filterArray(
 	return results, nil
 }
 
+// The step being played, when a command is executed as part of a sequence
+func StepFromCtx(ctx context.Context) (*Step, bool) {
+	step, ok := ctx.Value(StepKey).(*Step)
+	return step, ok && step != nil
+}
+
+// Assertions made by the step end up in the sequence's report, if there's one
+func (s *Step) RecordAssertion(result AssertionResult) {
+	s.assertions = append(s.assertions, result)
+}
+
+// The response of the closest preceding step that yielded one, steps like assertions don't
+func (s *Step) PrecedingResponse() (*http.Response, bool) {
+	for p := s.ParentStep; p != nil; p = p.ParentStep {
+		if p.Task == nil {
+			continue
+		}
+		if resp, ok := p.Task.GetResult().(*http.Response); ok && resp != nil {
+			return resp, true
+		}
+	}
+	return nil, false
+}
+
 // GetCmd returns the command slice
 func (s *Step) GetCmd() []string {
 	return s.Cmd
@@ -295,6 +421,7 @@ func (s *Step) GetName() string {
 func (s *Step) watchForUpdates(originalTask TaskUpdater) {
 	u := <-s.uChan //block until complete
 	if u.Error != nil {
+		s.err = u.Error
 		s.sequenceErrChan <- fmt.Errorf(
 			"sequence step %s failed. failed to exec cmd %s: %s",
 			s.GetName(),
@@ -0,0 +1,95 @@
+package syscmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shubm-quodes/repl-reqs/cmd"
+	"github.com/shubm-quodes/repl-reqs/network"
+)
+
+const CmdAssertName = "$assert"
+
+type CmdAssert struct {
+	*BaseReqCmd
+}
+
+/*
+'$assert [#task] <assertion> [&& <assertion>...]' checks the last response (or the task's, or the
+preceding step's within a sequence), for instance:
+  - '$status=200', '$header.Content-Type~=json', '$body.user.id=1' (see network.NewCondition)
+  - '$length.items>=3' for the number of items, keys or characters at the body path
+  - '$time<500' for the time the request took, in milliseconds
+
+Every assertion is reported with what was expected & what was found, the task fails if any of them
+don't hold. Within sequences the results are recorded in the step's report.
+*/
+func (ca *CmdAssert) ExecuteAsync(cmdCtx *cmd.CmdCtx) {
+	t := cmdCtx.Task
+	tokens := cmdCtx.ExpandedTokens
+
+	var taskId string
+	if len(tokens) > 0 && strings.HasPrefix(tokens[0], "#") {
+		taskId, tokens = tokens[0], tokens[1:]
+	}
+
+	assertions, err := network.NewAssertions(strings.Join(tokens, " "))
+	if err != nil {
+		t.Fail(err)
+		return
+	}
+
+	trackerReq, err := ca.resolveTrackerRequest(cmdCtx, taskId)
+	if err != nil {
+		t.Fail(err)
+		return
+	}
+	if trackerReq.Status == network.StatusProcessing {
+		t.Fail(errors.New("request is still in progress"))
+		return
+	}
+	if trackerReq.FullResponse == nil {
+		t.Fail(errors.New("there's no response to assert on"))
+		return
+	}
+
+	step, inSeq := cmd.StepFromCtx(cmdCtx.Ctx)
+	var lines, failures []string
+	for _, a := range assertions {
+		outcome := a.Check(trackerReq.FullResponse, trackerReq.Timing.Total)
+		if inSeq {
+			step.RecordAssertion(cmd.AssertionResult{
+				Assertion: a.Raw,
+				Passed:    outcome.Passed,
+				Expected:  outcome.Expected,
+				Actual:    outcome.Actual,
+			})
+		}
+
+		if outcome.Passed {
+			lines = append(lines, fmt.Sprintf("✅ %s", a.Raw))
+			continue
+		}
+
+		failure := fmt.Sprintf(
+			"%s (expected: %s, actual: %s)",
+			a.Raw,
+			outcome.Expected,
+			outcome.Actual,
+		)
+		lines = append(lines, "❌ "+failure)
+		failures = append(failures, failure)
+	}
+
+	t.AppendOutput(strings.Join(lines, "\n"))
+	if len(failures) > 0 {
+		t.Fail(fmt.Errorf("assertion failed: %s", strings.Join(failures, ", ")))
+		return
+	}
+	t.CompleteWithMessage(fmt.Sprintf("%d assertion(s) passed", len(assertions)), nil)
+}
+
+func (ca *CmdAssert) TakesExprs() bool {
+	return true
+}
@@ -1,6 +1,9 @@
 package syscmd
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/shubm-quodes/repl-reqs/cmd"
@@ -110,3 +113,49 @@ func (rc *BaseReqCmd) AllowInModeWithoutArgs() bool {
 func (rc *InModeBaseReqCmd) AllowInModeWithoutArgs() bool {
 	return true
 }
+
+// Resolves the tracker request of the last response, or the one of a task if its id is supplied.
+// Within a sequence the last response is the one of the closest preceding step that yielded one.
+func (brc *BaseReqCmd) resolveTrackerRequest(
+	cmdCtx *cmd.CmdCtx,
+	taskId string,
+) (*network.TrackerRequest, error) {
+	if step, ok := cmd.StepFromCtx(cmdCtx.Ctx); ok && taskId == "" {
+		resp, found := step.PrecedingResponse()
+		if !found {
+			return nil, errors.New("none of the preceding steps have a response")
+		}
+		return brc.findTrackerRequest(resp), nil
+	}
+
+	if taskId == "" {
+		return brc.Mgr.PeakTrackerRequest(cmdCtx.ID())
+	}
+
+	status, err := brc.GetCmdHandler().GetTaskStatus(taskId)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, ok := status.Result.(*http.Response)
+	if !ok {
+		return nil, fmt.Errorf("task '%s' doesn't have a response to inspect", taskId)
+	}
+	return brc.Mgr.FindTrackerRequest(resp)
+}
+
+// Responses that weren't tracked are wrapped as they are, without any timing
+func (brc *BaseReqCmd) findTrackerRequest(resp *http.Response) *network.TrackerRequest {
+	if trackerReq, err := brc.Mgr.FindTrackerRequest(resp); err == nil {
+		return trackerReq
+	}
+
+	return &network.TrackerRequest{
+		Request:         &network.Request{HttpRequest: resp.Request},
+		Status:          network.StatusCompleted,
+		StatusCode:      resp.StatusCode,
+		ResponseHeaders: resp.Header,
+		ResponseBody:    resp.Body,
+		FullResponse:    resp,
+	}
+}
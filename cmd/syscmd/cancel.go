@@ -0,0 +1,40 @@
+package syscmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shubm-quodes/repl-reqs/cmd"
+)
+
+const CmdCancelName = "$cancel"
+
+type CmdCancel struct {
+	*cmd.BaseNonModeCmd
+}
+
+func (cc *CmdCancel) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	ctx, tokens := cmdCtx.Ctx, cmdCtx.ExpandedTokens
+	if len(tokens) == 0 {
+		return ctx, errors.New("please specify task id(s), for instance '#2'")
+	}
+
+	hdlr := cc.GetCmdHandler()
+	for _, id := range tokens {
+		if err := hdlr.CancelTask(id); err != nil {
+			return ctx, err
+		}
+		hdlr.OutF(cmdCtx, "cancelling task %s 🚫\n", id)
+	}
+
+	return ctx, nil
+}
+
+func (cc *CmdCancel) GetSuggestions(tokens [][]rune) ([][]rune, int) {
+	var search string
+	if len(tokens) > 0 {
+		search = string(tokens[len(tokens)-1])
+	}
+
+	return cc.GetCmdHandler().SuggestTasks(search), len(search)
+}
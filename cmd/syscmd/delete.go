@@ -16,8 +16,9 @@ const (
 	CmdDeleteName = "$delete"
 
 	// Sub cmds
-	CmdDeleteVarName = "var"
-	CmdDeleteSeqName = "sequence"
+	CmdDeleteVarName    = "var"
+	CmdDeleteSeqName    = "sequence"
+	CmdDeleteCookieName = "cookie"
 )
 
 type CmdDelete struct {
@@ -32,6 +33,10 @@ type CmdDeleteSeq struct {
 	*cmd.BaseCmd
 }
 
+type CmdDeleteCookie struct {
+	*BaseReqCmd
+}
+
 func (dltVar *CmdDeleteVar) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
 	ctx, tokens := cmdCtx.Ctx, cmdCtx.ExpandedTokens
 	if len(tokens) == 0 {
@@ -116,3 +121,49 @@ func (dltVar *CmdDeleteVar) GetSuggestions(tokens [][]rune) ([][]rune, int) {
 
 	return util.RuneSliceDiff(suggestions, alreadySuggested), len(search)
 }
+
+func (dltCookie *CmdDeleteCookie) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	ctx, tokens := cmdCtx.Ctx, cmdCtx.ExpandedTokens
+	if len(tokens) == 0 {
+		return ctx, errors.New("please specify cookie name(s)❗️")
+	}
+
+	jar := dltCookie.Mgr.CookieJar()
+	if jar == nil {
+		return ctx, errors.New("cookie jar unavailable")
+	}
+
+	for _, name := range tokens {
+		if jar.Delete(name) == 0 {
+			return ctx, fmt.Errorf("'%s' doesn't seem to exist 😬", name)
+		}
+	}
+
+	dltCookie.GetCmdHandler().
+		OutF(cmdCtx, "done, '%s' crumbled away 🍪\n", strings.Join(tokens, ", "))
+	return ctx, nil
+}
+
+func (dltCookie *CmdDeleteCookie) GetSuggestions(tokens [][]rune) ([][]rune, int) {
+	jar := dltCookie.Mgr.CookieJar()
+	if jar == nil {
+		return nil, 0
+	}
+
+	var search string
+	if len(tokens) > 0 {
+		search = string(tokens[len(tokens)-1])
+	}
+
+	names := make(map[string]struct{})
+	for _, c := range jar.List() {
+		names[c.Name] = struct{}{}
+	}
+
+	criteria := &util.MatchCriteria[struct{}]{
+		Search:     search,
+		SuffixWith: " ",
+		M:          names,
+	}
+	return util.GetMatchingMapKeysAsRunes(criteria), len(search)
+}
@@ -0,0 +1,232 @@
+package syscmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/shubm-quodes/repl-reqs/cmd"
+	"github.com/shubm-quodes/repl-reqs/network"
+	"github.com/shubm-quodes/repl-reqs/util"
+)
+
+const CmdDiffName = "$diff"
+
+type CmdDiff struct {
+	*BaseReqCmd
+}
+
+// One side of a diff, responses & snapshots are compared the same way
+type diffSide struct {
+	status  string
+	headers map[string]any
+	body    any
+
+	// Snapshots only record some of the headers, only those are compared
+	isSnapshot bool
+}
+
+// '$diff <a> <b>' compares two responses, each side being a history entry ('3', 1 being the most
+// recent one), a task ('#2') or a snapshot ('@name')
+func (cd *CmdDiff) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	tokens := cmdCtx.ExpandedTokens
+	if len(tokens) != 2 {
+		return cmdCtx.Ctx, fmt.Errorf(
+			"please specify the two responses to compare, for instance '%s 2 1' or '%s #1 @users'",
+			CmdDiffName,
+			CmdDiffName,
+		)
+	}
+
+	a, err := cd.resolveSide(cmdCtx, tokens[0])
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	b, err := cd.resolveSide(cmdCtx, tokens[1])
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	cd.GetCmdHandler().Out(cmdCtx, formatResponseDiff(tokens[0], tokens[1], a, b))
+	return cmdCtx.Ctx, nil
+}
+
+func (cd *CmdDiff) resolveSide(cmdCtx *cmd.CmdCtx, ref string) (*diffSide, error) {
+	if name, ok := strings.CutPrefix(ref, "@"); ok {
+		snap, err := getSnapshotStore().Load(name)
+		if err != nil {
+			return nil, err
+		}
+
+		headers := make(map[string]any, len(snap.Headers))
+		for key, val := range snap.Headers {
+			headers[http.CanonicalHeaderKey(key)] = val
+		}
+		return &diffSide{
+			status:     fmt.Sprintf("%d %s", snap.StatusCode, http.StatusText(snap.StatusCode)),
+			headers:    headers,
+			body:       snap.Body,
+			isSnapshot: true,
+		}, nil
+	}
+
+	resp, err := cd.resolveResponse(cmdCtx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return &diffSide{
+		status:  resp.Status,
+		headers: headersToMap(resp.Header),
+		body:    decodeDiffBody(resp),
+	}, nil
+}
+
+func (cd *CmdDiff) resolveResponse(cmdCtx *cmd.CmdCtx, ref string) (*http.Response, error) {
+	if strings.HasPrefix(ref, "#") {
+		trackerReq, err := cd.resolveTrackerRequest(cmdCtx, ref)
+		if err != nil {
+			return nil, err
+		}
+		if trackerReq.FullResponse == nil {
+			return nil, fmt.Errorf("task '%s' doesn't have a response to compare", ref)
+		}
+		return trackerReq.FullResponse, nil
+	}
+
+	if _, err := strconv.Atoi(ref); err != nil {
+		return nil, fmt.Errorf(
+			"invalid response '%s', expected a history entry (e.g. 2), a task (e.g. #1) or a "+
+				"snapshot (e.g. @users)",
+			ref,
+		)
+	}
+
+	entry, err := getHistoryEntry(cd.BaseReqCmd, []string{ref})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := entry.Response()
+	if resp == nil {
+		return nil, fmt.Errorf("history entry %s doesn't have a response to compare", ref)
+	}
+	return resp, nil
+}
+
+func formatResponseDiff(refA, refB string, a, b *diffSide) string {
+	var (
+		sb        strings.Builder
+		identical = true
+	)
+
+	fmt.Fprintf(&sb, "\n🔍 %s ↔ %s\n\n", color.HiYellowString(refA), color.HiYellowString(refB))
+
+	if a.status != b.status {
+		identical = false
+		fmt.Fprintf(&sb, "%s\n", color.HiWhiteString("Status"))
+		fmt.Fprintf(&sb, "  %s → %s\n\n", color.RedString(a.status), color.GreenString(b.status))
+	}
+
+	aHeaders, bHeaders := a.headers, b.headers
+	if a.isSnapshot {
+		bHeaders = pickKeys(bHeaders, aHeaders)
+	}
+	if b.isSnapshot {
+		aHeaders = pickKeys(aHeaders, bHeaders)
+	}
+
+	if changes := util.Diff(aHeaders, bHeaders); len(changes) > 0 {
+		identical = false
+		fmt.Fprintf(&sb, "%s\n", color.HiWhiteString("Headers"))
+		writeChanges(&sb, changes)
+		sb.WriteString("\n")
+	}
+
+	if changes := util.Diff(a.body, b.body); len(changes) > 0 {
+		identical = false
+		fmt.Fprintf(&sb, "%s\n", color.HiWhiteString("Body"))
+		writeChanges(&sb, changes)
+		sb.WriteString("\n")
+	}
+
+	if identical {
+		sb.WriteString("✅ no differences\n\n")
+	}
+	return sb.String()
+}
+
+// The entries of m whose keys are present in keys
+func pickKeys(m, keys map[string]any) map[string]any {
+	picked := make(map[string]any, len(keys))
+	for key := range keys {
+		if val, ok := m[key]; ok {
+			picked[key] = val
+		}
+	}
+	return picked
+}
+
+// Header values are compared as a whole, names are canonicalized
+func headersToMap(header http.Header) map[string]any {
+	m := make(map[string]any, len(header))
+	for key, vals := range header {
+		m[http.CanonicalHeaderKey(key)] = strings.Join(vals, ", ")
+	}
+	return m
+}
+
+// Bodies that can't be decoded are compared as they are
+func decodeDiffBody(resp *http.Response) any {
+	raw, err := util.ReadAndResetIoCloser(&resp.Body)
+	if err != nil || len(raw) == 0 {
+		return nil
+	}
+
+	body, err := network.DecodeBody(resp.Header.Get("Content-Type"), raw)
+	if err != nil {
+		return string(raw)
+	}
+	return body
+}
+
+func writeChanges(sb *strings.Builder, changes []util.Change) {
+	for _, c := range changes {
+		path := c.Path
+		if path == "" {
+			path = "(whole)"
+		}
+
+		switch c.Kind {
+		case util.ChangeAdded:
+			fmt.Fprintf(sb, "  %s\n", color.GreenString("+ %s: %s", path, formatDiffVal(c.New)))
+		case util.ChangeRemoved:
+			fmt.Fprintf(sb, "  %s\n", color.RedString("- %s: %s", path, formatDiffVal(c.Old)))
+		default:
+			fmt.Fprintf(
+				sb,
+				"  %s %s: %s → %s\n",
+				color.YellowString("~"),
+				path,
+				color.RedString(formatDiffVal(c.Old)),
+				color.GreenString(formatDiffVal(c.New)),
+			)
+		}
+	}
+}
+
+func formatDiffVal(val any) string {
+	if val == nil {
+		return "null"
+	}
+
+	b, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprint(val)
+	}
+	return util.GetTruncatedStr(string(b))
+}
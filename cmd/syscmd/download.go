@@ -0,0 +1,218 @@
+package syscmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/shubm-quodes/repl-reqs/cmd"
+	"github.com/shubm-quodes/repl-reqs/network"
+	"github.com/shubm-quodes/repl-reqs/util"
+)
+
+const (
+	CmdDownloadName = "$download"
+
+	downloadLastReq   = "last"
+	downloadOutFlag   = "-o"
+	downloadResumeArg = "--resume"
+)
+
+type CmdDownload struct {
+	*BaseReqCmd
+}
+
+// $download <cmd...|last> <path> [--resume]
+func (cd *CmdDownload) ExecuteAsync(cmdCtx *cmd.CmdCtx) {
+	task := cmdCtx.Task
+	tokens, resume := extractFlag(cmdCtx.ExpandedTokens, downloadResumeArg)
+
+	if len(tokens) < 2 {
+		task.Fail(
+			errors.New("please specify a request command (or 'last') and the destination path"),
+		)
+		return
+	}
+
+	target, path := tokens[:len(tokens)-1], tokens[len(tokens)-1]
+	req, err := cd.resolveDownloadReq(cmdCtx, target)
+	if err != nil {
+		task.Fail(err)
+		return
+	}
+
+	cd.Download(req, cmdCtx, network.DownloadOpts{Path: path, Resume: resume})
+}
+
+func (cd *CmdDownload) resolveDownloadReq(
+	cmdCtx *cmd.CmdCtx,
+	target []string,
+) (*http.Request, error) {
+	if len(target) == 1 && target[0] == downloadLastReq {
+		tr, err := cd.Mgr.PeakTrackerRequest(cmdCtx.ID())
+		if err != nil {
+			return nil, err
+		}
+		return cloneForReplay(tr)
+	}
+
+	c, params := cd.GetCmdHandler().ResolveCommandFromRoot(target)
+	reqCmd, ok := c.(*ReqCmd)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a request command", strings.Join(target, " "))
+	}
+
+	cmdParams, err := reqCmd.getCmdParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return reqCmd.buildRequest(cmdParams)
+}
+
+func (cd *CmdDownload) GetSuggestions(tokens [][]rune) ([][]rune, int) {
+	if len(tokens) <= 1 {
+		var search string
+		if len(tokens) == 1 {
+			search = string(tokens[0])
+		}
+
+		suggestions, offset := cd.SuggestWithoutParams(tokens)
+		if strings.HasPrefix(downloadLastReq, search) && search != downloadLastReq {
+			suggestions = append(suggestions, []rune(downloadLastReq[len(search):]+" "))
+		}
+		return suggestions, offset
+	}
+	return cd.SuggestWithoutParams(tokens)
+}
+
+func (cd *CmdDownload) AllowInModeWithoutArgs() bool {
+	return false
+}
+
+// Streams the response body to the destination, reporting progress through the task's message
+func (brc *BaseReqCmd) Download(req *http.Request, cmdCtx *cmd.CmdCtx, opts network.DownloadOpts) {
+	task := cmdCtx.Task
+	if absPath, err := filepath.Abs(opts.Path); err == nil {
+		opts.Path = absPath
+	}
+
+	opts.OnProgress = func(p network.DownloadProgress) {
+		task.UpdateMessage(formatDownloadProgress(p))
+	}
+
+	req = req.WithContext(cmdCtx.Ctx)
+	_, netUpdate, err := brc.Mgr.MakeRequestToFile(cmdCtx.ID(), req, opts)
+	if err != nil {
+		task.Fail(err)
+		return
+	}
+
+	result := <-netUpdate
+	if result.Err() != nil {
+		task.Fail(result.Err())
+		return
+	}
+
+	resp := result.Resp()
+	switch {
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && opts.Resume:
+		task.AppendOutput(fmt.Sprintf("'%s' seems to be completely downloaded already", opts.Path))
+	case resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusPartialContent:
+		task.AppendOutput(fmt.Sprintf("⬇️  saved to '%s'", opts.Path))
+	default: // Error responses aren't written to disk
+		task.AppendOutput(getFormattedResp(resp))
+	}
+
+	task.AppendOutput(resp.Status)
+	task.Complete(resp)
+}
+
+func formatDownloadProgress(p network.DownloadProgress) string {
+	rate := util.FormatBytes(int64(p.Rate())) + "/s"
+	if p.Total < 0 {
+		return fmt.Sprintf("⬇️  %s · %s", util.FormatBytes(p.Written), rate)
+	}
+
+	percent := float64(p.Written) / float64(max(p.Total, 1)) * 100
+	return fmt.Sprintf(
+		"⬇️  %s / %s (%.1f%%) · %s",
+		util.FormatBytes(p.Written),
+		util.FormatBytes(p.Total),
+		percent,
+		rate,
+	)
+}
+
+// Extracts '-o <file> [--resume]' from request command tokens
+func extractDownloadOpts(tokens []string) ([]string, *network.DownloadOpts, error) {
+	tokens, resume := extractFlag(tokens, downloadResumeArg)
+
+	tokens, path, ok, err := extractFlagVal(tokens, downloadOutFlag)
+	if err != nil {
+		return nil, nil, errors.New("please specify the output file after '-o'")
+	}
+
+	if !ok {
+		if resume {
+			return nil, nil, errors.New("'--resume' can only be used along with '-o <file>'")
+		}
+		return tokens, nil, nil
+	}
+	return tokens, &network.DownloadOpts{Path: path, Resume: resume}, nil
+}
+
+// Removes the first occurrence of the flag along with it's value
+func extractFlagVal(tokens []string, flag string) ([]string, string, bool, error) {
+	for i, token := range tokens {
+		if token != flag {
+			continue
+		}
+
+		if i+1 >= len(tokens) {
+			return nil, "", false, fmt.Errorf("please specify a value for '%s'", flag)
+		}
+
+		rest := append(append([]string{}, tokens[:i]...), tokens[i+2:]...)
+		return rest, tokens[i+1], true, nil
+	}
+	return tokens, "", false, nil
+}
+
+// Removes all occurrences of the flag, reporting whether it was present
+func extractFlag(tokens []string, flag string) ([]string, bool) {
+	rest := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if token != flag {
+			rest = append(rest, token)
+		}
+	}
+	return rest, len(rest) != len(tokens)
+}
+
+// Clones an already sent request so that it can be sent again. Its headers are taken as they were
+// set on it, the common headers and cookies are added again when it's sent.
+func cloneForReplay(tr *network.TrackerRequest) (*http.Request, error) {
+	if tr.Request == nil || tr.Request.HttpRequest == nil {
+		return nil, errors.New("no request to replay")
+	}
+
+	req := tr.Request.HttpRequest
+	clone := req.Clone(req.Context())
+	if tr.RequestHeaders != nil {
+		clone.Header = tr.RequestHeaders.Clone()
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, errors.New("the request's body can't be replayed")
+		}
+
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
@@ -0,0 +1,320 @@
+package syscmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/shubm-quodes/repl-reqs/cmd"
+	"github.com/shubm-quodes/repl-reqs/network"
+	"github.com/shubm-quodes/repl-reqs/util"
+)
+
+const (
+	// Root cmd
+	CmdDraftName = "$draft"
+
+	// Sub cmds
+	CmdDraftLsName      = "ls"
+	CmdDraftUseName     = "use"
+	CmdDraftCloneName   = "clone"
+	CmdDraftDiscardName = "discard"
+	CmdDraftLabelName   = "name"
+	CmdDraftRestoreName = "restore"
+)
+
+type CmdDraft struct {
+	*cmd.BaseCmd
+}
+
+type CmdDraftLs struct {
+	*BaseReqCmd
+}
+
+type CmdDraftUse struct {
+	*BaseReqCmd
+}
+
+type CmdDraftClone struct {
+	*BaseReqCmd
+}
+
+type CmdDraftDiscard struct {
+	*BaseReqCmd
+}
+
+type CmdDraftLabel struct {
+	*BaseReqCmd
+}
+
+type CmdDraftRestore struct {
+	*BaseReqCmd
+}
+
+func (cl *CmdDraftLs) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	hdlr := cl.GetCmdHandler()
+	drafts := cl.Mgr.GetRequestDrafts(cmdCtx.ID())
+	if len(drafts) == 0 {
+		hdlr.OutF(
+			cmdCtx,
+			"\nNo drafts, start drafting requests using %s command 📝\n\n",
+			CmdDraftReqName,
+		)
+		return cmdCtx.Ctx, nil
+	}
+
+	hdlr.Out(cmdCtx, "\n📝 Drafts - (most recent first)\n\n")
+	for i, draft := range drafts {
+		marker := "  "
+		if i == 0 {
+			marker = color.GreenString("▶ ")
+		}
+		hdlr.OutF(cmdCtx, "%s%d. %s\n", marker, i+1, describeDraft(draft))
+	}
+	hdlr.Out(cmdCtx, "\n")
+	return cmdCtx.Ctx, nil
+}
+
+// '$draft use <n|name>' switches to another draft
+func (cu *CmdDraftUse) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	tokens := cmdCtx.ExpandedTokens
+	if len(tokens) == 0 {
+		return cmdCtx.Ctx, errors.New("please specify the draft's number or name")
+	}
+
+	draft, err := useDraft(cu.BaseReqCmd, cmdCtx, strings.Join(tokens, " "))
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	cu.GetCmdHandler().OutF(cmdCtx, "now drafting %s\n", describeDraft(draft))
+	return cmdCtx.Ctx, nil
+}
+
+func (cu *CmdDraftUse) GetSuggestions(tokens [][]rune) ([][]rune, int) {
+	return suggestDraftLabels(cu.BaseReqCmd, tokens)
+}
+
+// '$draft clone [n|name]' clones the current (or specified) draft, the clone becomes current
+func (cc *CmdDraftClone) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	draft, err := findDraft(cc.BaseReqCmd, cmdCtx, strings.Join(cmdCtx.ExpandedTokens, " "))
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	clone := draft.Clone()
+	if clone.GetLabel() != "" {
+		clone.SetLabel(clone.GetLabel() + " (copy)")
+	}
+
+	cc.Mgr.AddDraftRequest(cmdCtx.ID(), clone)
+	setDraftPrompt(cc.GetCmdHandler(), clone)
+	cc.GetCmdHandler().OutF(cmdCtx, "cloned into %s\n", describeDraft(clone))
+	return cmdCtx.Ctx, nil
+}
+
+func (cc *CmdDraftClone) GetSuggestions(tokens [][]rune) ([][]rune, int) {
+	return suggestDraftLabels(cc.BaseReqCmd, tokens)
+}
+
+// '$draft discard [n|name]' drops the current (or specified) draft
+func (cd *CmdDraftDiscard) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	draft, err := findDraft(cd.BaseReqCmd, cmdCtx, strings.Join(cmdCtx.ExpandedTokens, " "))
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	hdlr := cd.GetCmdHandler()
+	current := cd.Mgr.PeakRequestDraft(cmdCtx.ID())
+	cd.Mgr.RemoveRequestDraft(cmdCtx.ID(), draft.GetId())
+	hdlr.OutF(cmdCtx, "discarded %s 🗑️\n", describeDraft(draft))
+
+	if current != draft {
+		return cmdCtx.Ctx, nil
+	}
+
+	// The next most recent draft takes over
+	if next := cd.Mgr.PeakRequestDraft(cmdCtx.ID()); next != nil {
+		setDraftPrompt(hdlr, next)
+	} else {
+		cfg := hdlr.GetAppCfg()
+		hdlr.SetPrompt(cfg.GetPrompt(), cfg.GetPromptMascot())
+	}
+	return cmdCtx.Ctx, nil
+}
+
+func (cd *CmdDraftDiscard) GetSuggestions(tokens [][]rune) ([][]rune, int) {
+	return suggestDraftLabels(cd.BaseReqCmd, tokens)
+}
+
+// '$draft name <name>' labels the current draft, so it can be referred to by name
+func (cl *CmdDraftLabel) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	tokens := cmdCtx.ExpandedTokens
+	if len(tokens) == 0 {
+		return cmdCtx.Ctx, errors.New("please specify a name for the draft")
+	}
+
+	label := strings.Join(tokens, " ")
+	if _, err := strconv.Atoi(label); err == nil {
+		return cmdCtx.Ctx, errors.New("names can't be numbers, they'd be confused with positions")
+	}
+
+	draft := cl.Mgr.PeakRequestDraft(cmdCtx.ID())
+	if draft == nil {
+		return cmdCtx.Ctx, fmt.Errorf(
+			"no drafts, start drafting requests using %s command",
+			CmdDraftReqName,
+		)
+	}
+
+	draft.SetLabel(label)
+	cl.Mgr.SaveDrafts(cmdCtx.ID())
+	setDraftPrompt(cl.GetCmdHandler(), draft)
+	return cmdCtx.Ctx, nil
+}
+
+// '$draft restore [sessions]' brings back drafts discarded in the last n sessions (the current one
+// included), all of the retained ones by default
+func (cr *CmdDraftRestore) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	sessions := network.DiscardedDraftSessions
+	if tokens := cmdCtx.ExpandedTokens; len(tokens) > 0 {
+		n, err := strconv.Atoi(tokens[0])
+		if err != nil || n < 1 {
+			return cmdCtx.Ctx, fmt.Errorf("invalid number of sessions '%s'", tokens[0])
+		}
+		sessions = n
+	}
+
+	restored, err := cr.Mgr.RestoreDiscardedDrafts(cmdCtx.ID(), sessions)
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	hdlr := cr.GetCmdHandler()
+	if len(restored) == 0 {
+		hdlr.OutF(cmdCtx, "no drafts discarded in the last %d session(s)\n", sessions)
+		return cmdCtx.Ctx, nil
+	}
+
+	for _, draft := range restored {
+		hdlr.OutF(cmdCtx, "restored %s ♻️\n", describeDraft(draft))
+	}
+	setDraftPrompt(hdlr, restored[len(restored)-1])
+	return cmdCtx.Ctx, nil
+}
+
+// Looks up a draft by it's position (starting at 1) or name, defaults to the current draft
+func findDraft(
+	brc *BaseReqCmd,
+	cmdCtx *cmd.CmdCtx,
+	ref string,
+) (*network.RequestDraft, error) {
+	drafts := brc.Mgr.GetRequestDrafts(cmdCtx.ID())
+	if len(drafts) == 0 {
+		return nil, fmt.Errorf(
+			"no drafts, start drafting requests using %s command",
+			CmdDraftReqName,
+		)
+	}
+
+	if ref == "" {
+		return drafts[0], nil
+	}
+
+	idx, err := draftIndex(drafts, ref)
+	if err != nil {
+		return nil, err
+	}
+	return drafts[idx], nil
+}
+
+func useDraft(
+	brc *BaseReqCmd,
+	cmdCtx *cmd.CmdCtx,
+	ref string,
+) (*network.RequestDraft, error) {
+	idx, err := draftIndex(brc.Mgr.GetRequestDrafts(cmdCtx.ID()), ref)
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := brc.Mgr.UseRequestDraft(cmdCtx.ID(), idx)
+	if err != nil {
+		return nil, err
+	}
+
+	setDraftPrompt(brc.GetCmdHandler(), draft)
+	return draft, nil
+}
+
+func draftIndex(drafts []*network.RequestDraft, ref string) (int, error) {
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 1 || n > len(drafts) {
+			return 0, fmt.Errorf("draft #%d not found, there are %d drafts", n, len(drafts))
+		}
+		return n - 1, nil
+	}
+
+	for i, draft := range drafts {
+		if draft.GetLabel() == ref {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no draft named '%s'", ref)
+}
+
+func suggestDraftLabels(brc *BaseReqCmd, tokens [][]rune) ([][]rune, int) {
+	var search string
+	if len(tokens) > 1 {
+		return nil, 0
+	} else if len(tokens) == 1 {
+		search = string(tokens[0])
+	}
+
+	labels := make(map[string]struct{})
+	ctxId, _ := brc.GetCmdHandler().GetDefaultCtx().Value(cmd.CmdCtxIdKey).(string)
+	for _, draft := range brc.Mgr.GetRequestDrafts(ctxId) {
+		if draft.GetLabel() != "" {
+			labels[draft.GetLabel()] = struct{}{}
+		}
+	}
+
+	return util.GetMatchingMapKeysAsRunes(&util.MatchCriteria[struct{}]{
+		Search: search,
+		M:      labels,
+	}), len(search)
+}
+
+// [POST] https://example.com/users · signup
+func describeDraft(draft *network.RequestDraft) string {
+	method, url := string(draft.GetMethod()), draft.GetUrl()
+	if method == "" {
+		method = "?"
+	}
+	if url == "" {
+		url = color.HiBlackString("<no url>")
+	}
+
+	desc := fmt.Sprintf("[%s] %s", method, url)
+	if label := draft.GetLabel(); label != "" {
+		desc += " · " + color.HiYellowString(label)
+	}
+	return desc
+}
+
+// Drafts without a url yet are shown by their name, if they've got one
+func setDraftPrompt(hdlr cmd.CmdHandler, draft *network.RequestDraft) {
+	if draft.GetUrl() != "" {
+		setReqDraftPrompt(hdlr, draft)
+		return
+	}
+
+	prompt := "Request Draft"
+	if label := draft.GetLabel(); label != "" {
+		prompt = fmt.Sprintf("Request Draft (%s)", label)
+	}
+	hdlr.SetPrompt(prompt, "")
+}
@@ -84,7 +84,10 @@ func (er *CmdEditReq) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
 	if er.Mgr.PeakRequestDraft(cmdCtx.ID()) != rd {
 		er.Mgr.AddDraftRequest(cmdCtx.ID(), rd)
 	}
-	return ctx, rd.EditAsToml()
+
+	err := rd.EditAsToml()
+	er.Mgr.SaveDrafts(cmdCtx.ID())
+	return ctx, err
 }
 
 func (er *CmdEditResp) Execute(
@@ -212,7 +215,7 @@ func rawWfEdit(
 		return cmdCtx.Ctx, err
 	}
 
-	draft.Body = string(rawData)
-
+	draft.SetBody(string(rawData))
+	br.Mgr.SaveDrafts(cmdCtx.ID())
 	return cmdCtx.Ctx, nil
 }
@@ -0,0 +1,248 @@
+package syscmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/shubm-quodes/repl-reqs/cmd"
+	"github.com/shubm-quodes/repl-reqs/network"
+)
+
+const (
+	// Root cmd
+	CmdHistoryName = "$history"
+
+	// Sub cmds
+	CmdHistoryShowName   = "show"
+	CmdHistoryReplayName = "replay"
+	CmdHistoryDraftName  = "draft"
+	CmdHistoryClearName  = "clear"
+
+	defaultHistoryListSize = 20
+)
+
+type CmdHistory struct {
+	*BaseReqCmd
+}
+
+type CmdHistoryShow struct {
+	*BaseReqCmd
+}
+
+type CmdHistoryReplay struct {
+	*ReqCmd
+}
+
+type CmdHistoryDraft struct {
+	*BaseReqCmd
+}
+
+type CmdHistoryClear struct {
+	*BaseReqCmd
+}
+
+// '$history [count]' lists the most recent requests, newest first
+func (ch *CmdHistory) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	count := defaultHistoryListSize
+	if tokens := cmdCtx.ExpandedTokens; len(tokens) > 0 {
+		n, err := strconv.Atoi(tokens[0])
+		if err != nil || n < 1 {
+			return cmdCtx.Ctx, fmt.Errorf("invalid count '%s'", tokens[0])
+		}
+		count = n
+	}
+
+	history, err := getHistory(ch.BaseReqCmd)
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	hdlr := ch.GetCmdHandler()
+	entries := history.Entries()
+	if len(entries) == 0 {
+		hdlr.Out(cmdCtx, "\nNo requests made yet 📭\n\n")
+		return cmdCtx.Ctx, nil
+	}
+
+	shown := entries[:min(count, len(entries))]
+	hdlr.OutF(
+		cmdCtx,
+		"\n📜 History - (%d of %d, most recent first)\n\n",
+		len(shown),
+		len(entries),
+	)
+	for i, entry := range shown {
+		hdlr.OutF(cmdCtx, "%3d. %s\n", i+1, formatHistoryEntry(entry))
+	}
+	hdlr.Out(cmdCtx, "\n")
+	return cmdCtx.Ctx, nil
+}
+
+// '$history show <n>' prints the request & the response that was received
+func (cs *CmdHistoryShow) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	entry, err := getHistoryEntry(cs.BaseReqCmd, cmdCtx.ExpandedTokens)
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	cs.GetCmdHandler().Out(cmdCtx, formatHistoryDetails(entry))
+	return cmdCtx.Ctx, nil
+}
+
+// '$history replay <n>' sends the request again, as is
+func (cr *CmdHistoryReplay) ExecuteAsync(cmdCtx *cmd.CmdCtx) {
+	t := cmdCtx.Task
+	entry, err := getHistoryEntry(cr.BaseReqCmd, cmdCtx.ExpandedTokens)
+	if err != nil {
+		t.Fail(err)
+		return
+	}
+
+	req, err := entry.Request()
+	if err != nil {
+		t.Fail(err)
+		return
+	}
+
+	t.UpdateMessage(fmt.Sprintf("replaying [%s] %s", entry.Method, entry.Url))
+	cr.MakeRequest(req, cmdCtx, t)
+}
+
+func (cr *CmdHistoryReplay) AllowInModeWithoutArgs() bool {
+	return false
+}
+
+// '$history draft <n>' turns the request into a new draft, which becomes the current one
+func (cd *CmdHistoryDraft) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	entry, err := getHistoryEntry(cd.BaseReqCmd, cmdCtx.ExpandedTokens)
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	draft, err := entry.Draft()
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	cd.Mgr.AddDraftRequest(cmdCtx.ID(), draft)
+	setDraftPrompt(cd.GetCmdHandler(), draft)
+	cd.GetCmdHandler().OutF(cmdCtx, "now drafting %s\n", describeDraft(draft))
+	return cmdCtx.Ctx, nil
+}
+
+func (cc *CmdHistoryClear) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	history, err := getHistory(cc.BaseReqCmd)
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	history.Clear()
+	cc.GetCmdHandler().Out(cmdCtx, "history cleared 🧹\n")
+	return cmdCtx.Ctx, nil
+}
+
+func getHistory(brc *BaseReqCmd) (*network.History, error) {
+	history := brc.Mgr.History()
+	if history == nil {
+		return nil, errors.New("request history isn't available")
+	}
+	return history, nil
+}
+
+func getHistoryEntry(brc *BaseReqCmd, tokens []string) (*network.HistoryEntry, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("please specify the entry's number, see %s", CmdHistoryName)
+	}
+
+	n, err := strconv.Atoi(strings.TrimPrefix(tokens[0], "#"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid history entry '%s'", tokens[0])
+	}
+
+	history, err := getHistory(brc)
+	if err != nil {
+		return nil, err
+	}
+	return history.Get(n)
+}
+
+// 15:04:05  [GET] https://example.com/users → 200 · 120.00ms
+func formatHistoryEntry(entry *network.HistoryEntry) string {
+	return fmt.Sprintf(
+		"%s  [%s] %s → %s · %s",
+		color.HiBlackString(formatHistoryTimestamp(entry.Timestamp)),
+		entry.Method,
+		entry.Url,
+		formatHistoryStatus(entry),
+		cmd.FormatDuration(entry.Duration),
+	)
+}
+
+func formatHistoryTimestamp(ts time.Time) string {
+	local, now := ts.Local(), time.Now()
+	if local.YearDay() == now.YearDay() && local.Year() == now.Year() {
+		return local.Format("15:04:05")
+	}
+	return local.Format("Jan 02 15:04")
+}
+
+func formatHistoryStatus(entry *network.HistoryEntry) string {
+	switch {
+	case entry.StatusCode == 0:
+		return color.HiRedString("failed")
+	case entry.StatusCode >= 400:
+		return color.HiRedString("%d", entry.StatusCode)
+	case entry.StatusCode >= 300:
+		return color.HiYellowString("%d", entry.StatusCode)
+	default:
+		return color.HiGreenString("%d", entry.StatusCode)
+	}
+}
+
+func formatHistoryDetails(entry *network.HistoryEntry) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\n%s\n\n", formatHistoryEntry(entry))
+	fmt.Fprintf(&sb, "%s %s\n", color.HiWhiteString(entry.Method), entry.Url)
+	writeHistoryHeaders(&sb, entry.Headers)
+	if entry.Body != "" {
+		fmt.Fprintf(&sb, "\n%s\n", entry.Body)
+	}
+	if entry.BodyTruncated {
+		sb.WriteString(color.HiBlackString("... (truncated)\n"))
+	}
+
+	if entry.Err != "" {
+		fmt.Fprintf(&sb, "\n%s\n", color.HiRedString(entry.Err))
+	}
+
+	if resp := entry.Response(); resp != nil {
+		fmt.Fprintf(&sb, "\n%s\n", color.HiWhiteString(resp.Status))
+		writeHistoryHeaders(&sb, resp.Header)
+		if body := getFormattedResp(resp); body != "" {
+			fmt.Fprintf(&sb, "\n%s\n", body)
+		}
+		if entry.ResponseTruncated {
+			sb.WriteString(color.HiBlackString("... (truncated)\n"))
+		}
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+func writeHistoryHeaders(sb *strings.Builder, headers map[string][]string) {
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(sb, "%s: %s\n", color.CyanString(key), strings.Join(headers[key], ", "))
+	}
+}
@@ -2,10 +2,13 @@ package syscmd
 
 import (
 	"context"
+	"errors"
 	"sort"
+	"time"
 
 	"github.com/shubm-quodes/repl-reqs/cmd"
 	"github.com/shubm-quodes/repl-reqs/config"
+	"github.com/shubm-quodes/repl-reqs/network"
 	"github.com/shubm-quodes/repl-reqs/util"
 )
 
@@ -18,6 +21,8 @@ const (
 	CmdLsTasksName     = "tasks"
 	CmdLsSequencesName = "sequences"
 	CmdLsEnvName       = "envs"
+	CmdLsCookiesName   = "cookies"
+	CmdLsRedirectsName = "redirects"
 )
 
 type CmdLs struct {
@@ -40,6 +45,14 @@ type CmdLsEnvs struct {
 	*cmd.BaseNonModeCmd
 }
 
+type CmdLsCookies struct {
+	*BaseReqCmd
+}
+
+type CmdLsRedirects struct {
+	*BaseReqCmd
+}
+
 func (ls *CmdLsVars) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
 	envMgr := config.GetEnvManager()
 	envVars := envMgr.GetActiveEnvVars()
@@ -100,3 +113,87 @@ func (lsEnvs *CmdLsEnvs) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
 
 	return cmdCtx.Ctx, nil
 }
+
+func (ls *CmdLsCookies) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	hdlr := ls.GetCmdHandler()
+	jar := ls.Mgr.CookieJar()
+	if jar == nil {
+		return cmdCtx.Ctx, errors.New("cookie jar unavailable")
+	}
+
+	env := config.GetEnvManager().GetActiveEnvName()
+	if !jar.Enabled() {
+		hdlr.Out(cmdCtx, "\ncookie jar is turned off, use '$set cookie-jar on' to enable it\n")
+	}
+
+	cookies := jar.List()
+	if len(cookies) == 0 {
+		hdlr.OutF(cmdCtx, "\nNo cookies in the currently active env: '%s' 🍪\n\n", env)
+		return cmdCtx.Ctx, nil
+	}
+
+	hdlr.OutF(cmdCtx, "\n🍪 Cookies - (In currently active environment: '%s')\n\n", env)
+	for i, c := range cookies {
+		expires := "session"
+		if !c.Expires.IsZero() {
+			expires = c.Expires.Local().Format(time.RFC1123)
+		}
+		hdlr.OutF(
+			cmdCtx,
+			"%d. %s: %s (%s%s, expires: %s)\n",
+			i+1,
+			c.Name,
+			util.GetTruncatedStr(c.Value),
+			c.Domain,
+			c.Path,
+			expires,
+		)
+	}
+
+	return cmdCtx.Ctx, nil
+}
+
+// '$ls redirects [#task]' walks the redirect chain of the last (or the task's) request
+func (ls *CmdLsRedirects) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	var taskId string
+	if tokens := cmdCtx.ExpandedTokens; len(tokens) > 0 {
+		taskId = tokens[0]
+	}
+
+	trackerReq, err := ls.resolveTrackerRequest(cmdCtx, taskId)
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	if trackerReq.Status == network.StatusProcessing {
+		return cmdCtx.Ctx, errors.New("request is still in progress")
+	}
+
+	hdlr := ls.GetCmdHandler()
+	if len(trackerReq.Redirects) == 0 {
+		hdlr.Out(cmdCtx, "\nNo redirects were followed ↪️\n\n")
+		return cmdCtx.Ctx, nil
+	}
+
+	hdlr.Out(cmdCtx, "\n↪️  Redirect chain\n\n")
+	for i, hop := range trackerReq.Redirects {
+		hdlr.OutF(cmdCtx, "%d. [%d] %s %s\n", i+1, hop.StatusCode, hop.Method, hop.URL)
+		hdlr.OutF(cmdCtx, "   Location: %s\n", hop.Location)
+		for _, cookie := range hop.SetCookies {
+			hdlr.OutF(cmdCtx, "   Set-Cookie: %s\n", util.GetTruncatedStr(cookie))
+		}
+	}
+
+	if resp := trackerReq.FullResponse; resp != nil && resp.Request != nil {
+		hdlr.OutF(
+			cmdCtx,
+			"%d. [%d] %s %s (final)\n",
+			len(trackerReq.Redirects)+1,
+			resp.StatusCode,
+			resp.Request.Method,
+			resp.Request.URL.String(),
+		)
+	}
+	hdlr.Out(cmdCtx, "\n")
+	return cmdCtx.Ctx, nil
+}
@@ -4,7 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/shubm-quodes/repl-reqs/cmd"
 	"github.com/shubm-quodes/repl-reqs/network"
@@ -12,95 +15,231 @@ import (
 
 const (
 	CmdPollName = "$poll"
+
+	pollIntervalFlag    = "--interval"
+	pollTimeoutFlag     = "--timeout"
+	pollMaxAttemptsFlag = "--max-attempts"
+	pollBackoffFlag     = "--backoff"
+	pollTrackAllFlag    = "--track-all"
 )
 
 type CmdPoll struct {
 	*BaseReqCmd
 }
 
+type pollOpts struct {
+	interval    time.Duration
+	timeout     time.Duration
+	maxAttempts int
+	backoff     network.Backoff
+	trackAll    bool // Track every attempt, not just the last one
+}
+
 /*
-- Single argument is treated as the poll condition
-- In case of multiple args, the last arg would be treated as the condition, while all others preceding it are considered as commands
+  - '$poll <condition expr>' polls the request currently being drafted
+  - '$poll <cmd...> <condition expr>' polls the request command, everything from the first
+    condition ($status, $header or $body) onwards is treated as the condition expression
+  - '--interval <d>', '--timeout <d>', '--max-attempts <n>', '--backoff linear|exp' &
+    '--track-all' can be specified anywhere
 */
 func (cp *CmdPoll) ExecuteAsync(cmdCtx *cmd.CmdCtx) {
-	rd, condition, err := cp.determinePollReqDraft(
-		cmdCtx.ExpandedTokens,
-		cmdCtx,
-	)
-
 	t := cmdCtx.Task
+	tokens, opts, err := extractPollOpts(cmdCtx.ExpandedTokens)
 	if err != nil {
 		t.Fail(err)
 		return
 	}
 
-	req, err := rd.Finalize()
+	req, expr, err := cp.determinePollReq(tokens, cmdCtx)
 	if err != nil {
 		t.Fail(err)
 		return
 	}
 
-	response, err := cp.Poll(req, condition)
+	var attempts int
+	onAttempt := func(a network.PollAttempt) {
+		attempts = a.Number
+		t.UpdateMessage(formatPollAttempt(a))
+	}
+
+	response, err := cp.Poll(cmdCtx.ID(), req.WithContext(cmdCtx.Ctx), expr, opts, onAttempt)
 	if err != nil {
 		t.Fail(err)
 	} else {
 		t.AppendOutput(getFormattedResp(response) + "\n" + response.Status)
-		t.CompleteWithMessage("Polling complete", response)
+		t.CompleteWithMessage(
+			fmt.Sprintf("Polling complete after %d attempt(s)", attempts),
+			response,
+		)
 	}
 }
 
-func (cp *CmdPoll) Poll(req *http.Request, condition string) (*http.Response, error) {
-	c, err := network.NewCondition(condition)
+func (cp *CmdPoll) TakesExprs() bool {
+	return true
+}
+
+// Attempts are sent through the request manager, the last attempt (or every attempt, as per the
+// options) is tracked in the context just like any other request.
+func (cp *CmdPoll) Poll(
+	context string,
+	req *http.Request,
+	expr string,
+	opts pollOpts,
+	onAttempt func(network.PollAttempt),
+) (*http.Response, error) {
+	c, err := network.NewConditionExpr(expr)
 	if err != nil {
 		return nil, err
 	}
 
+	var last *network.TrackerRequest
+	defer func() {
+		if last != nil {
+			cp.Mgr.Track(context, last)
+		}
+	}()
+
 	p := network.NewPoller(req, c)
+	p.SetSender(func(attempt *http.Request) (*http.Response, error) {
+		resp, trackerReq, err := cp.Mgr.Send(attempt)
+		if opts.trackAll {
+			cp.Mgr.Track(context, trackerReq)
+		} else if resp != nil {
+			last = trackerReq
+		}
+		return resp, err
+	})
+	p.SetInterval(opts.interval)
+	p.SetMaxAttempts(opts.maxAttempts)
+	p.SetTimeout(opts.timeout)
+	p.SetBackoff(opts.backoff)
+	p.OnAttempt(onAttempt)
 	return p.Poll()
 }
 
-func (cp *CmdPoll) determinePollReqDraft(
+// Extracts the polling flags, remaining tokens are the command and the condition expression
+func extractPollOpts(tokens []string) ([]string, pollOpts, error) {
+	opts := pollOpts{
+		interval:    network.DefaultPollInterval,
+		maxAttempts: network.DefaultPollMaxAttempts,
+	}
+	tokens, opts.trackAll = extractFlag(tokens, pollTrackAllFlag)
+
+	durations := map[string]*time.Duration{
+		pollIntervalFlag: &opts.interval,
+		pollTimeoutFlag:  &opts.timeout,
+	}
+
+	for flag, target := range durations {
+		rest, val, ok, err := extractFlagVal(tokens, flag)
+		if err != nil {
+			return nil, opts, err
+		} else if !ok {
+			continue
+		}
+
+		// Attempts must be spaced out, a timeout of zero means there's none
+		d, err := time.ParseDuration(val)
+		if err != nil || d < 0 || (d == 0 && flag == pollIntervalFlag) {
+			return nil, opts, fmt.Errorf("invalid %s '%s', for instance '2s' or '1m'", flag, val)
+		}
+		tokens, *target = rest, d
+	}
+
+	tokens, val, ok, err := extractFlagVal(tokens, pollMaxAttemptsFlag)
+	if err != nil {
+		return nil, opts, err
+	} else if ok {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 {
+			return nil, opts, fmt.Errorf("invalid %s '%s'", pollMaxAttemptsFlag, val)
+		}
+		opts.maxAttempts = n
+	} else if opts.timeout > 0 {
+		opts.maxAttempts = 0 // The deadline alone bounds polling
+	}
+
+	tokens, val, ok, err = extractFlagVal(tokens, pollBackoffFlag)
+	if err != nil {
+		return nil, opts, err
+	} else if ok {
+		opts.backoff = network.Backoff(val)
+		if val == "" || !network.IsValidBackoff(opts.backoff) {
+			return nil, opts, fmt.Errorf(
+				"invalid backoff '%s', expected %s|%s",
+				val,
+				network.BackoffLinear,
+				network.BackoffExp,
+			)
+		}
+	}
+	return tokens, opts, nil
+}
+
+// 🔄 attempt 3/100 · 200 · job.progress=75 · next in 1s
+func formatPollAttempt(a network.PollAttempt) string {
+	attempt := fmt.Sprintf("🔄 attempt %d", a.Number)
+	if a.MaxAttempts > 0 {
+		attempt += fmt.Sprintf("/%d", a.MaxAttempts)
+	}
+
+	parts := []string{attempt}
+	if a.Err != nil {
+		parts = append(parts, a.Err.Error())
+	} else {
+		parts = append(parts, strconv.Itoa(a.StatusCode))
+		parts = append(parts, a.Values...)
+	}
+
+	if a.NextIn > 0 {
+		parts = append(parts, "next in "+cmd.FormatDuration(a.NextIn))
+	}
+	return strings.Join(parts, " · ")
+}
+
+func (cp *CmdPoll) determinePollReq(
 	tokens []string,
 	cmdCtx *cmd.CmdCtx,
-) (*network.RequestDraft, string, error) {
-	if len(tokens) == 0 {
+) (*http.Request, string, error) {
+	idx := slices.IndexFunc(tokens, isConditionToken)
+	if idx == -1 {
 		return nil, "", errors.New(
-			"neither condition nor command with a condition was specified ",
+			"please specify a poll condition, for instance '$status=200'",
 		)
 	}
 
-	if len(tokens) == 1 {
+	target, expr := tokens[:idx], strings.Join(tokens[idx:], " ")
+	if len(target) == 0 {
 		draft := cp.Mgr.PeakRequestDraft(cmdCtx.ID())
 		if draft == nil {
 			return nil, "", errors.New("no request drafts to poll")
-		} else {
-			return draft, tokens[0], nil
 		}
-	}
-
-	// Multiple args were specified.
-	cmd := tokens[:len(tokens)-1]
-	hdlr := cp.GetCmdHandler()
-	c, unusedTokens := hdlr.ResolveCommandFromRoot(cmd)
 
-	if len(unusedTokens) == 0 {
-		return nil, "", errors.New("please specify a 'poll condition' :/")
+		req, err := draft.Finalize()
+		return req, expr, err
 	}
 
-	if len(unusedTokens) > 1 { // Hmm... possibly wrong cmd
+	c, params := cp.GetCmdHandler().ResolveCommandFromRoot(target)
+	rCmd, ok := c.(*ReqCmd)
+	if !ok {
 		return nil, "", fmt.Errorf(
-			"invalid command '%s' :/",
-			strings.Join(cmd, " "),
+			"'%s' is not a request command",
+			strings.Join(target, " "),
 		)
 	}
 
-	// We're here, this means exactly one unused token is left, we'll assume it to be the 'condition'
-	if rCmd, ok := c.(*ReqCmd); !ok {
-		return nil, "", fmt.Errorf(
-			"'%s' is not a request command",
-			strings.Join(cmd, " "),
-		)
-	} else {
-		return rCmd.RequestDraft, unusedTokens[0], nil
+	cmdParams, err := rCmd.getCmdParams(params)
+	if err != nil {
+		return nil, "", err
 	}
+
+	req, err := rCmd.buildRequest(cmdParams)
+	return req, expr, err
+}
+
+func isConditionToken(token string) bool {
+	return strings.HasPrefix(token, "$status") ||
+		strings.HasPrefix(token, "$header") ||
+		strings.HasPrefix(token, "$body") ||
+		strings.HasPrefix(token, "$length")
 }
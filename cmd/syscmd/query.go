@@ -0,0 +1,90 @@
+package syscmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/shubm-quodes/repl-reqs/cmd"
+	"github.com/shubm-quodes/repl-reqs/network"
+	"github.com/shubm-quodes/repl-reqs/util"
+)
+
+const (
+	CmdQueryRespName = "$query"
+
+	// '$set var <name> --query <expr>' stores the result of a query on the last response
+	queryVarFlag = "--query"
+)
+
+type CmdQueryResp struct {
+	*BaseReqCmd
+}
+
+// '$query [#task] <expr>' runs a jq-like query (see util.QueryVal) against the last response,
+// or the one of a task
+func (cq *CmdQueryResp) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	results, err := cq.queryResponse(cmdCtx, cmdCtx.ExpandedTokens)
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	if len(results) == 0 {
+		cq.GetCmdHandler().Out(cmdCtx, "no results\n")
+		return cmdCtx.Ctx, nil
+	}
+
+	var sb strings.Builder
+	for _, result := range results {
+		formatted, err := util.ToIndentedPayload(result)
+		if err != nil {
+			return cmdCtx.Ctx, err
+		}
+		sb.WriteString(highlightText(string(formatted), lexers.Get("json")))
+		sb.WriteString("\n")
+	}
+
+	cq.GetCmdHandler().Out(cmdCtx, sb.String())
+	return cmdCtx.Ctx, nil
+}
+
+func (cq *CmdQueryResp) TakesExprs() bool {
+	return true
+}
+
+// The tokens are the (optional) task id followed by the expression, which is split by spaces.
+func (brc *BaseReqCmd) queryResponse(cmdCtx *cmd.CmdCtx, tokens []string) ([]any, error) {
+	var taskId string
+	if len(tokens) > 0 && strings.HasPrefix(tokens[0], "#") {
+		taskId, tokens = tokens[0], tokens[1:]
+	}
+
+	expr := strings.Join(tokens, " ")
+	if expr == "" {
+		return nil, errors.New("please specify a query, for instance '.items[0].id'")
+	}
+
+	trackerReq, err := brc.resolveTrackerRequest(cmdCtx, taskId)
+	if err != nil {
+		return nil, err
+	}
+	if trackerReq.Status == network.StatusProcessing {
+		return nil, errors.New("request is still in progress")
+	}
+	if trackerReq.ResponseBody == nil {
+		return nil, errors.New("there's no response to query")
+	}
+
+	raw, err := util.ReadAndResetIoCloser(&trackerReq.ResponseBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	data, err := network.DecodeBody(trackerReq.ResponseHeaders.Get("Content-Type"), raw)
+	if err != nil {
+		return nil, err
+	}
+	return util.QueryVal(data, expr)
+}
@@ -3,9 +3,16 @@ package syscmd
 import "github.com/shubm-quodes/repl-reqs/cmd"
 
 func RegisterCmds(reg *cmd.CmdRegistry) {
+	transport := &CmdTransport{cmd.NewBaseCmd(CmdTransportName, "")}
+	transport.AddSubCmd(&CmdTransportTimeout{NewBaseReqCmd(CmdTransportTimeoutName)}).
+		AddSubCmd(&CmdTransportProxy{NewBaseReqCmd(CmdTransportProxyName)}).
+		AddSubCmd(&CmdTransportCACert{NewBaseReqCmd(CmdTransportCACertName)}).
+		AddSubCmd(&CmdTransportClientCert{NewBaseReqCmd(CmdTransportClientCertName)}).
+		AddSubCmd(&CmdTransportInsecure{NewBaseReqCmd(CmdTransportInsecureName)})
+
 	s := &CmdSet{cmd.NewBaseCmd(CmdSetName, "")}
-	s.AddSubCmd(&CmdEnv{cmd.NewBaseCmd(CmdEnvName, "")}).
-		AddSubCmd(&CmdVar{cmd.NewBaseCmd(CmdVarName, "")}).
+	s.AddSubCmd(&CmdEnv{NewInModeBaseReqCmd(CmdEnvName)}).
+		AddSubCmd(&CmdVar{NewInModeBaseReqCmd(CmdVarName)}).
 		AddSubCmd(&CmdURL{NewBaseReqCmd(CmdURLName)}).
 		AddSubCmd(&CmdHeader{NewInModeBaseReqCmd(CmdHeaderName)}).
 		AddSubCmd(&CmdCookie{NewInModeBaseReqCmd(CmdCookieName)}).
@@ -14,7 +21,12 @@ func RegisterCmds(reg *cmd.CmdRegistry) {
 		AddSubCmd(&CmdBody{NewBaseReqCmd(CmdBodyName)}).
 		AddSubCmd(&CmdPrompt{cmd.NewBaseCmd(CmdPromptName, "")}).
 		AddSubCmd(&CmdMascot{cmd.NewBaseCmd(CmdMascotName, "")}).
-		AddSubCmd(&CmdQuery{NewInModeBaseReqCmd(CmdQueryName)})
+		AddSubCmd(&CmdQuery{NewInModeBaseReqCmd(CmdQueryName)}).
+		AddSubCmd(&CmdCookieJar{NewBaseReqCmd(CmdCookieJarName)}).
+		AddSubCmd(&CmdForm{NewInModeBaseReqCmd(CmdFormName)}).
+		AddSubCmd(&CmdFile{NewInModeBaseReqCmd(CmdFileName)}).
+		AddSubCmd(&CmdRedirects{NewBaseReqCmd(CmdRedirectsName)}).
+		AddSubCmd(transport)
 
 	n := &draftReqCmd{NewBaseReqCmd(CmdDraftReqName)}
 
@@ -24,12 +36,15 @@ func RegisterCmds(reg *cmd.CmdRegistry) {
 	ls.AddSubCmd(&CmdLsVars{cmd.NewBaseNonModeCmd(CmdLsVarsName, "")}).
 		AddSubCmd(&CmdLsTasks{cmd.NewBaseNonModeCmd(CmdLsTasksName, "")}).
 		AddSubCmd(&CmdLsSequences{cmd.NewBaseNonModeCmd(CmdLsSequencesName, "")}).
-		AddSubCmd(&CmdLsEnvs{cmd.NewBaseNonModeCmd(CmdLsEnvName, "")})
+		AddSubCmd(&CmdLsEnvs{cmd.NewBaseNonModeCmd(CmdLsEnvName, "")}).
+		AddSubCmd(&CmdLsCookies{NewBaseReqCmd(CmdLsCookiesName)}).
+		AddSubCmd(&CmdLsRedirects{NewBaseReqCmd(CmdLsRedirectsName)})
 
 	save := &CmdSave{&BaseReqCmd{BaseCmd: cmd.NewBaseCmd(CmdSaveName, "")}}
 	dlt := &CmdDelete{BaseCmd: cmd.NewBaseCmd(CmdDeleteName, "")}
 	dlt.AddSubCmd(&CmdDeleteVar{BaseCmd: cmd.NewBaseCmd(CmdDeleteVarName, "")}).
-		AddSubCmd(&CmdDeleteSeq{BaseCmd: cmd.NewBaseCmd(CmdDeleteSeqName, "")})
+		AddSubCmd(&CmdDeleteSeq{BaseCmd: cmd.NewBaseCmd(CmdDeleteSeqName, "")}).
+		AddSubCmd(&CmdDeleteCookie{NewBaseReqCmd(CmdDeleteCookieName)})
 
 	edit := &CmdEdit{&BaseReqCmd{BaseCmd: cmd.NewBaseCmd(CmdEditName, "")}}
 	edit.AddSubCmd(&CmdEditReq{&BaseReqCmd{BaseCmd: cmd.NewBaseCmd(CmdEditReqName, "")}}).
@@ -51,5 +66,40 @@ func RegisterCmds(reg *cmd.CmdRegistry) {
 	exp := &CmdExpand{cmd.NewBaseNonModeCmd(CmdExpandName, "")}
 	exp.AddSubCmd(&CmdExpandVar{cmd.NewBaseNonModeCmd(CmdExpandVarName, "")})
 
-	reg.RegisterCmd(s, n, send, ls, save, dlt, edit, p, cp, peak, exp)
+	cancel := &CmdCancel{cmd.NewBaseNonModeCmd(CmdCancelName, "")}
+
+	timing := &CmdTiming{NewBaseReqCmd(CmdTimingName)}
+
+	download := &CmdDownload{NewBaseReqCmd(CmdDownloadName)}
+
+	draft := &CmdDraft{cmd.NewBaseCmd(CmdDraftName, "")}
+	draft.AddSubCmd(&CmdDraftLs{NewBaseReqCmd(CmdDraftLsName)}).
+		AddSubCmd(&CmdDraftUse{NewBaseReqCmd(CmdDraftUseName)}).
+		AddSubCmd(&CmdDraftClone{NewBaseReqCmd(CmdDraftCloneName)}).
+		AddSubCmd(&CmdDraftDiscard{NewBaseReqCmd(CmdDraftDiscardName)}).
+		AddSubCmd(&CmdDraftLabel{NewBaseReqCmd(CmdDraftLabelName)}).
+		AddSubCmd(&CmdDraftRestore{NewBaseReqCmd(CmdDraftRestoreName)})
+
+	history := &CmdHistory{NewBaseReqCmd(CmdHistoryName)}
+	history.AddSubCmd(&CmdHistoryShow{NewBaseReqCmd(CmdHistoryShowName)}).
+		AddSubCmd(&CmdHistoryReplay{NewReqCmd(CmdHistoryReplayName, nil)}).
+		AddSubCmd(&CmdHistoryDraft{NewBaseReqCmd(CmdHistoryDraftName)}).
+		AddSubCmd(&CmdHistoryClear{NewBaseReqCmd(CmdHistoryClearName)})
+
+	diff := &CmdDiff{NewBaseReqCmd(CmdDiffName)}
+
+	query := &CmdQueryResp{NewBaseReqCmd(CmdQueryRespName)}
+
+	snapshot := &CmdSnapshot{cmd.NewBaseCmd(CmdSnapshotName, "")}
+	snapshot.AddSubCmd(&CmdSnapshotSave{NewBaseReqCmd(CmdSnapshotSaveName)}).
+		AddSubCmd(&CmdSnapshotCheck{NewBaseReqCmd(CmdSnapshotCheckName)}).
+		AddSubCmd(&CmdSnapshotLs{NewBaseReqCmd(CmdSnapshotLsName)}).
+		AddSubCmd(&CmdSnapshotDelete{NewBaseReqCmd(CmdSnapshotDeleteName)})
+
+	assert := &CmdAssert{NewBaseReqCmd(CmdAssertName)}
+
+	reg.RegisterCmd(
+		s, n, send, ls, save, dlt, edit, p, cp, peak, exp,
+		cancel, timing, download, draft, history, diff, query, snapshot, assert,
+	)
 }
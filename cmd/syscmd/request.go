@@ -23,6 +23,7 @@ import (
 	"github.com/alecthomas/chroma"
 	"github.com/alecthomas/chroma/lexers"
 	"github.com/alecthomas/chroma/styles"
+	"github.com/fatih/color"
 )
 
 const (
@@ -31,6 +32,12 @@ const (
 
 var RegexUrlParam = regexp.MustCompile(`:([a-zA-Z0-9]+)`)
 
+var (
+	draftStore *network.DraftStore
+	reqHistory *network.History
+	cookieJar  *network.CookieJar
+)
+
 type ReqMgrAware interface {
 	SetReqMgr(mgr *network.RequestManager)
 }
@@ -48,6 +55,7 @@ type ReqCmd struct {
 	*BaseReqCmd
 	*ReqPropsSchema
 	*network.RequestDraft
+	Retry *config.RetryCfg // Overrides the global & environment retry settings
 }
 
 type ReqCmdCfgBody struct {
@@ -63,6 +71,7 @@ type ReqCmdCfg struct {
 	UrlParams    map[string]Validation `json:"urlParams"`
 	Body         ReqCmdCfgBody         `json:"body"`
 	RequestDraft *network.RequestDraft `json:"requestDraft"`
+	Retry        *config.RetryCfg      `json:"retry,omitempty"`
 }
 
 type CmdParams struct {
@@ -99,6 +108,7 @@ func NewReqCfgFromCmd(rc *ReqCmd) *ReqCmdCfg {
 		QueryParams:  rc.ReqPropsSchema.QueryParams,
 		UrlParams:    rc.ReqPropsSchema.UrlParams,
 		RequestDraft: rc.RequestDraft,
+		Retry:        rc.Retry,
 	}
 
 	schemaType := "text/html"
@@ -111,6 +121,13 @@ func NewReqCfgFromCmd(rc *ReqCmd) *ReqCmdCfg {
 		}
 	}
 
+	switch rc.RequestDraft.GetBodyKind() {
+	case network.BodyKindForm:
+		schemaType = "form"
+	case network.BodyKindMultipart:
+		schemaType = "multipart"
+	}
+
 	cfg.Body.Type = schemaType
 	cfg.Body.Schema = rc.ReqPropsSchema.Body
 	return cfg
@@ -132,19 +149,52 @@ func (rc *ReqCmd) SetHeaders(headers KeyValPair) *ReqCmd {
 }
 
 func InitNetCmds(rawCfg config.RawCfg, hdlr *cmd.ReplCmdHandler) error {
+	cfg := config.GetAppCfg()
+	client, err := network.NewHttpClient(cfg.GetTransportCfg())
+	if err != nil {
+		return fmt.Errorf("failed to configure http transport: %w", err)
+	}
+
 	mgr := network.NewRequestManager(
 		network.NewRequestTracker(),
-		nil,
+		client,
 		strMapToHttpHeader(rawCfg.Commons.Headers),
 	)
+	cookieJar = network.NewCookieJar(cfg.ResolvePath(network.CookieJarFileName))
+	mgr.SetCookieJar(cookieJar)
 	if err := processRawReqCfg(rawCfg, hdlr, mgr); err != nil {
 		return err
 	}
 	injectReqMgr(hdlr, mgr)
 	registerListeners(hdlr, mgr)
+
+	reqHistory = network.NewHistory(
+		cfg.ResolvePath(network.HistoryFileName),
+		cfg.GetHistorySize(),
+		cfg.KeepHistoryBodies(),
+	)
+	mgr.SetHistory(reqHistory)
+
+	draftStore = network.NewDraftStore(cfg.ResolvePath(network.DraftsFileName))
+	hdlr.OnBootstrap(func() {
+		mgr.RestoreDrafts(hdlr.GetDefaultCtxId(), draftStore)
+	})
 	return nil
 }
 
+// Flushes pending drafts, history & cookies to disk
+func Shutdown() {
+	if draftStore != nil {
+		draftStore.Shutdown()
+	}
+	if reqHistory != nil {
+		reqHistory.Shutdown()
+	}
+	if cookieJar != nil {
+		cookieJar.Shutdown()
+	}
+}
+
 func registerListeners(hdlr *cmd.ReplCmdHandler, mgr *network.RequestManager) {
 	hdlr.RegisterListener(0x10, ActionCycleReq, func() bool {
 		ctxId := hdlr.GetDefaultCtx().Value(cmd.CmdCtxIdKey)
@@ -195,6 +245,7 @@ func (r *ReqCmd) UnmarshalJSON(data []byte) error {
 		RawUrlParams   map[string]json.RawMessage `json:"urlParams"`
 		RawBody        map[string]json.RawMessage `json:"body"`
 		RequestDraft   *network.RequestDraft      `json:"requestDraft"`
+		Retry          *config.RetryCfg           `json:"retry"`
 	}
 
 	if err := json.Unmarshal(data, &rawProps); err != nil {
@@ -204,6 +255,7 @@ func (r *ReqCmd) UnmarshalJSON(data []byte) error {
 	headers := util.CopyMap(rawProps.Headers, rawProps.RequestDraft.Headers)
 	r.Name_ = rawProps.Cmd // Temporary assignment, gets overriden upon registration
 	r.RequestDraft = rawProps.RequestDraft
+	r.Retry = rawProps.Retry
 	r.SetUrl(rawProps.Url).
 		SetMethod(rawProps.HttpMethod).
 		SetHeaders(headers)
@@ -451,6 +503,10 @@ func (rc *ReqCmd) processBodyParams(parsedParams map[string]string, cmdParams *C
 }
 
 func (rc *ReqCmd) parseExistingBody() (map[string]any, error) {
+	if rc.RequestDraft.GetBodyKind() != network.BodyKindRaw {
+		return rc.RequestDraft.FormFields(config.GetEnvManager().GetActiveEnvVars())
+	}
+
 	if rc.RequestDraft.Body == "" {
 		return make(map[string]any), nil
 	}
@@ -551,33 +607,69 @@ func (rc *ReqCmd) buildRequest(cmdParams *CmdParams) (*http.Request, error) {
 	}
 	u.RawQuery = q.Encode()
 
-	var reqBody io.Reader
-	if len(cmdParams.Body) > 0 {
-		bodyBytes, err := json.Marshal(cmdParams.Body)
-		if err != nil {
-			return nil, fmt.Errorf("error marshalling body: %w", err)
-		}
-		reqBody = bytes.NewReader(bodyBytes)
+	reqBody, contentType, err := rc.encodeBody(cmdParams.Body)
+	if err != nil {
+		return nil, err
 	}
 
 	req, err := http.NewRequest(string(draft.Method), u.String(), reqBody)
-	for _, c := range r.Cookies() {
-		req.AddCookie(c)
-	}
-
-	req.Header = r.Header.Clone()
-
 	if err != nil {
 		return nil, fmt.Errorf("error creating HTTP request: %w", err)
 	}
 
+	req.Header = r.Header.Clone() // Includes the draft's cookies
+	rc.reuseDraftBody(req, r, reqBody == nil)
+
 	for key, value := range draft.Headers {
 		req.Header.Set(key, value)
 	}
 
+	if contentType != "" {
+		network.SetContentType(req.Header, contentType)
+	}
+
 	return req, nil
 }
 
+// Falls back to the finalized draft's body (for instance a streamed body file) when no body params
+// were supplied, otherwise the draft's body is discarded.
+func (rc *ReqCmd) reuseDraftBody(req, finalized *http.Request, reuse bool) {
+	if finalized.Body == nil {
+		return
+	}
+
+	if !reuse {
+		finalized.Body.Close()
+		return
+	}
+
+	req.Body = finalized.Body
+	req.GetBody = finalized.GetBody
+	req.ContentLength = finalized.ContentLength
+}
+
+// Encodes body params as per the draft's body kind, content type is only returned for form bodies
+func (rc *ReqCmd) encodeBody(body map[string]any) (io.Reader, string, error) {
+	if len(body) == 0 {
+		return nil, "", nil
+	}
+
+	kind := rc.RequestDraft.GetBodyKind()
+	if kind != network.BodyKindRaw {
+		encoded, contentType, err := network.EncodeFormBody(kind, body)
+		if err != nil {
+			return nil, "", fmt.Errorf("error encoding %s body: %w", kind, err)
+		}
+		return encoded, contentType, nil
+	}
+
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("error marshalling body: %w", err)
+	}
+	return bytes.NewReader(bodyBytes), "", nil
+}
+
 func (rc *ReqCmd) extractTokenKey(token []rune) string {
 	s := string(token)
 
@@ -710,8 +802,12 @@ func (rc *ReqCmd) SuggestCmdParams(search []rune) (suggestions [][]rune) {
 }
 
 func (rc *ReqCmd) ExecuteAsync(cmdCtx *cmd.CmdCtx) {
-	tokens := cmdCtx.ExpandedTokens
 	task := cmdCtx.Task
+	tokens, dlOpts, err := extractDownloadOpts(cmdCtx.ExpandedTokens)
+	if err != nil {
+		task.Fail(err)
+		return
+	}
 
 	cmdParams, err := rc.getCmdParams(tokens)
 	if err != nil {
@@ -725,10 +821,37 @@ func (rc *ReqCmd) ExecuteAsync(cmdCtx *cmd.CmdCtx) {
 		return
 	}
 
-	rc.MakeRequest(req, cmdCtx, task)
+	cmdCtx, err = rc.withRetryPolicy(cmdCtx)
+	if err != nil {
+		task.Fail(err)
+		return
+	}
+
+	if dlOpts != nil {
+		rc.Download(req, cmdCtx, *dlOpts)
+	} else {
+		rc.MakeRequest(req, cmdCtx, task)
+	}
+}
+
+// Attaches the command's retry policy to the context the request is sent with
+func (rc *ReqCmd) withRetryPolicy(cmdCtx *cmd.CmdCtx) (*cmd.CmdCtx, error) {
+	if rc.Retry == nil {
+		return cmdCtx, nil
+	}
+
+	policy, err := network.NewRetryPolicy(config.GetAppCfg().GetRetryCfg().Merge(*rc.Retry))
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry settings for '%s': %w", rc.GetFullyQualifiedName(), err)
+	}
+
+	retryCtx := *cmdCtx
+	retryCtx.Ctx = network.WithRetryPolicy(cmdCtx.Ctx, policy)
+	return &retryCtx, nil
 }
 
 func (rc *ReqCmd) MakeRequest(req *http.Request, cmdCtx *cmd.CmdCtx, task cmd.TaskUpdater) {
+	req = req.WithContext(cmdCtx.Ctx)
 	_, netUpdate, err := rc.Mgr.MakeRequestWithContext(cmdCtx.ID(), req)
 
 	if err != nil {
@@ -761,9 +884,42 @@ func (rc *ReqCmd) readAndUnmarshalResponse(resp *http.Response, target map[strin
 
 func (rc *ReqCmd) handleSuccessfulResponse(task cmd.TaskUpdater, result network.Update) {
 	task.AppendOutput(getFormattedResp(result.Resp()) + "\n" + result.Resp().Status)
+	if trackerReq, err := rc.Mgr.GetTrackerRequest(result.ReqId()); err == nil {
+		if attempts := trackerReq.Attempts; len(attempts) > 1 ||
+			len(attempts) == 1 && attempts[0].NotRetried != "" {
+			task.AppendOutput(formatAttempts(attempts))
+		}
+		if config.GetAppCfg().ShowTiming() {
+			task.AppendOutput(formatTimingSummary(trackerReq.Timing))
+		}
+	}
 	task.Complete(result.Resp())
 }
 
+// 🔁 3 attempts: 503 (120ms) → 503 (95ms) → 200 (80ms), followed by why the last one wasn't retried
+// (if it could've been), as in '🔁 1 attempt: 503 (120ms), not retried: body not replayable'
+func formatAttempts(attempts []network.Attempt) string {
+	steps := make([]string, 0, len(attempts))
+	for _, a := range attempts {
+		outcome := "error"
+		if a.StatusCode != 0 {
+			outcome = strconv.Itoa(a.StatusCode)
+		}
+		steps = append(steps, fmt.Sprintf("%s (%s)", outcome, cmd.FormatDuration(a.Duration)))
+	}
+
+	noun := "attempts"
+	if len(attempts) == 1 {
+		noun = "attempt"
+	}
+
+	summary := fmt.Sprintf("🔁 %d %s: %s", len(attempts), noun, strings.Join(steps, " → "))
+	if last := attempts[len(attempts)-1]; last.NotRetried != "" {
+		summary += ", not retried: " + last.NotRetried
+	}
+	return color.HiBlackString("%s", summary)
+}
+
 func rgbToAnsiEscapeCode(r, g, b uint8) string {
 	ansiColor := 16 + (r/51)*36 + (g/51)*6 + (b / 51)
 	return fmt.Sprintf("\033[38;5;%dm", ansiColor)
@@ -862,7 +1018,11 @@ func (rc *ReqCmd) PopulateSchemasFromDraft() error {
 	if err != nil {
 		return err
 	}
-	rc.ReqPropsSchema.Body = populateSchemaFromJSONString(rc.GetBody())
+	if rc.GetBodyKind() == network.BodyKindRaw {
+		rc.ReqPropsSchema.Body = populateSchemaFromJSONString(rc.GetBody())
+	} else {
+		rc.ReqPropsSchema.Body = populateSchemaFromForm(rc.RequestDraft)
+	}
 	return nil
 }
 
@@ -890,19 +1050,34 @@ func (rc *ReqCmd) populateQuerySchemaFromDraft() {
 	schema.QueryParams = make(ValidationSchema)
 
 	handlerFunc := func(key, value string) {
-		if _, err := strconv.Atoi(value); err == nil {
-			schema.QueryParams[key] = &IntValidations{Type: "int"}
-		} else if _, err := strconv.ParseFloat(value, 64); err == nil {
-			schema.QueryParams[key] = &FloatValidations{Type: "float"}
-		} else {
-			schema.QueryParams[key] = &StrValidations{Type: "string"}
-		}
+		schema.QueryParams[key] = inferStrTypeSchema(value)
 	}
 
 	rc.IterateQueryParams(handlerFunc)
 	schema.Body = populateSchemaFromJSONString(rc.RequestDraft.GetBody())
 }
 
+func inferStrTypeSchema(value string) Validation {
+	if _, err := strconv.Atoi(value); err == nil {
+		return &IntValidations{Type: "int"}
+	} else if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return &FloatValidations{Type: "float"}
+	}
+	return &StrValidations{Type: "string"}
+}
+
+func populateSchemaFromForm(draft *network.RequestDraft) ValidationSchema {
+	schema := make(ValidationSchema, len(draft.Form)+len(draft.Files))
+	for key, value := range draft.Form {
+		schema[key] = inferStrTypeSchema(value)
+	}
+
+	for field := range draft.Files {
+		schema[field] = &FileValidations{Type: "file"}
+	}
+	return schema
+}
+
 func inferTypeSchema(value any) Validation {
 	if value == nil {
 		return &StrValidations{Type: "string"}
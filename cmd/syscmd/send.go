@@ -25,8 +25,16 @@ func (s *CmdSend) ExecuteAsync(cmdCtx *cmd.CmdCtx) {
 		return
 	}
 
+	_, dlOpts, err := extractDownloadOpts(cmdCtx.ExpandedTokens)
+	if err != nil {
+		t.Fail(err)
+		return
+	}
+
 	if req, err := draft.Finalize(); err != nil {
 		t.Fail(err)
+	} else if dlOpts != nil {
+		s.Download(req, cmdCtx, *dlOpts)
 	} else {
 		s.MakeRequest(req, cmdCtx, t)
 	}
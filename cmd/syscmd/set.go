@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 
@@ -35,14 +37,17 @@ const (
 	CmdBodyName         = "body"
 	CmdPromptName       = "prompt"
 	CmdMascotName       = "mascot"
+	CmdCookieJarName    = "cookie-jar"
+	CmdFormName         = "form"
+	CmdFileName         = "file"
 )
 
 type CmdEnv struct {
-	*cmd.BaseCmd
+	*InModeBaseReqCmd
 }
 
 type CmdVar struct {
-	*cmd.BaseCmd
+	*InModeBaseReqCmd
 }
 
 type CmdMultiVar struct {
@@ -67,6 +72,18 @@ type CmdCookie struct {
 	*InModeBaseReqCmd
 }
 
+type CmdForm struct {
+	*InModeBaseReqCmd
+}
+
+type CmdFile struct {
+	*InModeBaseReqCmd
+}
+
+type CmdCookieJar struct {
+	*BaseReqCmd
+}
+
 type CmdMethod struct {
 	*BaseReqCmd
 }
@@ -110,6 +127,7 @@ func (ch *CmdHeader) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
 	}
 
 	reqDraft.SetHeader(key, val)
+	ch.Mgr.SaveDrafts(cmdCtx.ID())
 	return ctx, nil
 }
 
@@ -130,9 +148,90 @@ func (ch *CmdCookie) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
 	}
 
 	reqDraft.SetCookie(key, val)
+	ch.Mgr.SaveDrafts(cmdCtx.ID())
+	return ctx, nil
+}
+
+func (cf *CmdForm) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	ctx, tokens := cmdCtx.Ctx, cmdCtx.RawTokens
+	if len(tokens) < 2 {
+		return ctx, errors.New("please provide form field [key] [val]")
+	}
+
+	key, val := tokens[0], strings.Join(tokens[1:], " ")
+	reqDraft := cf.Mgr.PeakRequestDraft(cmdCtx.ID())
+
+	if reqDraft == nil {
+		return ctx, fmt.Errorf(
+			"no drafts, start drafting requests using %s command",
+			CmdDraftReqName,
+		)
+	}
+
+	reqDraft.SetFormField(key, val)
+	cf.Mgr.SaveDrafts(cmdCtx.ID())
 	return ctx, nil
 }
 
+func (cf *CmdFile) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	ctx, tokens := cmdCtx.Ctx, cmdCtx.RawTokens
+	if len(tokens) < 2 {
+		return ctx, errors.New("please provide [field] and file [path]")
+	}
+
+	field, path := tokens[0], strings.TrimPrefix(strings.Join(tokens[1:], " "), "@")
+	reqDraft := cf.Mgr.PeakRequestDraft(cmdCtx.ID())
+
+	if reqDraft == nil {
+		return ctx, fmt.Errorf(
+			"no drafts, start drafting requests using %s command",
+			CmdDraftReqName,
+		)
+	}
+
+	// Paths containing variables can only be verified once expanded
+	if !strings.Contains(path, "{{") {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return ctx, err
+		}
+
+		if _, err := os.Stat(absPath); err != nil {
+			return ctx, fmt.Errorf("file not accessible: %w", err)
+		}
+		path = absPath
+	}
+
+	reqDraft.SetFile(field, path)
+	cf.Mgr.SaveDrafts(cmdCtx.ID())
+	return ctx, nil
+}
+
+func (cj *CmdCookieJar) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	ctx, tokens := cmdCtx.Ctx, cmdCtx.ExpandedTokens
+	if len(tokens) == 0 {
+		return ctx, errors.New("please specify on|off")
+	}
+
+	enable, err := parseOnOff(tokens[0])
+	if err != nil {
+		return ctx, err
+	}
+
+	jar := cj.Mgr.CookieJar()
+	if jar == nil {
+		return ctx, errors.New("cookie jar unavailable")
+	}
+
+	jar.SetEnabled(enable)
+	cj.GetCmdHandler().OutF(cmdCtx, "cookie jar turned %s 🍪\n", tokens[0])
+	return ctx, nil
+}
+
+func (cj *CmdCookieJar) GetSuggestions(tokens [][]rune) ([][]rune, int) {
+	return suggestOnOff(tokens)
+}
+
 func (u *CmdURL) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
 	if u.Mgr == nil {
 		return cmdCtx.Ctx, errors.New("failed to set url, manager unavailable")
@@ -146,6 +245,7 @@ func (u *CmdURL) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
 
 	if draft != nil {
 		draft.SetUrl(url)
+		u.Mgr.SaveDrafts(cmdCtx.ID())
 		setReqDraftPrompt(u.GetCmdHandler(), draft)
 		return cmdCtx.Ctx, nil
 	}
@@ -169,6 +269,7 @@ func (cmh *CmdQuery) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
 		)
 	}
 	draft.SetQueryParam(key, val)
+	rMgr.SaveDrafts(cmdCtx.ID())
 	return ctx, nil
 }
 
@@ -180,15 +281,52 @@ func (cb *CmdBody) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
 		return cmdCtx.Ctx, errors.New("please specify body :/")
 	}
 
-	body := strings.Join(cmdCtx.ExpandedTokens[0:], " ")
 	draft := cb.Mgr.PeakRequestDraft(cmdCtx.ID())
+	if draft == nil {
+		return cmdCtx.Ctx, errors.New("no request to draft")
+	}
 
-	if draft != nil {
-		draft.SetBody(body)
+	if path, expand, ok := parseBodyFileTokens(cmdCtx.ExpandedTokens); ok {
+		resolved, err := resolveBodyFilePath(path)
+		if err != nil {
+			return cmdCtx.Ctx, err
+		}
+
+		draft.SetBodyFile(resolved, expand)
+		cb.Mgr.SaveDrafts(cmdCtx.ID())
 		return cmdCtx.Ctx, nil
 	}
 
-	return cmdCtx.Ctx, errors.New("no request to draft")
+	draft.SetBody(strings.Join(cmdCtx.ExpandedTokens[0:], " "))
+	cb.Mgr.SaveDrafts(cmdCtx.ID())
+	return cmdCtx.Ctx, nil
+}
+
+// Parses '[--expand] @path' tokens
+func parseBodyFileTokens(tokens []string) (path string, expand bool, ok bool) {
+	if len(tokens) > 0 && tokens[0] == "--expand" {
+		expand, tokens = true, tokens[1:]
+	}
+
+	if len(tokens) != 1 || !strings.HasPrefix(tokens[0], "@") || len(tokens[0]) == 1 {
+		return "", false, false
+	}
+	return tokens[0][1:], expand, true
+}
+
+// Paths relative to the working directory are made absolute, otherwise they're expected to be
+// relative to the config directory.
+func resolveBodyFilePath(path string) (string, error) {
+	if absPath, err := filepath.Abs(path); err == nil && !filepath.IsAbs(path) {
+		if _, err := os.Stat(absPath); err == nil {
+			return absPath, nil
+		}
+	}
+
+	if _, err := os.Stat(config.GetAppCfg().ResolvePath(path)); err != nil {
+		return "", fmt.Errorf("body file not accessible: %w", err)
+	}
+	return path, nil
 }
 
 func (cmh *CmdMultiHeaders) setHeader(key, val string) error {
@@ -216,6 +354,7 @@ func (chv *CmdMethod) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
 	}
 
 	draft.SetMethod(network.HTTPMethod(httpVerb))
+	chv.Mgr.SaveDrafts(cmdCtx.ID())
 	setReqDraftPrompt(chv.GetCmdHandler(), draft)
 	return ctx, nil
 }
@@ -233,6 +372,13 @@ func (ec *CmdEnv) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
 	hdlr := ec.GetCmdHandler()
 	hdlr.UpdatePromptEnv()
 	hdlr.OutF(cmdCtx, `Environment now set to "%s"`+"\n", env)
+
+	// Transport settings can be environment specific
+	if ec.Mgr != nil {
+		if err := ec.Mgr.ApplyTransportCfg(c.GetAppCfg().GetTransportCfg()); err != nil {
+			return ctx, fmt.Errorf("failed to apply transport settings for '%s': %w", env, err)
+		}
+	}
 	return ctx, nil
 }
 
@@ -247,12 +393,35 @@ func (vc *CmdVar) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
 	mgr := c.GetEnvManager()
 	name, val := tokens[0], strings.Join(tokens[1:], " ")
 
+	if tokens[1] == queryVarFlag {
+		results, err := vc.queryResponse(cmdCtx, tokens[2:])
+		if err != nil {
+			return ctx, fmt.Errorf("failed to set variable: %w", err)
+		}
+		if len(results) == 0 {
+			return ctx, errors.New("failed to set variable: the query yielded no results")
+		}
+		val = util.StringifyQueryResults(results)
+	}
+
 	mgr.SetVar(name, val)
 	vc.GetCmdHandler().OutF(cmdCtx, "'%s' now set to '%s'\n", name, val)
 
 	return ctx, nil
 }
 
+// Only the args of '--query' are an expression, as in '$set var ids --query .items | join'
+func (vc *CmdVar) TakesExprs() bool {
+	return false
+}
+
+func (vc *CmdVar) ExprStart(args []string) int {
+	if len(args) > 2 && args[1] == queryVarFlag {
+		return 2
+	}
+	return -1
+}
+
 func (vc *CmdVar) GetModeName() string {
 	return "$set var 📦"
 }
@@ -0,0 +1,233 @@
+package syscmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/shubm-quodes/repl-reqs/cmd"
+	"github.com/shubm-quodes/repl-reqs/config"
+	"github.com/shubm-quodes/repl-reqs/network"
+	"github.com/shubm-quodes/repl-reqs/util"
+)
+
+const (
+	CmdSnapshotName = "$snapshot"
+
+	// Sub cmds
+	CmdSnapshotSaveName   = "save"
+	CmdSnapshotCheckName  = "check"
+	CmdSnapshotLsName     = "ls"
+	CmdSnapshotDeleteName = "delete"
+
+	snapshotHeadersFlag = "--headers"
+	snapshotIgnoreFlag  = "--ignore"
+)
+
+type CmdSnapshot struct {
+	*cmd.BaseCmd
+}
+
+type CmdSnapshotSave struct {
+	*BaseReqCmd
+}
+
+type CmdSnapshotCheck struct {
+	*BaseReqCmd
+}
+
+type CmdSnapshotLs struct {
+	*BaseReqCmd
+}
+
+type CmdSnapshotDelete struct {
+	*BaseReqCmd
+}
+
+// '$snapshot save <name> [#task] [--headers h1,h2] [--ignore p1,p2]' stores the last response (or
+// the task's), the ignore paths are saved along with it
+func (cs *CmdSnapshotSave) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	tokens, headers, err := extractListFlag(cmdCtx.ExpandedTokens, snapshotHeadersFlag)
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+	tokens, ignore, err := extractListFlag(tokens, snapshotIgnoreFlag)
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	name, taskId, err := parseSnapshotArgs(tokens)
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	trackerReq, err := cs.resolveTrackerRequest(cmdCtx, taskId)
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+	if trackerReq.FullResponse == nil {
+		return cmdCtx.Ctx, errors.New("there's no response to snapshot")
+	}
+
+	snap, err := network.NewSnapshot(name, trackerReq.FullResponse, headers)
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+	snap.Ignore = ignore
+
+	if err := getSnapshotStore().Save(snap); err != nil {
+		return cmdCtx.Ctx, fmt.Errorf("failed to save snapshot '%s': %w", name, err)
+	}
+
+	cs.GetCmdHandler().OutF(cmdCtx, "📸 snapshot '%s' saved (%d)\n", name, snap.StatusCode)
+	return cmdCtx.Ctx, nil
+}
+
+/*
+'$snapshot check <name> [#task] [--ignore p1,p2]' compares the last response (or the task's, or
+the preceding step's within a sequence) against the snapshot. Drifting responses are reported as an
+error, so that sequences fail on them.
+*/
+func (cs *CmdSnapshotCheck) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	tokens, ignore, err := extractListFlag(cmdCtx.ExpandedTokens, snapshotIgnoreFlag)
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	name, taskId, err := parseSnapshotArgs(tokens)
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	snap, err := getSnapshotStore().Load(name)
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	trackerReq, err := cs.resolveTrackerRequest(cmdCtx, taskId)
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+	if trackerReq.FullResponse == nil {
+		return cmdCtx.Ctx, errors.New("there's no response to check")
+	}
+
+	changes, err := snap.Compare(trackerReq.FullResponse, ignore)
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	if len(changes) > 0 {
+		var sb strings.Builder
+		writeChanges(&sb, changes)
+		return cmdCtx.Ctx, fmt.Errorf(
+			"response drifted from snapshot '%s'\n%s",
+			name,
+			strings.TrimSuffix(sb.String(), "\n"),
+		)
+	}
+
+	cs.GetCmdHandler().OutF(cmdCtx, "✅ response matches snapshot '%s'\n", name)
+	return cmdCtx.Ctx, nil
+}
+
+func (cs *CmdSnapshotCheck) GetSuggestions(tokens [][]rune) ([][]rune, int) {
+	return suggestSnapshots(tokens)
+}
+
+func (cs *CmdSnapshotLs) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	names, err := getSnapshotStore().List()
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	if len(names) == 0 {
+		cs.GetCmdHandler().OutF(
+			cmdCtx,
+			"no snapshots yet, save one using '%s %s <name>'\n",
+			CmdSnapshotName,
+			CmdSnapshotSaveName,
+		)
+		return cmdCtx.Ctx, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n📸 Snapshots\n\n")
+	for i, name := range names {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, color.HiYellowString(name))
+	}
+	cs.GetCmdHandler().Out(cmdCtx, sb.String()+"\n")
+	return cmdCtx.Ctx, nil
+}
+
+func (cs *CmdSnapshotDelete) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	tokens := cmdCtx.ExpandedTokens
+	if len(tokens) != 1 {
+		return cmdCtx.Ctx, errors.New("please specify the snapshot to delete")
+	}
+
+	if err := getSnapshotStore().Delete(tokens[0]); err != nil {
+		return cmdCtx.Ctx, err
+	}
+	cs.GetCmdHandler().OutF(cmdCtx, "snapshot '%s' deleted\n", tokens[0])
+	return cmdCtx.Ctx, nil
+}
+
+func (cs *CmdSnapshotDelete) GetSuggestions(tokens [][]rune) ([][]rune, int) {
+	return suggestSnapshots(tokens)
+}
+
+func getSnapshotStore() *network.SnapshotStore {
+	return network.NewSnapshotStore(config.GetAppCfg().ResolvePath(network.SnapshotsDirName))
+}
+
+// '<name> [#task]'
+func parseSnapshotArgs(tokens []string) (name, taskId string, err error) {
+	for _, token := range tokens {
+		switch {
+		case strings.HasPrefix(token, "#") && taskId == "":
+			taskId = token
+		case name == "":
+			name = token
+		default:
+			return "", "", fmt.Errorf("unexpected argument '%s'", token)
+		}
+	}
+
+	if name == "" {
+		return "", "", errors.New("please specify the snapshot name")
+	}
+	return name, taskId, nil
+}
+
+// Comma separated flag values, as in '--ignore id,meta.updatedAt'
+func extractListFlag(tokens []string, flag string) ([]string, []string, error) {
+	rest, val, ok, err := extractFlagVal(tokens, flag)
+	if err != nil || !ok {
+		return rest, nil, err
+	}
+
+	var list []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return rest, list, nil
+}
+
+func suggestSnapshots(tokens [][]rune) ([][]rune, int) {
+	if len(tokens) > 1 {
+		return nil, 0
+	}
+
+	var search string
+	if len(tokens) == 1 {
+		search = string(tokens[0])
+	}
+
+	names, _ := getSnapshotStore().List()
+	return util.StrArrToRune(util.FilterPrefixedStrsWithOffset(names, search, true)), len(search)
+}
@@ -0,0 +1,112 @@
+package syscmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/shubm-quodes/repl-reqs/cmd"
+	"github.com/shubm-quodes/repl-reqs/config"
+	"github.com/shubm-quodes/repl-reqs/network"
+)
+
+const CmdTimingName = "$timing"
+
+type CmdTiming struct {
+	*BaseReqCmd
+}
+
+// '$timing [#task]' prints the breakdown, '$timing on|off' toggles the summary after each request
+func (ct *CmdTiming) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	var arg string
+	if tokens := cmdCtx.ExpandedTokens; len(tokens) > 0 {
+		arg = tokens[0]
+	}
+
+	if show, err := parseOnOff(arg); err == nil {
+		config.GetAppCfg().SetShowTiming(show)
+		ct.GetCmdHandler().OutF(cmdCtx, "timing summary turned %s\n", arg)
+		return cmdCtx.Ctx, nil
+	}
+
+	trackerReq, err := ct.resolveTrackerRequest(cmdCtx, arg)
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	if trackerReq.Status == network.StatusProcessing {
+		return cmdCtx.Ctx, fmt.Errorf("request is still in progress")
+	}
+
+	if trackerReq.Timing.Total == 0 {
+		return cmdCtx.Ctx, fmt.Errorf("no timing was recorded for the response")
+	}
+
+	ct.GetCmdHandler().Out(cmdCtx, formatTimingBreakdown(trackerReq))
+	return cmdCtx.Ctx, nil
+}
+
+func (ct *CmdTiming) GetSuggestions(tokens [][]rune) ([][]rune, int) {
+	return suggestOnOff(tokens)
+}
+
+func formatTimingBreakdown(trackerReq *network.TrackerRequest) string {
+	t := trackerReq.Timing
+	phases := []struct {
+		name string
+		d    time.Duration
+	}{
+		{"DNS lookup", t.DNS},
+		{"TCP connect", t.Connect},
+		{"TLS handshake", t.TLS},
+		{"Time to first byte", t.TTFB},
+		{"Download", t.Download},
+	}
+
+	var sb strings.Builder
+	if trackerReq.Request != nil && trackerReq.Request.HttpRequest != nil {
+		req := trackerReq.Request.HttpRequest
+		fmt.Fprintf(&sb, "⏱️  %s %s\n", req.Method, req.URL.String())
+	}
+
+	for _, phase := range phases {
+		fmt.Fprintf(
+			&sb,
+			"  %-20s %10s  %s\n",
+			phase.name,
+			cmd.FormatDuration(phase.d),
+			timingBar(phase.d, t.Total),
+		)
+	}
+	fmt.Fprintf(&sb, "  %-20s %10s\n", color.HiWhiteString("Total"), cmd.FormatDuration(t.Total))
+
+	if t.Reused {
+		sb.WriteString(color.HiBlackString("  (connection reused, no dns/connect/tls)\n"))
+	}
+	return sb.String()
+}
+
+func timingBar(d, total time.Duration) string {
+	const width = 30
+	if total <= 0 || d <= 0 {
+		return ""
+	}
+
+	n := int(float64(d) / float64(total) * width)
+	return color.CyanString(strings.Repeat("█", max(n, 1)))
+}
+
+// Compact one liner, printed after each request when enabled
+func formatTimingSummary(t network.RequestTiming) string {
+	return color.HiBlackString(
+		"⏱️  dns %s · connect %s · tls %s · ttfb %s · download %s · total %s",
+		cmd.FormatDuration(t.DNS),
+		cmd.FormatDuration(t.Connect),
+		cmd.FormatDuration(t.TLS),
+		cmd.FormatDuration(t.TTFB),
+		cmd.FormatDuration(t.Download),
+		cmd.FormatDuration(t.Total),
+	)
+}
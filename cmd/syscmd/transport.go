@@ -0,0 +1,212 @@
+package syscmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/shubm-quodes/repl-reqs/cmd"
+	"github.com/shubm-quodes/repl-reqs/config"
+	"github.com/shubm-quodes/repl-reqs/util"
+)
+
+const (
+	// Sub cmd for '$set'
+	CmdTransportName = "transport"
+
+	// Sub cmds for '$set transport'
+	CmdTransportTimeoutName    = "timeout"
+	CmdTransportProxyName      = "proxy"
+	CmdTransportCACertName     = "ca-cert"
+	CmdTransportClientCertName = "client-cert"
+	CmdTransportInsecureName   = "insecure"
+
+	// Sub cmd for '$set'
+	CmdRedirectsName = "redirects"
+)
+
+type CmdTransport struct {
+	*cmd.BaseCmd
+}
+
+type CmdTransportTimeout struct {
+	*BaseReqCmd
+}
+
+type CmdTransportProxy struct {
+	*BaseReqCmd
+}
+
+type CmdTransportCACert struct {
+	*BaseReqCmd
+}
+
+type CmdTransportClientCert struct {
+	*BaseReqCmd
+}
+
+type CmdTransportInsecure struct {
+	*BaseReqCmd
+}
+
+type CmdRedirects struct {
+	*BaseReqCmd
+}
+
+func (ct *CmdTransportTimeout) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	tokens := cmdCtx.ExpandedTokens
+	if len(tokens) == 0 {
+		return cmdCtx.Ctx, fmt.Errorf(
+			"please specify timeout, for instance '30s' or '1m' (%s to unset it)",
+			config.TransportUnset,
+		)
+	}
+
+	return applyTransportOverride(ct.BaseReqCmd, cmdCtx, config.TransportCfg{Timeout: tokens[0]})
+}
+
+func (cp *CmdTransportProxy) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	tokens := cmdCtx.ExpandedTokens
+	if len(tokens) == 0 {
+		return cmdCtx.Ctx, fmt.Errorf("please specify proxy url (%s to unset it)", config.TransportUnset)
+	}
+
+	return applyTransportOverride(cp.BaseReqCmd, cmdCtx, config.TransportCfg{Proxy: tokens[0]})
+}
+
+func (cc *CmdTransportCACert) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	tokens := cmdCtx.ExpandedTokens
+	if len(tokens) == 0 {
+		return cmdCtx.Ctx, fmt.Errorf(
+			"please specify path to the ca bundle (pem) (%s to unset it)",
+			config.TransportUnset,
+		)
+	}
+
+	return applyTransportOverride(cc.BaseReqCmd, cmdCtx, config.TransportCfg{CACert: tokens[0]})
+}
+
+func (cc *CmdTransportClientCert) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	tokens := cmdCtx.ExpandedTokens
+	if len(tokens) == 1 && tokens[0] == config.TransportUnset {
+		return applyTransportOverride(
+			cc.BaseReqCmd,
+			cmdCtx,
+			config.TransportCfg{ClientCert: config.TransportUnset},
+		)
+	}
+	if len(tokens) < 2 {
+		return cmdCtx.Ctx, fmt.Errorf(
+			"please specify client [cert] and [key] paths (%s to unset them)",
+			config.TransportUnset,
+		)
+	}
+
+	return applyTransportOverride(cc.BaseReqCmd, cmdCtx, config.TransportCfg{
+		ClientCert: tokens[0],
+		ClientKey:  tokens[1],
+	})
+}
+
+func (ci *CmdTransportInsecure) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	tokens := cmdCtx.ExpandedTokens
+	if len(tokens) == 0 {
+		return cmdCtx.Ctx, errors.New("please specify on|off")
+	}
+
+	skip, err := parseOnOff(tokens[0])
+	if err != nil {
+		return cmdCtx.Ctx, err
+	}
+
+	return applyTransportOverride(
+		ci.BaseReqCmd,
+		cmdCtx,
+		config.TransportCfg{InsecureSkipVerify: &skip},
+	)
+}
+
+func (ci *CmdTransportInsecure) GetSuggestions(tokens [][]rune) ([][]rune, int) {
+	return suggestOnOff(tokens)
+}
+
+// '$set redirects follow|none|<max>'
+func (cr *CmdRedirects) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	tokens := cmdCtx.ExpandedTokens
+	if len(tokens) == 0 {
+		return cmdCtx.Ctx, fmt.Errorf(
+			"please specify %s|%s|<max>",
+			config.RedirectsFollow,
+			config.RedirectsNone,
+		)
+	}
+
+	override := config.TransportCfg{Redirects: tokens[0]}
+	if _, err := override.GetMaxRedirects(); err != nil {
+		return cmdCtx.Ctx, err
+	}
+	return applyTransportOverride(cr.BaseReqCmd, cmdCtx, override)
+}
+
+func (cr *CmdRedirects) GetSuggestions(tokens [][]rune) ([][]rune, int) {
+	var search string
+	if len(tokens) > 1 {
+		return nil, 0
+	} else if len(tokens) == 1 {
+		search = string(tokens[0])
+	}
+
+	matches := util.FilterPrefixedStrsWithOffset(
+		[]string{config.RedirectsFollow, config.RedirectsNone},
+		search,
+		true,
+	)
+	return util.StrArrToRune(matches), len(search)
+}
+
+// Session overrides are only persisted if the http client could be successfully rebuilt.
+func applyTransportOverride(
+	brc *BaseReqCmd,
+	cmdCtx *cmd.CmdCtx,
+	override config.TransportCfg,
+) (context.Context, error) {
+	if brc.Mgr == nil {
+		return cmdCtx.Ctx, errors.New("failed to update transport, manager unavailable")
+	}
+
+	cfg := config.GetAppCfg()
+	if err := brc.Mgr.ApplyTransportCfg(cfg.TransportCfgWith(override)); err != nil {
+		return cmdCtx.Ctx, fmt.Errorf("failed to update transport: %w", err)
+	}
+
+	cfg.OverrideTransportCfg(override)
+	brc.GetCmdHandler().OutF(
+		cmdCtx,
+		"transport updated for environment '%s'\n",
+		config.GetEnvManager().GetActiveEnvName(),
+	)
+	return cmdCtx.Ctx, nil
+}
+
+func parseOnOff(val string) (bool, error) {
+	switch val {
+	case "on", "true", "yes":
+		return true, nil
+	case "off", "false", "no":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid value '%s', expected on|off", val)
+	}
+}
+
+func suggestOnOff(tokens [][]rune) ([][]rune, int) {
+	var search string
+	if len(tokens) > 1 {
+		return nil, 0
+	} else if len(tokens) == 1 {
+		search = string(tokens[0])
+	}
+
+	matches := util.FilterPrefixedStrsWithOffset([]string{"on", "off"}, search, true)
+	return util.StrArrToRune(matches), len(search)
+}
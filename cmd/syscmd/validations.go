@@ -31,6 +31,12 @@ type StrValidations struct {
 	Regex     *string `json:"regex"`
 }
 
+// Form field to be uploaded as a file, values are paths optionally prefixed with '@'
+type FileValidations struct {
+	Required *bool  `json:"required"`
+	Type     string `json:"type"`
+}
+
 type IterableVld interface {
 	ArrValidation | ObjValidation
 }
@@ -72,6 +78,8 @@ func newValidator(t string, cfg json.RawMessage) (Validation, error) {
 		return &StrValidations{}, nil
 	case "array":
 		return &ArrValidation{}, nil
+	case "file":
+		return &FileValidations{}, nil
 	case "object", "json":
 		return buildObjValidation(cfg)
 	default:
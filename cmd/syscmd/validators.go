@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/shubm-quodes/repl-reqs/network"
 	"github.com/shubm-quodes/repl-reqs/util"
 )
 
@@ -53,6 +55,23 @@ func (sv *StrValidations) validate(value string) (any, error) {
 	return value, nil
 }
 
+func (fv *FileValidations) validate(value string) (any, error) {
+	path := strings.TrimPrefix(value, "@")
+	if path == "" {
+		return nil, errors.New("file path can't be empty")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("\"%v\": file not accessible: %w", path, err)
+	}
+
+	if info.IsDir() {
+		return nil, fmt.Errorf("\"%v\": is a directory", path)
+	}
+	return network.FormFile(path), nil
+}
+
 func (arVld *ArrValidation) validate(value string) (any, error) {
 	str := []byte(fmt.Sprintf(`{"arr": %s}`, value)) // Hehehehuhuhu, am I Evil?
 	var arrWrapper map[string]any
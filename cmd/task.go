@@ -1,12 +1,15 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"sync"
 	"time"
 )
 
 const (
 	TaskStatusInitiated        = "initiated"
+	TaskStatusCancelled        = "cancelled"
 	DefaultTaskIdNonTrackingID = "0x0"
 )
 
@@ -16,6 +19,7 @@ type TaskStatus struct {
 	Message   string
 	Error     error
 	Done      bool
+	Cancelled bool
 	Result    any
 	Output    string
 	CreatedAt time.Time
@@ -44,7 +48,11 @@ type TaskUpdater interface {
 type Task struct {
 	status     TaskStatus
 	updateChan chan<- TaskStatus
+	cancel     context.CancelFunc
 	mu         sync.RWMutex
+	redirect   *redirect // Where the output goes once the task completes, if not the terminal
+	done       chan struct{}
+	doneOnce   sync.Once
 }
 
 func NewTask(id, cmd string, updateChan chan<- TaskStatus) *Task {
@@ -55,6 +63,7 @@ func NewTask(id, cmd string, updateChan chan<- TaskStatus) *Task {
 			CreatedAt: time.Now(),
 		},
 		updateChan: updateChan,
+		done:       make(chan struct{}),
 	}
 
 	return t
@@ -92,6 +101,7 @@ func (t *Task) SetResult(result any) {
 func (t *Task) Fail(err error) {
 	t.mu.Lock()
 	t.status.Error = err
+	t.status.Cancelled = errors.Is(err, context.Canceled)
 	if t.status.Message == "" {
 		t.status.Message = "Task failed"
 	}
@@ -119,6 +129,26 @@ func (t *Task) CompleteWithMessage(msg string, result any) {
 	t.sendUpdate()
 }
 
+func (t *Task) setCancelFunc(cancel context.CancelFunc) {
+	t.mu.Lock()
+	t.cancel = cancel
+	t.mu.Unlock()
+}
+
+// Cancels the task's context, returns false if the task isn't running anymore.
+func (t *Task) Cancel() bool {
+	t.mu.RLock()
+	cancel, finished := t.cancel, t.status.Done || t.status.Error != nil
+	t.mu.RUnlock()
+
+	if cancel == nil || finished {
+		return false
+	}
+
+	cancel()
+	return true
+}
+
 func (t *Task) sendUpdate() {
 	if t.updateChan != nil {
 		t.mu.RLock()
@@ -130,6 +160,17 @@ func (t *Task) sendUpdate() {
 	}
 }
 
+// Called once the handler is done with the task's final update (completion, failure or cancellation)
+func (t *Task) markDone() {
+	t.doneOnce.Do(func() { close(t.done) })
+}
+
+// Blocks until the task's final update has been handled
+func (t *Task) wait() TaskStatus {
+	<-t.done
+	return t.GetStatus()
+}
+
 func (t *Task) GetStatus() TaskStatus {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -12,6 +13,8 @@ const (
 	VarPattern    = `{{(.*?)}}`
 	defaultPrompt = "repl-reqs"
 	defaultMascot = "😼"
+
+	DefaultHistorySize = 200
 )
 
 var appCfg *AppCfg
@@ -25,6 +28,12 @@ type RawCfg struct {
 		vars    map[string]string
 	} `json:"commons"`
 	RawRequests []json.RawMessage `json:"requests"`
+	Transport   RawTransportCfg   `json:"transport"`
+	Retry       RawRetryCfg       `json:"retry"`
+	ShowTiming  bool              `json:"showTiming"`
+	HistorySize int               `json:"historySize"`
+	// Request bodies may hold credentials, they're only written to the history file if enabled
+	HistoryBodies bool `json:"historyBodies"`
 }
 
 // TODO: check and un-export fields
@@ -40,8 +49,11 @@ type AppCfg struct {
 	vimMode         bool
 	enableDebugging bool
 	truncatePrompt  bool
+	showTiming      bool
 	maxPromptChars  int32
 	RawCfg          RawCfg
+
+	transportOverrides map[Environment]TransportCfg
 }
 
 type ReqCmdCfg struct {
@@ -88,6 +100,14 @@ func (ac *AppCfg) DirPath() string {
 	return ac.dirPath
 }
 
+// Resolves relative paths against the config directory, absolute paths are returned as is
+func (ac *AppCfg) ResolvePath(p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(getConfDirPath(ac.dirPath), p)
+}
+
 func (ac *AppCfg) GetDefaultEditor() string {
 	return ac.defaultEditor
 }
@@ -112,6 +132,28 @@ func (ac *AppCfg) MaxPromptChars() int32 {
 	return ac.maxPromptChars
 }
 
+// Whether a timing breakdown should be printed after every completed request
+func (ac *AppCfg) ShowTiming() bool {
+	return ac.showTiming
+}
+
+func (ac *AppCfg) SetShowTiming(show bool) {
+	ac.showTiming = show
+}
+
+// Max number of requests kept in the request history
+func (ac *AppCfg) GetHistorySize() int {
+	if ac.RawCfg.HistorySize > 0 {
+		return ac.RawCfg.HistorySize
+	}
+	return DefaultHistorySize
+}
+
+// Whether request bodies are written to the request history file
+func (ac *AppCfg) KeepHistoryBodies() bool {
+	return ac.RawCfg.HistoryBodies
+}
+
 func (ac *AppCfg) UpdateDefaultPrompt(newPrompt string) error {
 	if strings.Trim(newPrompt, " ") == "" {
 		return errors.New("prompt cannot be empty")
@@ -6,7 +6,6 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/shubm-quodes/repl-reqs/log"
 	"github.com/shubm-quodes/repl-reqs/util"
@@ -15,21 +14,17 @@ import (
 const (
 	EnvDefaultGlobal = "Global"
 	envFileName      = "env.json"
-	saveDebounce     = 500 * time.Millisecond
 )
 
 type Environment string
 
 type envManager struct {
-	variables    map[Environment]map[string]string
-	mu           sync.RWMutex
-	activeEnv    Environment
-	filePath     string
-	saveChan     chan struct{}
-	saveTimer    *time.Timer
-	wg           sync.WaitGroup
-	shutdownChan chan struct{}
-	shutdownOnce sync.Once
+	variables map[Environment]map[string]string
+	mu        sync.RWMutex
+	activeEnv Environment
+	savedEnv  Environment // Active env as per the env file, differs while it's overridden
+	filePath  string
+	saver     *util.DebouncedSaver
 }
 
 type envData struct {
@@ -41,18 +36,14 @@ var manager *envManager
 
 func init() {
 	manager = &envManager{
-		variables:    make(map[Environment]map[string]string),
-		activeEnv:    EnvDefaultGlobal,
-		filePath:     filepath.Join(GetDefConfDirPath(), envFileName),
-		saveChan:     make(chan struct{}, 1),
-		shutdownChan: make(chan struct{}),
+		variables: make(map[Environment]map[string]string),
+		activeEnv: EnvDefaultGlobal,
+		savedEnv:  EnvDefaultGlobal,
+		filePath:  filepath.Join(GetDefConfDirPath(), envFileName),
 	}
 
 	manager.load()
-
-	// Start background saver
-	manager.wg.Add(1)
-	go manager.backgroundSaver()
+	manager.saver = util.NewDebouncedSaver("env_manager", util.SaveDebounce, manager.save)
 }
 
 func GetEnvManager() *envManager {
@@ -87,6 +78,7 @@ func (m *envManager) load() {
 
 	if envData.ActiveEnv != "" {
 		m.activeEnv = Environment(envData.ActiveEnv)
+		m.savedEnv = m.activeEnv
 	}
 }
 
@@ -104,7 +96,7 @@ func (m *envManager) save() error {
 
 	data := envData{
 		Variables: varsMap,
-		ActiveEnv: string(m.activeEnv),
+		ActiveEnv: string(m.savedEnv),
 	}
 	m.mu.RUnlock()
 
@@ -113,56 +105,16 @@ func (m *envManager) save() error {
 		return err
 	}
 
-	// Write to temp file first, then rename for atomic operation
-	tempFile := m.filePath + ".tmp"
-	if err := os.WriteFile(tempFile, jsonData, 0644); err != nil {
-		return err
-	}
-
-	return os.Rename(tempFile, m.filePath)
+	return util.WriteFileAtomic(m.filePath, jsonData, 0644)
 }
 
 func (m *envManager) triggerSave() {
-	select {
-	case m.saveChan <- struct{}{}:
-	default:
-		// Channel already has a pending save signal
-	}
-}
-
-// run in a goroutine and handle debounced saves
-func (m *envManager) backgroundSaver() {
-	defer m.wg.Done()
-
-	for {
-		select {
-		case <-m.saveChan:
-			if m.saveTimer != nil {
-				m.saveTimer.Stop()
-			}
-			m.saveTimer = time.AfterFunc(saveDebounce, func() {
-				if err := m.save(); err != nil {
-					log.Debug("env_manager: background saver attempt failed %w", err)
-				}
-			})
-
-		case <-m.shutdownChan:
-			if m.saveTimer != nil {
-				m.saveTimer.Stop()
-			}
-			// Perform final save
-			m.save()
-			return
-		}
-	}
+	m.saver.Trigger()
 }
 
 // gracefully stops the background saver and ensures final save
 func (m *envManager) Shutdown() {
-	m.shutdownOnce.Do(func() {
-		close(m.shutdownChan)
-		m.wg.Wait()
-	})
+	m.saver.Shutdown()
 }
 
 func (m *envManager) SetVar(key, value string) {
@@ -190,16 +142,28 @@ func (m *envManager) GetVar(key string) (string, bool) {
 
 func (m *envManager) SetActiveEnv(env string) {
 	m.mu.Lock()
-	cleanEnv := sanitizeEnvName(env)
-	m.activeEnv = Environment(cleanEnv)
-	if _, exists := m.variables[m.activeEnv]; !exists {
-		m.variables[m.activeEnv] = make(map[string]string)
-	}
+	m.setActiveEnv(env)
+	m.savedEnv = m.activeEnv
 	m.mu.Unlock()
 
 	m.triggerSave()
 }
 
+// Makes env the active one for this run only, the env file keeps the previously active env. Meant
+// for headless runs, which mustn't leave the shell in another env (even when interrupted).
+func (m *envManager) OverrideActiveEnv(env string) {
+	m.mu.Lock()
+	m.setActiveEnv(env)
+	m.mu.Unlock()
+}
+
+func (m *envManager) setActiveEnv(env string) {
+	m.activeEnv = Environment(sanitizeEnvName(env))
+	if _, exists := m.variables[m.activeEnv]; !exists {
+		m.variables[m.activeEnv] = make(map[string]string)
+	}
+}
+
 func sanitizeEnvName(s string) string {
 	// Remove control characters (ASCII 0-31 and 127)
 	result := make([]rune, 0, len(s))
@@ -2,6 +2,9 @@ package config
 
 import (
 	"flag"
+	"fmt"
+	"os"
+	"strings"
 
 	"github.com/shubm-quodes/repl-reqs/log"
 )
@@ -12,6 +15,32 @@ type FlagVal struct {
 	ShowVersion     bool
 	enableVimMode   bool
 	configPath      string
+	Cmds            cmdList // '-e', one-shot cmds run without starting the shell
+	ScriptPath      string  // '-f', file to read cmds from ('-' for stdin)
+	Play            *PlayArgs
+}
+
+const PlaySubCmd = "play"
+
+// 'repl-reqs play <sequence> [--env <env>] [--data <file>] [--report <file>]' plays a sequence
+// without a shell
+type PlayArgs struct {
+	Sequence   string
+	Env        string
+	DataPath   string // Plays the sequence once per row of a '.csv' or '.json' file
+	ReportPath string // JUnit XML for '.xml' files, JSON otherwise
+}
+
+// Collects every occurrence of a repeatable flag
+type cmdList []string
+
+func (c *cmdList) String() string {
+	return strings.Join(*c, "; ")
+}
+
+func (c *cmdList) Set(val string) error {
+	*c = append(*c, val)
+	return nil
 }
 
 // Processes the provided flags.
@@ -19,6 +48,11 @@ func (f *FlagVal) Process() {
 	log.SetDebug(f.enableDebugging)
 }
 
+// Cmds are run non-interactively (without a shell) when passed using '-e', '-f' or 'play'
+func (f *FlagVal) IsHeadless() bool {
+	return len(f.Cmds) > 0 || f.ScriptPath != "" || f.Play != nil
+}
+
 // Initialize flags alongside their default values
 func InitializeFlags() (fv *FlagVal) {
 	fv = &FlagVal{}
@@ -31,6 +65,61 @@ func InitializeFlags() (fv *FlagVal) {
 		GetDefConfDirPath(),
 		"Path for custom configuration",
 	)
+	flag.Var(&fv.Cmds, "e", "Run a command and exit, can be repeated")
+	flag.StringVar(
+		&fv.ScriptPath,
+		"f",
+		"",
+		"Run the commands in a script file (one per line) and exit, '-' reads them from stdin",
+	)
 	flag.Parse()
+
+	if flag.Arg(0) == PlaySubCmd {
+		fv.Play = parsePlayArgs(flag.Args()[1:])
+	}
 	return
 }
+
+func parsePlayArgs(args []string) *PlayArgs {
+	pa := &PlayArgs{}
+	fs := flag.NewFlagSet(PlaySubCmd, flag.ExitOnError)
+	fs.StringVar(&pa.Env, "env", "", "Environment to play the sequence in")
+	fs.StringVar(
+		&pa.DataPath,
+		"data",
+		"",
+		"Play the sequence once per row of a .csv or .json file, its columns become variables",
+	)
+	fs.StringVar(
+		&pa.ReportPath,
+		"report",
+		"",
+		"Write a report of the run, JUnit XML for '.xml' files and JSON otherwise",
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(
+			fs.Output(),
+			"Usage: repl-reqs [flags] play <sequence> [--env <env>] [--data <file>] [--report <file>]",
+		)
+		fs.PrintDefaults()
+	}
+
+	// The sequence name may come before or after the flags
+	var positional []string
+	for {
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if len(positional) != 1 {
+		fmt.Fprintln(fs.Output(), "please specify the sequence to play")
+		fs.Usage()
+		os.Exit(2)
+	}
+	pa.Sequence = positional[0]
+	return pa
+}
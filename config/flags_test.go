@@ -0,0 +1,64 @@
+package config
+
+import "testing"
+
+func TestParsePlayArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want PlayArgs
+	}{
+		{
+			name: "sequence only",
+			args: []string{"login"},
+			want: PlayArgs{Sequence: "login"},
+		},
+		{
+			name: "flags after the sequence",
+			args: []string{"login", "--env", "staging", "--report", "junit.xml"},
+			want: PlayArgs{Sequence: "login", Env: "staging", ReportPath: "junit.xml"},
+		},
+		{
+			name: "flags around the sequence",
+			args: []string{"--data", "users.csv", "login", "-report=report.json"},
+			want: PlayArgs{Sequence: "login", DataPath: "users.csv", ReportPath: "report.json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parsePlayArgs(tt.args); *got != tt.want {
+				t.Errorf("parsePlayArgs(%q) = %+v, want %+v", tt.args, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsHeadless(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags FlagVal
+		want  bool
+	}{
+		{"shell", FlagVal{}, false},
+		{"cmds", FlagVal{Cmds: cmdList{"$env"}}, true},
+		{"script", FlagVal{ScriptPath: "-"}, true},
+		{"play", FlagVal{Play: &PlayArgs{Sequence: "login"}}, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.flags.IsHeadless(); got != tt.want {
+			t.Errorf("%s: IsHeadless() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCmdListCollectsRepeatedFlags(t *testing.T) {
+	var cmds cmdList
+	cmds.Set("$env")
+	cmds.Set("get users")
+
+	if len(cmds) != 2 || cmds.String() != "$env; get users" {
+		t.Errorf("unexpected cmds %q", cmds)
+	}
+}
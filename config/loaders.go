@@ -30,6 +30,7 @@ func (c *AppCfg) loadCfg() {
 		os.Exit(1)
 	}
 	c.prompt, c.mascot = defaultPrompt, defaultMascot
+	c.showTiming = c.RawCfg.ShowTiming
 	if strings.Trim(c.RawCfg.Prompt, " ") != "" {
 		c.prompt = c.RawCfg.Prompt
 	}
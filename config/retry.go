@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Retry settings for requests, zero values fall back to the defaults of the request manager.
+type RetryCfg struct {
+	MaxAttempts   int      `json:"maxAttempts,omitempty"`
+	StatusCodes   []int    `json:"statusCodes,omitempty"`
+	InitialDelay  string   `json:"initialDelay,omitempty"`
+	MaxDelay      string   `json:"maxDelay,omitempty"`
+	Jitter        *float64 `json:"jitter,omitempty"`        // Fraction of the delay to randomize, 0 to 1
+	NonIdempotent *bool    `json:"nonIdempotent,omitempty"` // Retry POST & PATCH requests as well
+}
+
+// Global retry settings along with per environment overrides
+type RawRetryCfg struct {
+	RetryCfg
+	Environments map[Environment]RetryCfg `json:"environments"`
+}
+
+// Returns a copy of r with all non zero fields of override applied on top of it.
+func (r RetryCfg) Merge(override RetryCfg) RetryCfg {
+	if override.MaxAttempts != 0 {
+		r.MaxAttempts = override.MaxAttempts
+	}
+	if len(override.StatusCodes) != 0 {
+		r.StatusCodes = override.StatusCodes
+	}
+	if override.InitialDelay != "" {
+		r.InitialDelay = override.InitialDelay
+	}
+	if override.MaxDelay != "" {
+		r.MaxDelay = override.MaxDelay
+	}
+	if override.Jitter != nil {
+		r.Jitter = override.Jitter
+	}
+	if override.NonIdempotent != nil {
+		r.NonIdempotent = override.NonIdempotent
+	}
+	return r
+}
+
+func (r RetryCfg) GetInitialDelay() (time.Duration, error) {
+	return parseOptionalDuration("initial retry delay", r.InitialDelay)
+}
+
+func (r RetryCfg) GetMaxDelay() (time.Duration, error) {
+	return parseOptionalDuration("max retry delay", r.MaxDelay)
+}
+
+// Resolves the retry settings for the currently active environment, environment specific settings
+// take precedence over the global ones.
+func (ac *AppCfg) GetRetryCfg() RetryCfg {
+	raw := ac.RawCfg.Retry
+	cfg := raw.RetryCfg
+	if envCfg, ok := raw.Environments[Environment(manager.GetActiveEnvName())]; ok {
+		cfg = cfg.Merge(envCfg)
+	}
+	return cfg
+}
+
+func parseOptionalDuration(name, val string) (time.Duration, error) {
+	if val == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s '%s': %w", name, val, err)
+	}
+	return d, nil
+}
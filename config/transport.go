@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	RedirectsFollow = "follow"
+	RedirectsNone   = "none"
+
+	DefaultMaxRedirects = 10
+
+	// Explicitly unsets a setting, for instance to drop a proxy set globally or earlier in the
+	// session. Redirects use 'follow' for that instead, 'none' disables them.
+	TransportUnset = "none"
+)
+
+// Settings for the underlying http client, every field is optional and zero values fall back to
+// go's defaults.
+type TransportCfg struct {
+	Timeout            string `json:"timeout,omitempty"`
+	Proxy              string `json:"proxy,omitempty"`
+	CACert             string `json:"caCert,omitempty"`
+	ClientCert         string `json:"clientCert,omitempty"`
+	ClientKey          string `json:"clientKey,omitempty"`
+	InsecureSkipVerify *bool  `json:"insecureSkipVerify,omitempty"`
+	Redirects          string `json:"redirects,omitempty"` // follow|none|<max>
+}
+
+// Global transport settings along with per environment overrides
+type RawTransportCfg struct {
+	TransportCfg
+	Environments map[Environment]TransportCfg `json:"environments"`
+}
+
+// Returns a copy of t with all non zero fields of override applied on top of it. Unset ('none')
+// values are carried over as is so they keep overriding lower layers, see withoutUnset.
+func (t TransportCfg) Merge(override TransportCfg) TransportCfg {
+	if override.Timeout != "" {
+		t.Timeout = override.Timeout
+	}
+	if override.Proxy != "" {
+		t.Proxy = override.Proxy
+	}
+	if override.CACert != "" {
+		t.CACert = override.CACert
+	}
+	if override.ClientCert != "" {
+		t.ClientCert = override.ClientCert
+	}
+	if override.ClientKey != "" {
+		t.ClientKey = override.ClientKey
+	}
+	if override.InsecureSkipVerify != nil {
+		t.InsecureSkipVerify = override.InsecureSkipVerify
+	}
+	if override.Redirects != "" {
+		t.Redirects = override.Redirects
+	}
+	return t
+}
+
+// Clears the settings that were explicitly unset, they fall back to go's defaults
+func (t TransportCfg) withoutUnset() TransportCfg {
+	for _, field := range []*string{&t.Timeout, &t.Proxy, &t.CACert} {
+		if strings.TrimSpace(*field) == TransportUnset {
+			*field = ""
+		}
+	}
+	if strings.TrimSpace(t.ClientCert) == TransportUnset {
+		t.ClientCert, t.ClientKey = "", ""
+	}
+	return t
+}
+
+func (t TransportCfg) GetTimeout() (time.Duration, error) {
+	if strings.TrimSpace(t.Timeout) == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(t.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid transport timeout '%s': %w", t.Timeout, err)
+	}
+	return d, nil
+}
+
+// Maximum number of redirects to follow, 0 meaning redirects aren't followed at all
+func (t TransportCfg) GetMaxRedirects() (int, error) {
+	switch val := strings.TrimSpace(t.Redirects); val {
+	case "", RedirectsFollow:
+		return DefaultMaxRedirects, nil
+	case RedirectsNone:
+		return 0, nil
+	default:
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf(
+				"invalid redirects '%s', expected %s|%s|<max>",
+				val,
+				RedirectsFollow,
+				RedirectsNone,
+			)
+		}
+		return n, nil
+	}
+}
+
+func (t TransportCfg) SkipsVerification() bool {
+	return t.InsecureSkipVerify != nil && *t.InsecureSkipVerify
+}
+
+// Resolves the transport settings for the currently active environment. Precedence (lowest to
+// highest) - global config, environment specific config, overrides set during the session.
+func (ac *AppCfg) GetTransportCfg() TransportCfg {
+	return ac.TransportCfgWith(TransportCfg{})
+}
+
+// Same as GetTransportCfg, with override applied on top of the session's overrides. Lets callers
+// try an override out before committing it with OverrideTransportCfg.
+func (ac *AppCfg) TransportCfgWith(override TransportCfg) TransportCfg {
+	env := Environment(manager.GetActiveEnvName())
+	raw := ac.RawCfg.Transport
+
+	cfg := raw.TransportCfg
+	if envCfg, ok := raw.Environments[env]; ok {
+		cfg = cfg.Merge(envCfg)
+	}
+
+	if sessionOverride, ok := ac.transportOverrides[env]; ok {
+		cfg = cfg.Merge(sessionOverride)
+	}
+	return cfg.Merge(override).withoutUnset()
+}
+
+// Overrides transport settings for the currently active environment, only for this session.
+func (ac *AppCfg) OverrideTransportCfg(override TransportCfg) {
+	env := Environment(manager.GetActiveEnvName())
+	if ac.transportOverrides == nil {
+		ac.transportOverrides = make(map[Environment]TransportCfg)
+	}
+	ac.transportOverrides[env] = ac.transportOverrides[env].Merge(override)
+}
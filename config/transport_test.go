@@ -0,0 +1,29 @@
+package config
+
+import "testing"
+
+func TestTransportCfgUnset(t *testing.T) {
+	global := TransportCfg{
+		Timeout:    "30s",
+		Proxy:      "http://localhost:8080",
+		CACert:     "ca.pem",
+		ClientCert: "client.pem",
+		ClientKey:  "client-key.pem",
+	}
+
+	// Session overrides pile up, a later unset wins over an earlier value and vice versa
+	session := TransportCfg{Proxy: "http://localhost:9090", Timeout: TransportUnset}
+	session = session.Merge(TransportCfg{Proxy: TransportUnset, ClientCert: TransportUnset})
+
+	got := global.Merge(session).withoutUnset()
+	want := TransportCfg{CACert: "ca.pem"}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	session = session.Merge(TransportCfg{Timeout: "5s"})
+	got = global.Merge(session).withoutUnset()
+	if got.Timeout != "5s" || got.Proxy != "" {
+		t.Errorf("expected the timeout to be set again and the proxy to stay unset, got %+v", got)
+	}
+}
@@ -12,6 +12,8 @@ require github.com/shubm-quodes/readline v1.5.3
 
 require github.com/atotto/clipboard v0.1.4
 
+require golang.org/x/net v0.44.0
+
 require (
 	github.com/alecthomas/chroma v0.10.0
 	github.com/briandowns/spinner v1.23.2
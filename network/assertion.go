@@ -0,0 +1,135 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// '$time<500', the time the request took in milliseconds
+var timeAssertionRegex = regexp.MustCompile(`^\$time\s*(!=|>=|<=|=|>|<)\s*(.*)$`)
+
+var assertionPrefixRegex = regexp.MustCompile(`^\$(status|header|body|length|time)\b`)
+
+// Checked against a response, as in '$status=200' or '$time<500'. Assertions are conditions (see
+// NewCondition) that explain what they found, along with response time checks, which conditions
+// can't express as they need the request's timing on top of the response.
+type Assertion struct {
+	Raw      string
+	Op       Operator
+	Expected string
+	actual   func(resp *http.Response, elapsed time.Duration) (any, bool)
+}
+
+type AssertionOutcome struct {
+	Passed   bool
+	Expected string
+	Actual   string
+}
+
+// Conditions able to tell the value they compare, see Assertion
+type valueCondition interface {
+	Condition
+	operands() (Operator, string)
+	actual(resp *http.Response) (any, bool)
+}
+
+// Tells assertions ('$status=200', '$body.id exists'...) apart from other expressions
+func IsAssertionExpr(expr string) bool {
+	return assertionPrefixRegex.MatchString(strings.TrimSpace(expr))
+}
+
+// Parses assertions combined with '&&' (surrounded by spaces, like in conditions), each of which is
+// checked (and reported) on its own
+func NewAssertions(expr string) ([]*Assertion, error) {
+	if len(splitLogical(expr, LogicalOr)) > 1 {
+		return nil, fmt.Errorf("'%s' isn't supported by assertions", LogicalOr)
+	}
+
+	var assertions []*Assertion
+	for _, part := range splitLogical(expr, LogicalAnd) {
+		a, err := NewAssertion(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		assertions = append(assertions, a)
+	}
+	return assertions, nil
+}
+
+func NewAssertion(raw string) (*Assertion, error) {
+	if raw == "" {
+		return nil, errors.New("please specify an assertion, for instance '$status=200'")
+	}
+
+	if matches := timeAssertionRegex.FindStringSubmatch(raw); matches != nil {
+		op, value := Operator(matches[1]), matches[2]
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, fmt.Errorf("invalid assertion '%s': '$time' requires milliseconds", raw)
+		}
+
+		return &Assertion{
+			Raw:      raw,
+			Op:       op,
+			Expected: value,
+			actual: func(_ *http.Response, elapsed time.Duration) (any, bool) {
+				return float64(elapsed.Microseconds()) / 1000, elapsed > 0
+			},
+		}, nil
+	}
+
+	c, err := NewCondition(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	vc, ok := c.(valueCondition)
+	if !ok {
+		return nil, fmt.Errorf("'%s' can't be asserted", raw)
+	}
+
+	a := &Assertion{Raw: raw}
+	a.Op, a.Expected = vc.operands()
+	a.actual = func(resp *http.Response, _ time.Duration) (any, bool) {
+		return vc.actual(resp)
+	}
+	return a, nil
+}
+
+// Elapsed is the time the request took, '$time' assertions fail when it isn't known
+func (a *Assertion) Check(resp *http.Response, elapsed time.Duration) AssertionOutcome {
+	actual, found := a.actual(resp, elapsed)
+
+	outcome := AssertionOutcome{
+		Passed:   compare(a.Op, a.Expected, actual, found),
+		Expected: a.describeExpected(),
+		Actual:   "<missing>",
+	}
+	if found {
+		outcome.Actual = stringify(actual)
+	}
+	return outcome
+}
+
+// As in '>= 3', plain equality reads as the value alone
+func (a *Assertion) describeExpected() string {
+	switch a.Op {
+	case OpEq:
+		return a.Expected
+	case OpExists:
+		return string(OpExists)
+	case OpMatches:
+		return "matching " + a.Expected
+	default:
+		return string(a.Op) + " " + a.Expected
+	}
+}
+
+func (c *StatusCondition) operands() (Operator, string) { return c.Op, c.Expected }
+func (c *HeaderCondition) operands() (Operator, string) { return c.Op, c.Expected }
+func (c *BodyCondition) operands() (Operator, string)   { return c.Op, c.Expected }
+func (c *LengthCondition) operands() (Operator, string) { return c.Op, c.Expected }
@@ -0,0 +1,124 @@
+package network
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAssertionCheck(t *testing.T) {
+	body := `{"items": [{"id": 1}, {"id": 2}], "name": "repl-reqs", "count": "3"}`
+	headers := map[string]string{"X-Request-Id": "abc-123"}
+
+	tests := []struct {
+		raw          string
+		wantPassed   bool
+		wantExpected string
+		wantActual   string
+	}{
+		{"$status=200", true, "200", "200"},
+		{"$status>=400", false, ">= 400", "200"},
+		{"$header.X-Request-Id~=^abc", true, "matching ^abc", "abc-123"},
+		{"$header.X-Missing exists", false, "exists", "<missing>"},
+		{"$body.name=repl-reqs", true, "repl-reqs", "repl-reqs"},
+		{"$body.count>2", true, "> 2", "3"},
+		{"$body.items.0.id!=1", false, "!= 1", "1"},
+		{"$length.items=2", true, "2", "2"},
+		{"$length.items>2", false, "> 2", "2"},
+		{"$length.name=9", true, "9", "9"},
+		{"$length.missing=0", false, "0", "<missing>"},
+		{"$time<500", true, "< 500", "120"},
+		{"$time<=100", false, "<= 100", "120"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			a, err := NewAssertion(tt.raw)
+			if err != nil {
+				t.Fatalf("NewAssertion() error = %v", err)
+			}
+
+			resp := mockResponse(200, body, "application/json", headers)
+			got := a.Check(resp, 120*time.Millisecond)
+			if got.Passed != tt.wantPassed {
+				t.Errorf("Check() passed = %v, want %v", got.Passed, tt.wantPassed)
+			}
+			if got.Expected != tt.wantExpected || got.Actual != tt.wantActual {
+				t.Errorf(
+					"Check() = expected %q actual %q, want expected %q actual %q",
+					got.Expected,
+					got.Actual,
+					tt.wantExpected,
+					tt.wantActual,
+				)
+			}
+		})
+	}
+}
+
+func TestAssertionUnknownTime(t *testing.T) {
+	a, err := NewAssertion("$time<500")
+	if err != nil {
+		t.Fatalf("NewAssertion() error = %v", err)
+	}
+
+	got := a.Check(mockResponse(200, "", "text/plain", nil), 0)
+	if got.Passed || got.Actual != "<missing>" {
+		t.Errorf("Check() = %+v, want a failure for an unknown response time", got)
+	}
+}
+
+func TestNewAssertions(t *testing.T) {
+	assertions, err := NewAssertions("$status=200 && $length.items>=1 && $time<1000")
+	if err != nil {
+		t.Fatalf("NewAssertions() error = %v", err)
+	}
+	if len(assertions) != 3 {
+		t.Fatalf("NewAssertions() got %d assertions, want 3", len(assertions))
+	}
+
+	// Operators within values are left alone
+	values := map[string]string{
+		"$body.msg~=a||b":                      "a||b",
+		"$body.q contains x&&y":                "x&&y",
+		"$body.q contains x&&y && $status=200": "x&&y",
+	}
+	for expr, want := range values {
+		assertions, err := NewAssertions(expr)
+		if err != nil {
+			t.Fatalf("NewAssertions(%q) error = %v", expr, err)
+		}
+		if got := assertions[0].Expected; got != want {
+			t.Errorf("NewAssertions(%q) expected %q, want %q", expr, got, want)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"$status=200 || $status=204",
+		"$time<fast",
+		"$length.items>few",
+		"status=200",
+	}
+	for _, expr := range invalid {
+		if _, err := NewAssertions(expr); err == nil {
+			t.Errorf("NewAssertions(%q) expected an error", expr)
+		}
+	}
+}
+
+func TestIsAssertionExpr(t *testing.T) {
+	tests := map[string]bool{
+		"$status=200":                true,
+		" $body.items exists":        true,
+		"$length.items>0 && $time<5": true,
+		"{{$1.role}}=admin":          false,
+		"$statuses=1":                false,
+		"status=200":                 false,
+	}
+
+	for expr, want := range tests {
+		if got := IsAssertionExpr(expr); got != want {
+			t.Errorf("IsAssertionExpr(%q) = %v, want %v", expr, got, want)
+		}
+	}
+}
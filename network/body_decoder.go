@@ -0,0 +1,220 @@
+package network
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Decodes a response body into a value conditions & paths can be evaluated against, for instance
+// a map[string]any for JSON objects or a string for plain text.
+type BodyDecoder func(raw []byte) (any, error)
+
+var (
+	decodersMu   sync.RWMutex
+	bodyDecoders = map[string]BodyDecoder{
+		"application/json":     decodeJSON,
+		"application/x-ndjson": decodeNDJSON,
+		"application/ndjson":   decodeNDJSON,
+		"application/jsonl":    decodeNDJSON,
+		"application/xml":      decodeXML,
+		"text/xml":             decodeXML,
+		"text/plain":           decodeText,
+	}
+
+	// Decoders for structured syntax suffixes, as in 'application/problem+json'
+	suffixDecoders = map[string]BodyDecoder{
+		"+json": decodeJSON,
+		"+xml":  decodeXML,
+	}
+)
+
+// Registers (or replaces) the decoder for the media type, for instance 'application/yaml'
+func RegisterBodyDecoder(mediaType string, decoder BodyDecoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	bodyDecoders[strings.ToLower(mediaType)] = decoder
+}
+
+// Decodes the body as per it's content type. The body is sniffed when the content type is missing
+// or too generic (application/octet-stream) to pick a decoder.
+func DecodeBody(contentType string, raw []byte) (any, error) {
+	if decoder := lookupDecoder(contentType); decoder != nil {
+		return decoder(raw)
+	}
+
+	if decoder := sniffDecoder(raw); decoder != nil {
+		return decoder(raw)
+	}
+	return nil, fmt.Errorf("unsupported Content-Type: %s", contentType)
+}
+
+func lookupDecoder(contentType string) BodyDecoder {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+
+	if decoder, ok := bodyDecoders[mediaType]; ok {
+		return decoder
+	}
+
+	for suffix, decoder := range suffixDecoders {
+		if strings.HasSuffix(mediaType, suffix) {
+			return decoder
+		}
+	}
+
+	if strings.HasPrefix(mediaType, "text/") {
+		return decodeText
+	}
+	return nil
+}
+
+func sniffDecoder(raw []byte) BodyDecoder {
+	trimmed := bytes.TrimSpace(raw)
+	switch {
+	case len(trimmed) == 0:
+		return nil
+	case json.Valid(trimmed):
+		return decodeJSON
+	case trimmed[0] == '{' && isNDJSON(trimmed):
+		return decodeNDJSON
+	case trimmed[0] == '<':
+		return decodeXML
+	case utf8.Valid(trimmed):
+		return decodeText
+	default:
+		return nil
+	}
+}
+
+func isNDJSON(raw []byte) bool {
+	for line := range bytes.Lines(raw) {
+		if line = bytes.TrimSpace(line); len(line) > 0 && !json.Valid(line) {
+			return false
+		}
+	}
+	return true
+}
+
+func decodeJSON(raw []byte) (any, error) {
+	var body any
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, fmt.Errorf("failed to decode json: %w", err)
+	}
+	return body, nil
+}
+
+// Each line is decoded into an element of the resulting []any
+func decodeNDJSON(raw []byte) (any, error) {
+	var (
+		items   []any
+		scanner = bufio.NewScanner(bytes.NewReader(raw))
+	)
+
+	scanner.Buffer(make([]byte, 0, 64*1024), len(raw)+1)
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var item any
+		if err := json.Unmarshal(line, &item); err != nil {
+			return nil, fmt.Errorf("failed to decode ndjson line %d: %w", n, err)
+		}
+		items = append(items, item)
+	}
+	return items, scanner.Err()
+}
+
+func decodeText(raw []byte) (any, error) {
+	return string(raw), nil
+}
+
+// Decodes xml into maps keyed by element names, attributes are prefixed with '@'. Elements
+// without attributes & children decode into their text, repeated elements into a []any.
+func decodeXML(raw []byte) (any, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("failed to decode xml: no root element")
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode xml: %w", err)
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			root, err := decodeXMLElement(decoder, start)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode xml: %w", err)
+			}
+			return map[string]any{start.Name.Local: root}, nil
+		}
+	}
+}
+
+func decodeXMLElement(decoder *xml.Decoder, start xml.StartElement) (any, error) {
+	var (
+		fields = make(map[string]any)
+		text   strings.Builder
+	)
+
+	for _, attr := range start.Attr {
+		fields["@"+attr.Name.Local] = attr.Value
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := decodeXMLElement(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			addXMLChild(fields, t.Name.Local, child)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+			if len(fields) == 0 {
+				return content, nil
+			}
+
+			if content != "" {
+				fields["#text"] = content
+			}
+			return fields, nil
+		}
+	}
+}
+
+func addXMLChild(fields map[string]any, name string, child any) {
+	existing, ok := fields[name]
+	if !ok {
+		fields[name] = child
+		return
+	}
+
+	if items, isList := existing.([]any); isList {
+		fields[name] = append(items, child)
+	} else {
+		fields[name] = []any{existing, child}
+	}
+}
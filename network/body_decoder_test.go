@@ -0,0 +1,114 @@
+package network
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        any
+		wantErr     bool
+	}{
+		{
+			"json with params",
+			"application/json; charset=utf-8",
+			`{"ok": true}`,
+			map[string]any{"ok": true},
+			false,
+		},
+		{
+			"problem json, odd casing",
+			"Application/Problem+JSON",
+			`{"status": 503}`,
+			map[string]any{"status": float64(503)},
+			false,
+		},
+		{
+			"ndjson",
+			"application/x-ndjson",
+			"{\"n\": 1}\n\n{\"n\": 2}\n",
+			[]any{map[string]any{"n": float64(1)}, map[string]any{"n": float64(2)}},
+			false,
+		},
+		{"plain text", "text/plain", "OK", "OK", false},
+		{"other text", "text/csv", "a,b", "a,b", false},
+		{
+			"xml",
+			"application/xml",
+			`<job id="7"><state>done</state><tag>a</tag><tag>b</tag></job>`,
+			map[string]any{"job": map[string]any{
+				"@id":   "7",
+				"state": "done",
+				"tag":   []any{"a", "b"},
+			}},
+			false,
+		},
+		{"sniffed json", "", `[1, 2]`, []any{float64(1), float64(2)}, false},
+		{
+			"sniffed ndjson",
+			"application/octet-stream",
+			"{\"n\": 1}\n{\"n\": 2}",
+			[]any{map[string]any{"n": float64(1)}, map[string]any{"n": float64(2)}},
+			false,
+		},
+		{"sniffed xml", "", `<ok>yes</ok>`, map[string]any{"ok": "yes"}, false},
+		{"sniffed text", "", "healthy", "healthy", false},
+		{"invalid json", "application/json", `{"ok": `, nil, true},
+		{"binary", "", "\xff\xfe\x00", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeBody(tt.contentType, []byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeBody() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeBody() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterBodyDecoder(t *testing.T) {
+	RegisterBodyDecoder("application/x-custom", func(raw []byte) (any, error) {
+		return map[string]any{"len": len(raw)}, nil
+	})
+
+	cond, _ := NewCondition("$body.len=3")
+	if !cond.Evaluate(mockResponse(200, "abc", "application/x-custom", nil)) {
+		t.Errorf("expected the registered decoder to be used")
+	}
+}
+
+func TestTextBodyConditions(t *testing.T) {
+	body := "status: healthy\nuptime: 42h"
+
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{"$body contains healthy", true},
+		{"$body contains degraded", false},
+		{"$body~=(?m)^uptime: \\d+h$", true},
+		{"$body~=^healthy", false},
+		{"$body.status=healthy", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			cond, err := NewCondition(tt.raw)
+			if err != nil {
+				t.Fatalf("NewCondition() error = %v", err)
+			}
+
+			if got := cond.Evaluate(mockResponse(200, body, "", nil)); got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
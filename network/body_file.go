@@ -0,0 +1,109 @@
+package network
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/shubm-quodes/repl-reqs/config"
+	"github.com/shubm-quodes/repl-reqs/util"
+)
+
+// Body read from a file, relative paths are resolved against the config directory. When expand is
+// set, '{{var}}' placeholders are substituted line by line as the file is streamed.
+func (rd *RequestDraft) SetBodyFile(path string, expand bool) *RequestDraft {
+	rd.Body = ""
+	rd.BodyFile = path
+	rd.ExpandBodyFile = expand
+	rd.BodyKind = BodyKindRaw
+	return rd
+}
+
+func (rd *RequestDraft) applyBodyFile(req *http.Request, lookups map[string]string) error {
+	path, err := util.ReplaceStrPattern(rd.BodyFile, config.VarPattern, lookups)
+	if err != nil {
+		return err
+	}
+	path = config.GetAppCfg().ResolvePath(path)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to read body file: %w", err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("body file '%s' is a directory", path)
+	}
+
+	expand := rd.ExpandBodyFile
+	req.GetBody = func() (io.ReadCloser, error) {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if expand {
+			return newExpandingReader(file, lookups), nil
+		}
+		return file, nil
+	}
+
+	if req.Body, err = req.GetBody(); err != nil {
+		return fmt.Errorf("failed to open body file: %w", err)
+	}
+
+	// Size of an expanded body isn't known upfront, it's sent chunked instead
+	req.ContentLength = info.Size()
+	if expand {
+		req.ContentLength = -1
+	}
+	return nil
+}
+
+// Streams a file substituting variables one line at a time, so that large payloads never have to be
+// held in memory as a whole.
+type expandingReader struct {
+	file    *os.File
+	src     *bufio.Reader
+	lookups map[string]string
+	pending []byte
+	err     error
+}
+
+func newExpandingReader(file *os.File, lookups map[string]string) *expandingReader {
+	return &expandingReader{
+		file:    file,
+		src:     bufio.NewReader(file),
+		lookups: lookups,
+	}
+}
+
+func (er *expandingReader) Read(p []byte) (int, error) {
+	for len(er.pending) == 0 {
+		if er.err != nil {
+			return 0, er.err
+		}
+
+		line, err := er.src.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+		er.err = err
+
+		expanded, expErr := util.ReplaceStrPattern(line, config.VarPattern, er.lookups)
+		if expErr != nil {
+			return 0, expErr
+		}
+		er.pending = []byte(expanded)
+	}
+
+	n := copy(p, er.pending)
+	er.pending = er.pending[n:]
+	return n, nil
+}
+
+func (er *expandingReader) Close() error {
+	return er.file.Close()
+}
@@ -0,0 +1,347 @@
+package network
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shubm-quodes/repl-reqs/config"
+	"github.com/shubm-quodes/repl-reqs/log"
+	"github.com/shubm-quodes/repl-reqs/util"
+	"golang.org/x/net/publicsuffix"
+)
+
+const CookieJarFileName = "cookies.json"
+
+type StoredCookie struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain"`
+	Path     string    `json:"path"`
+	Expires  time.Time `json:"expires,omitzero"`
+	Secure   bool      `json:"secure,omitempty"`
+	HttpOnly bool      `json:"httpOnly,omitempty"`
+	HostOnly bool      `json:"hostOnly,omitempty"`
+}
+
+// CookieJar is a http.CookieJar that keeps a separate set of cookies for every environment and
+// persists them to disk, so that sessions survive restarts.
+type CookieJar struct {
+	mu        sync.Mutex
+	filePath  string
+	enabled   bool
+	cookies   map[config.Environment][]*StoredCookie
+	activeEnv func() config.Environment
+	saver     *util.DebouncedSaver
+}
+
+type cookieJarData struct {
+	Enabled      *bool                                  `json:"enabled,omitempty"`
+	Environments map[config.Environment][]*StoredCookie `json:"environments"`
+}
+
+func NewCookieJar(filePath string) *CookieJar {
+	jar := &CookieJar{
+		filePath: filePath,
+		enabled:  true,
+		cookies:  make(map[config.Environment][]*StoredCookie),
+		activeEnv: func() config.Environment {
+			return config.Environment(config.GetEnvManager().GetActiveEnvName())
+		},
+	}
+	jar.load()
+	jar.saver = util.NewDebouncedSaver("cookie_jar", util.SaveDebounce, jar.save)
+	return jar
+}
+
+func (j *CookieJar) load() {
+	data, err := os.ReadFile(j.filePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Debug("cookie_jar: %s", err.Error())
+		}
+		return
+	}
+
+	var jarData cookieJarData
+	if err := json.Unmarshal(data, &jarData); err != nil {
+		log.Warn("cookie_jar: failed to parse persisted cookies")
+		log.Debug("cookie_jar: %s", err.Error())
+		return
+	}
+
+	// Files without the field, say hand-written ones, keep the jar enabled
+	if jarData.Enabled != nil {
+		j.enabled = *jarData.Enabled
+	}
+	if jarData.Environments != nil {
+		j.cookies = jarData.Environments
+	}
+}
+
+func (j *CookieJar) save() error {
+	if j.filePath == "" {
+		return nil
+	}
+
+	j.mu.Lock()
+	enabled := j.enabled
+	jsonData, err := json.MarshalIndent(cookieJarData{
+		Enabled:      &enabled,
+		Environments: j.cookies,
+	}, "", "  ")
+	j.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return util.WriteFileAtomic(j.filePath, jsonData, 0600)
+}
+
+func (j *CookieJar) triggerSave() {
+	j.saver.Trigger()
+}
+
+// gracefully stops the background saver and ensures final save
+func (j *CookieJar) Shutdown() {
+	j.saver.Shutdown()
+}
+
+func (j *CookieJar) Enabled() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.enabled
+}
+
+func (j *CookieJar) SetEnabled(enabled bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.enabled = enabled
+	j.triggerSave()
+}
+
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.enabled {
+		return
+	}
+
+	env := j.activeEnv()
+	now := time.Now()
+	host := canonicalHost(u.Host)
+	changed := false
+
+	for _, c := range cookies {
+		stored, ok := newStoredCookie(c, u, host, now)
+		if !ok {
+			continue
+		}
+
+		j.cookies[env] = removeCookie(j.cookies[env], stored.Name, stored.Domain, stored.Path)
+		if stored.Expires.IsZero() || stored.Expires.After(now) {
+			j.cookies[env] = append(j.cookies[env], stored)
+		}
+		changed = true
+	}
+
+	if changed {
+		j.triggerSave()
+	}
+}
+
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.enabled {
+		return nil
+	}
+
+	var (
+		now     = time.Now()
+		host    = canonicalHost(u.Host)
+		secure  = u.Scheme == "https"
+		matches []*StoredCookie
+	)
+
+	reqPath := u.Path
+	if reqPath == "" {
+		reqPath = "/"
+	}
+
+	for _, c := range j.cookies[j.activeEnv()] {
+		if !c.Expires.IsZero() && !c.Expires.After(now) {
+			continue
+		}
+		if (c.Secure && !secure) || !c.matchesDomain(host) || !pathMatches(reqPath, c.Path) {
+			continue
+		}
+		matches = append(matches, c)
+	}
+
+	// More specific paths go first
+	sort.SliceStable(matches, func(a, b int) bool {
+		return len(matches[a].Path) > len(matches[b].Path)
+	})
+
+	cookies := make([]*http.Cookie, 0, len(matches))
+	for _, c := range matches {
+		cookies = append(cookies, &http.Cookie{Name: c.Name, Value: c.Value})
+	}
+	return cookies
+}
+
+// Returns the unexpired cookies of the active environment
+func (j *CookieJar) List() []StoredCookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	var cookies []StoredCookie
+	for _, c := range j.cookies[j.activeEnv()] {
+		if c.Expires.IsZero() || c.Expires.After(now) {
+			cookies = append(cookies, *c)
+		}
+	}
+
+	sort.Slice(cookies, func(a, b int) bool {
+		if cookies[a].Domain != cookies[b].Domain {
+			return cookies[a].Domain < cookies[b].Domain
+		}
+		return cookies[a].Name < cookies[b].Name
+	})
+	return cookies
+}
+
+// Deletes cookies with the given name from the active environment, irrespective of their domain
+// and path. Returns the number of cookies deleted.
+func (j *CookieJar) Delete(name string) int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	env := j.activeEnv()
+	remaining := make([]*StoredCookie, 0, len(j.cookies[env]))
+	for _, c := range j.cookies[env] {
+		if c.Name != name {
+			remaining = append(remaining, c)
+		}
+	}
+
+	deleted := len(j.cookies[env]) - len(remaining)
+	if deleted > 0 {
+		j.cookies[env] = remaining
+		j.triggerSave()
+	}
+	return deleted
+}
+
+func (j *CookieJar) Clear() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	delete(j.cookies, j.activeEnv())
+	j.triggerSave()
+}
+
+func newStoredCookie(c *http.Cookie, u *url.URL, host string, now time.Time) (*StoredCookie, bool) {
+	stored := &StoredCookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Path:     c.Path,
+		Secure:   c.Secure,
+		HttpOnly: c.HttpOnly,
+	}
+
+	if c.Domain == "" {
+		stored.Domain, stored.HostOnly = host, true
+	} else {
+		domain := strings.ToLower(strings.TrimPrefix(c.Domain, "."))
+		if host != domain && !isSubdomain(host, domain) {
+			return nil, false // Servers can't set cookies for other domains
+		}
+		if isPublicSuffix(domain) {
+			if host != domain {
+				return nil, false // Nor for public suffixes like 'com' or 'co.uk'
+			}
+			stored.HostOnly = true
+		}
+		stored.Domain = domain
+	}
+
+	if stored.Path == "" || stored.Path[0] != '/' {
+		stored.Path = defaultCookiePath(u.Path)
+	}
+
+	switch {
+	case c.MaxAge < 0:
+		stored.Expires = now.Add(-time.Second)
+	case c.MaxAge > 0:
+		stored.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+	case !c.Expires.IsZero():
+		stored.Expires = c.Expires
+	}
+
+	return stored, true
+}
+
+func (c *StoredCookie) matchesDomain(host string) bool {
+	if c.HostOnly {
+		return host == c.Domain
+	}
+	return host == c.Domain || isSubdomain(host, c.Domain)
+}
+
+func removeCookie(cookies []*StoredCookie, name, domain, path string) []*StoredCookie {
+	for i, c := range cookies {
+		if c.Name == name && c.Domain == domain && c.Path == path {
+			return append(cookies[:i], cookies[i+1:]...)
+		}
+	}
+	return cookies
+}
+
+func canonicalHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+func isSubdomain(host, domain string) bool {
+	return net.ParseIP(host) == nil && strings.HasSuffix(host, "."+domain)
+}
+
+// As per RFC 6265 section 5.3, step 5
+func isPublicSuffix(domain string) bool {
+	return net.ParseIP(domain) == nil && publicsuffix.List.PublicSuffix(domain) == domain
+}
+
+// As per RFC 6265 section 5.1.4
+func pathMatches(reqPath, cookiePath string) bool {
+	if reqPath == cookiePath {
+		return true
+	}
+
+	if strings.HasPrefix(reqPath, cookiePath) {
+		return strings.HasSuffix(cookiePath, "/") || reqPath[len(cookiePath)] == '/'
+	}
+	return false
+}
+
+func defaultCookiePath(reqPath string) string {
+	idx := strings.LastIndex(reqPath, "/")
+	if idx <= 0 {
+		return "/"
+	}
+	return reqPath[:idx]
+}
@@ -0,0 +1,203 @@
+package network
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shubm-quodes/repl-reqs/config"
+)
+
+func newTestCookieJar(t *testing.T, env *config.Environment) *CookieJar {
+	jar := NewCookieJar(filepath.Join(t.TempDir(), CookieJarFileName))
+	jar.activeEnv = func() config.Environment { return *env }
+	t.Cleanup(jar.Shutdown)
+	return jar
+}
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("failed to parse url %s: %v", raw, err)
+	}
+	return u
+}
+
+func cookieNames(cookies []*http.Cookie) map[string]string {
+	names := make(map[string]string, len(cookies))
+	for _, c := range cookies {
+		names[c.Name] = c.Value
+	}
+	return names
+}
+
+func TestCookieJarMatching(t *testing.T) {
+	env := config.Environment("dev")
+	jar := newTestCookieJar(t, &env)
+
+	jar.SetCookies(mustParseURL(t, "https://api.example.com/auth/login"), []*http.Cookie{
+		{Name: "session", Value: "abc"},
+		{Name: "shared", Value: "1", Domain: ".example.com", Path: "/"},
+		{Name: "secure", Value: "s", Path: "/", Secure: true},
+		{Name: "scoped", Value: "x", Path: "/admin"},
+		{Name: "foreign", Value: "nope", Domain: "other.com"},
+	})
+
+	tests := []struct {
+		name string
+		url  string
+		want []string
+	}{
+		{"same path https", "https://api.example.com/auth/me", []string{"session", "shared", "secure"}},
+		{"plain http drops secure", "http://api.example.com/auth", []string{"session", "shared"}},
+		{"sibling host gets domain cookie", "https://www.example.com/", []string{"shared"}},
+		{"path scoped", "https://api.example.com/admin/users", []string{"shared", "secure", "scoped"}},
+		{"path prefix isn't a match", "https://api.example.com/administrator", []string{"shared", "secure"}},
+		{"other domain", "https://other.com/", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cookieNames(jar.Cookies(mustParseURL(t, tt.url)))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Cookies() = %v, want %v", got, tt.want)
+			}
+			for _, name := range tt.want {
+				if _, ok := got[name]; !ok {
+					t.Errorf("Cookies() = %v, missing %s", got, name)
+				}
+			}
+		})
+	}
+}
+
+func TestCookieJarPublicSuffixes(t *testing.T) {
+	env := config.Environment("dev")
+	jar := newTestCookieJar(t, &env)
+
+	jar.SetCookies(mustParseURL(t, "https://api.example.com/"), []*http.Cookie{
+		{Name: "tld", Value: "1", Domain: "com", Path: "/"},
+		{Name: "dotted", Value: "1", Domain: ".com", Path: "/"},
+	})
+	jar.SetCookies(mustParseURL(t, "https://shop.example.co.uk/"), []*http.Cookie{
+		{Name: "etld", Value: "1", Domain: "co.uk", Path: "/"},
+		{Name: "site", Value: "1", Domain: "example.co.uk", Path: "/"},
+	})
+	// A host that is itself a public suffix keeps its cookie, just not for its subdomains
+	jar.SetCookies(mustParseURL(t, "https://github.io/"), []*http.Cookie{
+		{Name: "self", Value: "1", Domain: "github.io", Path: "/"},
+	})
+
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{"https://other.com/", nil},
+		{"https://api.example.com/", nil},
+		{"https://other.co.uk/", nil},
+		{"https://www.example.co.uk/", []string{"site"}},
+		{"https://github.io/", []string{"self"}},
+		{"https://someone.github.io/", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got := cookieNames(jar.Cookies(mustParseURL(t, tt.url)))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Cookies() = %v, want %v", got, tt.want)
+			}
+			for _, name := range tt.want {
+				if _, ok := got[name]; !ok {
+					t.Errorf("Cookies() = %v, missing %s", got, name)
+				}
+			}
+		})
+	}
+}
+
+func TestCookieJarEnvironmentsAndPersistence(t *testing.T) {
+	env := config.Environment("dev")
+	jar := newTestCookieJar(t, &env)
+	u := mustParseURL(t, "https://api.example.com/")
+
+	jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: "dev-session"}})
+
+	env = "prod"
+	if got := jar.Cookies(u); len(got) != 0 {
+		t.Errorf("expected no cookies in another env, got %v", got)
+	}
+	jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: "prod-session"}})
+
+	jar.Shutdown()
+	reloaded := NewCookieJar(jar.filePath)
+	defer reloaded.Shutdown()
+	reloaded.activeEnv = jar.activeEnv
+	if got := cookieNames(reloaded.Cookies(u)); got["session"] != "prod-session" {
+		t.Errorf("expected persisted prod session, got %v", got)
+	}
+
+	env = "dev"
+	if got := cookieNames(reloaded.Cookies(u)); got["session"] != "dev-session" {
+		t.Errorf("expected persisted dev session, got %v", got)
+	}
+}
+
+func TestCookieJarExpiryDeleteAndToggle(t *testing.T) {
+	env := config.Environment("dev")
+	jar := newTestCookieJar(t, &env)
+	u := mustParseURL(t, "https://api.example.com/")
+
+	jar.SetCookies(u, []*http.Cookie{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}})
+	jar.SetCookies(u, []*http.Cookie{{Name: "a", MaxAge: -1}})
+	if got := cookieNames(jar.Cookies(u)); len(got) != 1 || got["b"] != "2" {
+		t.Fatalf("expected only 'b' after expiring 'a', got %v", got)
+	}
+
+	if deleted := jar.Delete("b"); deleted != 1 {
+		t.Errorf("Delete() = %d, want 1", deleted)
+	}
+	if got := jar.List(); len(got) != 0 {
+		t.Errorf("expected empty jar, got %v", got)
+	}
+
+	jar.SetEnabled(false)
+	jar.SetCookies(u, []*http.Cookie{{Name: "c", Value: "3"}})
+	if got := jar.List(); len(got) != 0 {
+		t.Errorf("disabled jar shouldn't store cookies, got %v", got)
+	}
+
+	jar.SetEnabled(true)
+	jar.SetCookies(u, []*http.Cookie{{Name: "c", Value: "3"}})
+	if got := cookieNames(jar.Cookies(u)); got["c"] != "3" {
+		t.Errorf("expected 'c' once re-enabled, got %v", got)
+	}
+}
+
+func TestCookieJarLoadsFilesWithoutEnabled(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), CookieJarFileName)
+	data := `{"environments": {"dev": [{"name": "sid", "value": "1", "domain": "example.com", "path": "/"}]}}`
+	if err := os.WriteFile(filePath, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	jar := NewCookieJar(filePath)
+	defer jar.Shutdown()
+	jar.activeEnv = func() config.Environment { return "dev" }
+
+	if !jar.Enabled() {
+		t.Errorf("expected the jar to stay enabled")
+	}
+	if got := cookieNames(jar.Cookies(mustParseURL(t, "https://example.com/"))); got["sid"] != "1" {
+		t.Errorf("expected the persisted cookie, got %v", got)
+	}
+
+	jar.SetEnabled(false)
+	jar.Shutdown()
+	reloaded := NewCookieJar(filePath)
+	defer reloaded.Shutdown()
+	if reloaded.Enabled() {
+		t.Errorf("expected the jar to stay disabled once disabled")
+	}
+}
@@ -0,0 +1,139 @@
+package network
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const progressInterval = 200 * time.Millisecond
+
+type DownloadOpts struct {
+	Path string
+	// Continues a partial download by requesting the remaining bytes using a 'Range' header
+	Resume     bool
+	OnProgress func(DownloadProgress)
+}
+
+type DownloadProgress struct {
+	Written int64 // Includes bytes written by previous attempts while resuming
+	Total   int64 // -1 if the server didn't specify the length
+	Elapsed time.Duration
+	resumed int64
+}
+
+// Throughput in bytes per second for the current attempt
+func (dp DownloadProgress) Rate() float64 {
+	if dp.Elapsed <= 0 {
+		return 0
+	}
+	return float64(dp.Written-dp.resumed) / dp.Elapsed.Seconds()
+}
+
+// Returns the offset to resume from, if the destination already has some bytes
+func prepareDownload(req *http.Request, opts *DownloadOpts) int64 {
+	// Transparent decompression would make lengths & ranges meaningless
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "identity")
+	}
+
+	if !opts.Resume {
+		return 0
+	}
+
+	info, err := os.Stat(opts.Path)
+	if err != nil || info.Size() == 0 {
+		return 0
+	}
+
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", info.Size()))
+	return info.Size()
+}
+
+func isDownloadable(resp *http.Response, opts *DownloadOpts) bool {
+	return resp.StatusCode == http.StatusOK ||
+		(opts.Resume && resp.StatusCode == http.StatusPartialContent)
+}
+
+func streamToFile(resp *http.Response, opts *DownloadOpts, offset int64) error {
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resp.StatusCode == http.StatusPartialContent {
+		if start := contentRangeStart(resp); start != offset {
+			return fmt.Errorf("server resumed from byte %d, expected %d", start, offset)
+		}
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	} else {
+		offset = 0 // Server ignored the range, start over
+	}
+
+	file, err := os.OpenFile(opts.Path, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open download destination: %w", err)
+	}
+	defer file.Close()
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+
+	pw := &progressWriter{
+		progress: DownloadProgress{Written: offset, Total: total, resumed: offset},
+		start:    time.Now(),
+		notify:   opts.OnProgress,
+	}
+
+	if _, err := io.Copy(io.MultiWriter(file, pw), resp.Body); err != nil {
+		return fmt.Errorf("download interrupted after %d bytes: %w", pw.progress.Written, err)
+	}
+
+	pw.flush()
+	return nil
+}
+
+// Parses the start offset from a 'Content-Range: bytes start-end/total' header
+func contentRangeStart(resp *http.Response) int64 {
+	cr := strings.TrimPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	start, _, found := strings.Cut(cr, "-")
+	if !found {
+		return -1
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(start), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// Counts written bytes, notifying at most once every progressInterval
+type progressWriter struct {
+	progress DownloadProgress
+	start    time.Time
+	lastSent time.Time
+	notify   func(DownloadProgress)
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	pw.progress.Written += int64(len(p))
+	if time.Since(pw.lastSent) >= progressInterval {
+		pw.flush()
+	}
+	return len(p), nil
+}
+
+func (pw *progressWriter) flush() {
+	if pw.notify == nil {
+		return
+	}
+
+	pw.lastSent = time.Now()
+	pw.progress.Elapsed = time.Since(pw.start)
+	pw.notify(pw.progress)
+}
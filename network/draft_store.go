@@ -0,0 +1,202 @@
+package network
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/shubm-quodes/repl-reqs/log"
+	"github.com/shubm-quodes/repl-reqs/util"
+)
+
+const (
+	DraftsFileName = "drafts.json"
+
+	// Discarded drafts are kept around for these many sessions
+	DiscardedDraftSessions = 10
+)
+
+type StoredDraft struct {
+	Id    string        `json:"id"`
+	Draft *RequestDraft `json:"draft"`
+}
+
+type DiscardedDraft struct {
+	StoredDraft
+	Session     int       `json:"session"`
+	DiscardedAt time.Time `json:"discardedAt"`
+}
+
+type draftStoreData struct {
+	Session   int              `json:"session"`
+	Drafts    []StoredDraft    `json:"drafts"` // Most recent first
+	Discarded []DiscardedDraft `json:"discarded,omitempty"`
+}
+
+// DraftStore autosaves request drafts to disk (debounced, in the background) so that they survive
+// restarts. Every run of the REPL counts as a new session, discarded drafts are kept around for
+// DiscardedDraftSessions sessions so that they can be restored.
+type DraftStore struct {
+	mu        sync.Mutex
+	filePath  string
+	session   int
+	restored  []*RequestDraft
+	discarded []DiscardedDraft
+	drafts    []StoredDraft // Copies of the drafts as of their last change, see stage
+	attached  bool
+	saver     *util.DebouncedSaver
+}
+
+func NewDraftStore(filePath string) *DraftStore {
+	store := &DraftStore{filePath: filePath}
+	store.load()
+	store.saver = util.NewDebouncedSaver("draft_store", util.SaveDebounce, store.save)
+	return store
+}
+
+func (s *DraftStore) load() {
+	s.session = 1
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Debug("draft_store: %s", err.Error())
+		}
+		return
+	}
+
+	var storeData draftStoreData
+	if err := json.Unmarshal(data, &storeData); err != nil {
+		log.Warn("draft_store: failed to parse persisted drafts")
+		log.Debug("draft_store: %s", err.Error())
+		return
+	}
+
+	s.session = storeData.Session + 1
+	for _, stored := range storeData.Drafts {
+		if draft := stored.restore(); draft != nil {
+			s.restored = append(s.restored, draft)
+		}
+	}
+
+	for _, discarded := range storeData.Discarded {
+		if discarded.Draft != nil && s.isRecent(discarded.Session, DiscardedDraftSessions) {
+			s.discarded = append(s.discarded, discarded)
+		}
+	}
+}
+
+func (sd StoredDraft) restore() *RequestDraft {
+	if sd.Draft == nil {
+		return nil
+	}
+
+	if sd.Id != "" {
+		sd.Draft.id = sd.Id
+	}
+	return sd.Draft
+}
+
+// Whether the session is one of the last n sessions, the current one included
+func (s *DraftStore) isRecent(session, n int) bool {
+	return session > s.session-n
+}
+
+func (s *DraftStore) save() error {
+	if s.filePath == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	// Nothing's attached to the store yet, saving now would wipe out the persisted drafts
+	if !s.attached {
+		s.mu.Unlock()
+		return nil
+	}
+
+	jsonData, err := json.MarshalIndent(draftStoreData{
+		Session:   s.session,
+		Drafts:    s.drafts,
+		Discarded: s.discarded,
+	}, "", "  ")
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return util.WriteFileAtomic(s.filePath, jsonData, 0600)
+}
+
+func (s *DraftStore) triggerSave() {
+	s.saver.Trigger()
+}
+
+// gracefully stops the background saver and ensures final save
+func (s *DraftStore) Shutdown() {
+	s.saver.Shutdown()
+}
+
+func (s *DraftStore) Session() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.session
+}
+
+// Drafts persisted by the previous session, most recent first
+func (s *DraftStore) Restored() []*RequestDraft {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.restored
+}
+
+/*
+Keeps copies of the drafts (most recent first) for the next save. Drafts are edited in place by the
+REPL, copies are taken as they change so that the background save never reads a draft mid edit.
+*/
+func (s *DraftStore) stage(drafts []*RequestDraft) {
+	stored := make([]StoredDraft, 0, len(drafts))
+	for _, draft := range drafts {
+		stored = append(stored, StoredDraft{Id: draft.GetId(), Draft: draft.Clone()})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drafts, s.attached = stored, true
+}
+
+func (s *DraftStore) Discard(draft *RequestDraft) {
+	s.mu.Lock()
+	s.discarded = append(s.discarded, DiscardedDraft{
+		StoredDraft: StoredDraft{Id: draft.GetId(), Draft: draft.Clone()},
+		Session:     s.session,
+		DiscardedAt: time.Now(),
+	})
+	s.mu.Unlock()
+
+	s.triggerSave()
+}
+
+// Takes out the drafts discarded in the last n sessions (the current one included), in the order
+// they were discarded
+func (s *DraftStore) TakeDiscarded(sessions int) []*RequestDraft {
+	s.mu.Lock()
+	var (
+		taken []*RequestDraft
+		kept  = make([]DiscardedDraft, 0, len(s.discarded))
+	)
+	for _, discarded := range s.discarded {
+		if s.isRecent(discarded.Session, sessions) {
+			taken = append(taken, discarded.restore())
+		} else {
+			kept = append(kept, discarded)
+		}
+	}
+	s.discarded = kept
+	s.mu.Unlock()
+
+	if len(taken) > 0 {
+		s.triggerSave()
+	}
+	return taken
+}
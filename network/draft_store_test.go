@@ -0,0 +1,102 @@
+package network
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// Starts a session against the drafts file, drafts of the "ctx" context are persisted
+func newDraftSession(t *testing.T, filePath string) (*RequestManager, *DraftStore) {
+	t.Helper()
+	store := NewDraftStore(filePath)
+	mgr := NewRequestManager(NewRequestTracker(), nil, nil)
+	mgr.RestoreDrafts("ctx", store)
+	return mgr, store
+}
+
+func TestDraftsPersistAcrossSessions(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), DraftsFileName)
+
+	mgr, store := newDraftSession(t, filePath)
+	older := NewRequestDraft().SetUrl("https://example.com/older").SetHeader("X-Id", "1")
+	newer := NewRequestDraft().SetUrl("https://example.com/newer").SetLabel("newer")
+	mgr.AddDraftRequest("ctx", older)
+	mgr.AddDraftRequest("ctx", newer)
+	store.Shutdown()
+
+	mgr, store = newDraftSession(t, filePath)
+	defer store.Shutdown()
+
+	if store.Session() != 2 {
+		t.Errorf("expected the second session, got %d", store.Session())
+	}
+
+	drafts := mgr.GetRequestDrafts("ctx")
+	if len(drafts) != 2 {
+		t.Fatalf("expected 2 drafts to be restored, got %d", len(drafts))
+	}
+	if drafts[0].GetId() != newer.GetId() || drafts[1].GetId() != older.GetId() {
+		t.Errorf("expected ids & order to be preserved")
+	}
+	if drafts[0].GetLabel() != "newer" || drafts[1].Headers["x-id"] != "1" {
+		t.Errorf("restored drafts don't match the persisted ones")
+	}
+}
+
+func TestRestoreDiscardedDrafts(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), DraftsFileName)
+
+	mgr, store := newDraftSession(t, filePath)
+	draft := NewRequestDraft().SetUrl("https://example.com/gone")
+	mgr.AddDraftRequest("ctx", draft)
+	mgr.RemoveRequestDraft("ctx", draft.GetId())
+	store.Shutdown()
+
+	mgr, store = newDraftSession(t, filePath)
+	defer store.Shutdown()
+
+	if len(mgr.GetRequestDrafts("ctx")) != 0 {
+		t.Fatalf("expected the discarded draft not to be restored")
+	}
+
+	if restored, _ := mgr.RestoreDiscardedDrafts("ctx", 1); len(restored) != 0 {
+		t.Errorf("expected nothing discarded in the current session, got %d", len(restored))
+	}
+
+	restored, err := mgr.RestoreDiscardedDrafts("ctx", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(restored) != 1 || mgr.PeakRequestDraft("ctx").GetId() != draft.GetId() {
+		t.Errorf("expected the discarded draft to be current again")
+	}
+
+	if _, err := mgr.RestoreDiscardedDrafts("other-ctx", 2); err == nil {
+		t.Errorf("expected an error for a context that isn't persisted")
+	}
+}
+
+func TestDraftEditsPersistOnceSaved(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), DraftsFileName)
+
+	mgr, store := newDraftSession(t, filePath)
+	draft := NewRequestDraft().SetUrl("https://example.com/edited")
+	mgr.AddDraftRequest("ctx", draft)
+
+	draft.SetHeader("X-Saved", "1")
+	mgr.SaveDrafts("ctx")
+	// Edits the store hasn't been told about stay out of the saved copies
+	draft.SetHeader("X-Unsaved", "1")
+	store.Shutdown()
+
+	mgr, store = newDraftSession(t, filePath)
+	defer store.Shutdown()
+
+	restored := mgr.PeakRequestDraft("ctx")
+	if restored == nil || restored.Headers["x-saved"] != "1" {
+		t.Fatalf("expected the saved edit to be restored")
+	}
+	if _, ok := restored.Headers["x-unsaved"]; ok {
+		t.Errorf("expected the draft to be saved as of SaveDrafts")
+	}
+}
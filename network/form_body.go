@@ -0,0 +1,105 @@
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+type BodyKind string
+
+const (
+	BodyKindRaw       BodyKind = "raw"
+	BodyKindForm      BodyKind = "form"
+	BodyKindMultipart BodyKind = "multipart"
+
+	ContentTypeForm = "application/x-www-form-urlencoded"
+)
+
+// Path of a file to be uploaded as a multipart form field
+type FormFile string
+
+func (bk BodyKind) IsValid() bool {
+	switch bk {
+	case "", BodyKindRaw, BodyKindForm, BodyKindMultipart:
+		return true
+	default:
+		return false
+	}
+}
+
+// Encodes the fields as per the body kind, values of type FormFile are attached as files when
+// encoding multipart bodies. Returns the encoded body along with it's content type.
+func EncodeFormBody(kind BodyKind, fields map[string]any) (*bytes.Buffer, string, error) {
+	switch kind {
+	case BodyKindForm:
+		vals := make(url.Values, len(fields))
+		for key, val := range fields {
+			if _, isFile := val.(FormFile); isFile {
+				return nil, "", fmt.Errorf(
+					"'%s' is a file, files can only be sent as multipart bodies",
+					key,
+				)
+			}
+			vals.Set(key, fmt.Sprint(val))
+		}
+		return bytes.NewBufferString(vals.Encode()), ContentTypeForm, nil
+
+	case BodyKindMultipart:
+		return encodeMultipartBody(fields)
+
+	default:
+		return nil, "", fmt.Errorf("'%s' bodies can't be form encoded", kind)
+	}
+}
+
+func encodeMultipartBody(fields map[string]any) (*bytes.Buffer, string, error) {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+
+	// Sorted, to keep the parts in a predictable order
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var err error
+		if path, isFile := fields[key].(FormFile); isFile {
+			err = writeFilePart(writer, key, string(path))
+		} else {
+			err = writer.WriteField(key, fmt.Sprint(fields[key]))
+		}
+
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return body, writer.FormDataContentType(), nil
+}
+
+func writeFilePart(writer *multipart.Writer, field, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file for field '%s': %w", field, err)
+	}
+	defer file.Close()
+
+	part, err := writer.CreateFormFile(field, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(part, file)
+	return err
+}
@@ -0,0 +1,333 @@
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shubm-quodes/repl-reqs/config"
+	"github.com/shubm-quodes/repl-reqs/log"
+	"github.com/shubm-quodes/repl-reqs/util"
+)
+
+const (
+	HistoryFileName = "request_history.json"
+
+	// Request & response bodies larger than this are truncated in the history
+	HistoryBodyLimit = 64 << 10
+
+	RedactedHeaderValue = "<redacted>"
+)
+
+// Headers that carry credentials, their values aren't written to the history file
+var SensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"Api-Key",
+	"X-Auth-Token",
+	"X-Access-Token",
+	"X-Csrf-Token",
+	"X-Xsrf-Token",
+}
+
+type HistoryEntry struct {
+	Id                string        `json:"id"`
+	Method            string        `json:"method"`
+	Url               string        `json:"url"`
+	Headers           http.Header   `json:"headers,omitempty"`
+	Body              string        `json:"body,omitempty"`
+	BodyTruncated     bool          `json:"bodyTruncated,omitempty"`
+	BodyOmitted       bool          `json:"bodyOmitted,omitempty"` // Not kept, see historyBodies
+	StatusCode        int           `json:"statusCode,omitempty"`
+	Err               string        `json:"error,omitempty"`
+	Duration          time.Duration `json:"duration"`
+	Timestamp         time.Time     `json:"timestamp"`
+	ResponseHeaders   http.Header   `json:"responseHeaders,omitempty"`
+	ResponseBody      string        `json:"responseBody,omitempty"`
+	ResponseTruncated bool          `json:"responseTruncated,omitempty"`
+}
+
+/*
+History keeps the most recent requests (newest first) along with their responses, it's persisted to
+disk and capped at a max number of entries. Entries are written to disk without the values of
+SensitiveHeaders, and without request bodies unless keepBodies is set.
+*/
+type History struct {
+	mu         sync.Mutex
+	filePath   string
+	maxSize    int
+	keepBodies bool
+	entries    []*HistoryEntry
+	saver      *util.DebouncedSaver
+}
+
+func NewHistory(filePath string, maxSize int, keepBodies bool) *History {
+	if maxSize <= 0 {
+		maxSize = config.DefaultHistorySize
+	}
+
+	h := &History{filePath: filePath, maxSize: maxSize, keepBodies: keepBodies}
+	h.load()
+	h.saver = util.NewDebouncedSaver("history", util.SaveDebounce, h.save)
+	return h
+}
+
+func (h *History) load() {
+	data, err := os.ReadFile(h.filePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Debug("history: %s", err.Error())
+		}
+		return
+	}
+
+	if err := json.Unmarshal(data, &h.entries); err != nil {
+		log.Warn("history: failed to parse request history")
+		log.Debug("history: %s", err.Error())
+		return
+	}
+
+	if len(h.entries) > h.maxSize {
+		h.entries = h.entries[:h.maxSize]
+	}
+}
+
+func (h *History) save() error {
+	if h.filePath == "" {
+		return nil
+	}
+
+	h.mu.Lock()
+	persisted := make([]*HistoryEntry, len(h.entries))
+	for i, entry := range h.entries {
+		persisted[i] = entry.persisted(h.keepBodies)
+	}
+	h.mu.Unlock()
+
+	jsonData, err := json.MarshalIndent(persisted, "", "  ")
+
+	if err != nil {
+		return err
+	}
+	return util.WriteFileAtomic(h.filePath, jsonData, 0600)
+}
+
+// gracefully stops the background saver and ensures final save
+func (h *History) Shutdown() {
+	h.saver.Shutdown()
+}
+
+func (h *History) Add(entry *HistoryEntry) {
+	h.mu.Lock()
+	h.entries = append([]*HistoryEntry{entry}, h.entries...)
+	if len(h.entries) > h.maxSize {
+		h.entries = h.entries[:h.maxSize]
+	}
+	h.mu.Unlock()
+
+	h.saver.Trigger()
+}
+
+// Most recent first
+func (h *History) Entries() []*HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]*HistoryEntry(nil), h.entries...)
+}
+
+// Looks up an entry by it's position, 1 being the most recent one
+func (h *History) Get(n int) (*HistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if n < 1 || n > len(h.entries) {
+		return nil, fmt.Errorf("history entry #%d not found, there are %d entries", n, len(h.entries))
+	}
+	return h.entries[n-1], nil
+}
+
+func (h *History) Clear() {
+	h.mu.Lock()
+	h.entries = nil
+	h.mu.Unlock()
+
+	h.saver.Trigger()
+}
+
+/*
+Records the request & it's (buffered) response, the bodies are left intact. Headers are recorded as
+they were set on the request, replays get the common headers and cookies as any other request.
+*/
+func newHistoryEntry(trackerReq *TrackerRequest, resp *http.Response, err error) *HistoryEntry {
+	req := trackerReq.Request.HttpRequest
+	entry := &HistoryEntry{
+		Id:        trackerReq.Request.ID,
+		Method:    req.Method,
+		Url:       req.URL.String(),
+		Headers:   trackerReq.RequestHeaders.Clone(),
+		Duration:  trackerReq.RequestTime,
+		Timestamp: time.Now().Add(-trackerReq.RequestTime),
+	}
+
+	if req.GetBody != nil {
+		if body, bodyErr := req.GetBody(); bodyErr == nil {
+			entry.Body, entry.BodyTruncated = readCapped(body)
+			body.Close()
+		}
+	}
+
+	if err != nil {
+		entry.Err = err.Error()
+	}
+
+	if resp != nil {
+		entry.StatusCode = resp.StatusCode
+		entry.ResponseHeaders = resp.Header.Clone()
+		if raw, readErr := util.ReadAndResetIoCloser(&resp.Body); readErr == nil {
+			entry.ResponseBody, entry.ResponseTruncated = capBody(raw)
+		}
+	}
+	return entry
+}
+
+// Copy of the entry as it's written to disk
+func (e *HistoryEntry) persisted(keepBodies bool) *HistoryEntry {
+	persisted := *e
+	persisted.Headers = redactHeaders(e.Headers)
+	persisted.ResponseHeaders = redactHeaders(e.ResponseHeaders)
+	if !keepBodies && e.Body != "" {
+		persisted.Body, persisted.BodyTruncated, persisted.BodyOmitted = "", false, true
+	}
+	return &persisted
+}
+
+func redactHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	for key := range redacted {
+		if isSensitiveHeader(key) {
+			redacted[key] = []string{RedactedHeaderValue}
+		}
+	}
+	return redacted
+}
+
+func isSensitiveHeader(name string) bool {
+	return slices.ContainsFunc(SensitiveHeaders, func(sensitive string) bool {
+		return strings.EqualFold(sensitive, name)
+	})
+}
+
+// Redacted headers are left out of replays, common headers are applied to them as to any request
+func isRedacted(vals []string) bool {
+	return len(vals) == 1 && vals[0] == RedactedHeaderValue
+}
+
+func (e *HistoryEntry) replayableBody() error {
+	switch {
+	case e.BodyTruncated:
+		return errors.New("the request body was too large to be kept in the history")
+	case e.BodyOmitted:
+		return errors.New(
+			"the request body wasn't kept in the history, enable 'historyBodies' to keep them",
+		)
+	}
+	return nil
+}
+
+func readCapped(r io.Reader) (string, bool) {
+	raw, err := io.ReadAll(io.LimitReader(r, HistoryBodyLimit+1))
+	if err != nil {
+		return "", false
+	}
+	return capBody(raw)
+}
+
+func capBody(raw []byte) (string, bool) {
+	if len(raw) > HistoryBodyLimit {
+		return string(raw[:HistoryBodyLimit]), true
+	}
+	return string(raw), false
+}
+
+// Rebuilds the request, so that it can be sent again
+func (e *HistoryEntry) Request() (*http.Request, error) {
+	if err := e.replayableBody(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(e.Method, e.Url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, vals := range e.Headers {
+		if !isRedacted(vals) {
+			key = http.CanonicalHeaderKey(key)
+			req.Header[key] = append(req.Header[key], vals...)
+		}
+	}
+
+	if e.Body != "" {
+		setReplayableBody(req, []byte(e.Body))
+	}
+	return req, nil
+}
+
+// Turns the entry into a draft, query params are split out of the url. Drafts are persisted in
+// plain text, so SensitiveHeaders are left out of them.
+func (e *HistoryEntry) Draft() (*RequestDraft, error) {
+	if err := e.replayableBody(); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(e.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	draft := NewRequestDraft().SetMethod(HTTPMethod(e.Method))
+	for key, vals := range u.Query() {
+		draft.SetQueryParam(key, vals[len(vals)-1])
+	}
+
+	u.RawQuery = ""
+	draft.SetUrl(u.String())
+
+	for key, vals := range e.Headers {
+		if !strings.EqualFold(key, "Content-Length") && !isSensitiveHeader(key) {
+			draft.SetHeader(key, strings.Join(vals, ", "))
+		}
+	}
+
+	if e.Body != "" {
+		draft.SetBody(e.Body)
+	}
+	return draft, nil
+}
+
+// Gives back the recorded response, as if it were just received
+func (e *HistoryEntry) Response() *http.Response {
+	if e.StatusCode == 0 {
+		return nil
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Header:        e.ResponseHeaders.Clone(),
+		Body:          io.NopCloser(bytes.NewReader([]byte(e.ResponseBody))),
+		ContentLength: int64(len(e.ResponseBody)),
+	}
+}
@@ -0,0 +1,250 @@
+package network
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shubm-quodes/repl-reqs/config"
+)
+
+func TestHistoryRecordsTrackedRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"echo": "` + string(body) + `"}`))
+	}))
+	defer srv.Close()
+
+	filePath := filepath.Join(t.TempDir(), HistoryFileName)
+	history := NewHistory(filePath, 10, true)
+	mgr := NewRequestManager(NewRequestTracker(), nil, nil)
+	mgr.SetHistory(history)
+
+	req := createTestRequest(t, http.MethodPost, srv.URL+"/users?role=admin")
+	setReplayableBody(req, []byte("john"))
+	_, updates, _ := mgr.MakeRequestWithContext("ctx", req)
+	update := <-updates
+
+	if body, _ := io.ReadAll(update.Resp().Body); string(body) != `{"echo": "john"}` {
+		t.Errorf("expected the response body to be left intact, got %q", body)
+	}
+
+	entry, err := history.Get(1)
+	if err != nil {
+		t.Fatalf("expected the request to be recorded: %v", err)
+	}
+	if entry.Method != http.MethodPost || entry.StatusCode != http.StatusCreated {
+		t.Errorf("unexpected entry %s %d", entry.Method, entry.StatusCode)
+	}
+	if entry.Body != "john" || entry.ResponseBody != `{"echo": "john"}` {
+		t.Errorf("expected bodies to be recorded, got %q & %q", entry.Body, entry.ResponseBody)
+	}
+
+	history.Shutdown()
+	if reloaded, _ := NewHistory(filePath, 10, true).Get(1); reloaded == nil || reloaded.Id != entry.Id {
+		t.Errorf("expected the history to be persisted")
+	}
+}
+
+func TestHistoryReplayDoesNotRepeatCommonHeaders(t *testing.T) {
+	var received []http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Clone())
+	}))
+	defer srv.Close()
+
+	env := config.Environment("dev")
+	jar := newTestCookieJar(t, &env)
+	jar.SetCookies(mustParseURL(t, srv.URL), []*http.Cookie{{Name: "sid", Value: "1"}})
+
+	history := NewHistory("", 10, false)
+	defer history.Shutdown()
+	mgr := NewRequestManager(NewRequestTracker(), nil, http.Header{"X-Common": {"c"}})
+	mgr.SetCookieJar(jar)
+	mgr.SetHistory(history)
+
+	req := createTestRequest(t, http.MethodGet, srv.URL)
+	req.Header.Set("Authorization", "Bearer secret")
+	_, updates, _ := mgr.MakeRequestWithContext("ctx", req)
+	<-updates
+
+	entry, _ := history.Get(1)
+	if _, ok := entry.Headers["X-Common"]; ok || entry.Headers.Get("Cookie") != "" {
+		t.Errorf("expected common headers & cookies not to be recorded, got %v", entry.Headers)
+	}
+
+	replay, err := entry.Request()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, updates, _ = mgr.MakeRequestWithContext("ctx", replay)
+	<-updates
+
+	for i, header := range received {
+		if got := header.Values("X-Common"); len(got) != 1 {
+			t.Errorf("request %d sent X-Common %v, want it once", i+1, got)
+		}
+		if got := header.Values("Cookie"); len(got) != 1 || got[0] != "sid=1" {
+			t.Errorf("request %d sent Cookie %v, want [sid=1]", i+1, got)
+		}
+		if got := header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("request %d sent Authorization %q", i+1, got)
+		}
+	}
+
+	draft, err := entry.Draft()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := draft.Headers["authorization"]; ok {
+		t.Errorf("expected sensitive headers to be left out of drafts, got %v", draft.Headers)
+	}
+}
+
+func TestHistoryIsCapped(t *testing.T) {
+	history := NewHistory("", 2, false)
+	defer history.Shutdown()
+
+	for _, id := range []string{"a", "b", "c"} {
+		history.Add(&HistoryEntry{Id: id})
+	}
+
+	entries := history.Entries()
+	if len(entries) != 2 || entries[0].Id != "c" || entries[1].Id != "b" {
+		t.Errorf("expected only the 2 most recent entries to be kept")
+	}
+	if _, err := history.Get(3); err == nil {
+		t.Errorf("expected an error for an evicted entry")
+	}
+}
+
+func TestHistoryEntryToDraft(t *testing.T) {
+	entry := &HistoryEntry{
+		Method:  http.MethodPut,
+		Url:     "https://example.com/users/1?notify=true",
+		Headers: http.Header{"Content-Type": {"application/json"}, "Content-Length": {"13"}},
+		Body:    `{"name": "j"}`,
+	}
+
+	draft, err := entry.Draft()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if draft.GetUrl() != "https://example.com/users/1" || draft.QueryParams["notify"] != "true" {
+		t.Errorf("expected query params to be split out of the url, got %s", draft.GetUrl())
+	}
+	if draft.Headers["content-type"] != "application/json" || draft.Headers["content-length"] != "" {
+		t.Errorf("unexpected headers %v", draft.Headers)
+	}
+
+	req, err := draft.Finalize()
+	if err != nil {
+		t.Fatalf("failed to finalize the draft: %v", err)
+	}
+	if body, _ := io.ReadAll(req.Body); !strings.Contains(string(body), `"j"`) {
+		t.Errorf("expected the body to be carried over, got %q", body)
+	}
+
+	entry.BodyTruncated = true
+	if _, err := entry.Request(); err == nil {
+		t.Errorf("expected truncated bodies not to be replayed")
+	}
+}
+
+func TestHistoryRedactsPersistedEntries(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), HistoryFileName)
+	history := NewHistory(filePath, 10, false)
+	history.Add(&HistoryEntry{
+		Id:     "a",
+		Method: http.MethodPost,
+		Url:    "https://example.com/login",
+		Headers: http.Header{
+			"Authorization": {"Bearer secret"},
+			"X-Api-Key":     {"key"},
+			"Accept":        {"application/json"},
+		},
+		Body:            `{"password": "hunter2"}`,
+		ResponseHeaders: http.Header{"Set-Cookie": {"session=secret"}},
+	})
+
+	// Entries of the current session are left intact
+	if entry, _ := history.Get(1); entry.Headers.Get("Authorization") != "Bearer secret" {
+		t.Errorf("expected the in-memory entry to keep its headers")
+	}
+	history.Shutdown()
+
+	raw, _ := os.ReadFile(filePath)
+	for _, secret := range []string{"Bearer secret", "key\"", "hunter2", "session=secret"} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("expected %q not to be written to the history file", secret)
+		}
+	}
+
+	entry, _ := NewHistory(filePath, 10, false).Get(1)
+	if entry == nil || entry.Headers.Get("Accept") != "application/json" || !entry.BodyOmitted {
+		t.Fatalf("unexpected persisted entry %+v", entry)
+	}
+	if _, err := entry.Request(); err == nil {
+		t.Errorf("expected requests with omitted bodies not to be replayed")
+	}
+
+	entry.Body, entry.BodyOmitted = "", false
+	req, err := entry.Request()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := req.Header["Authorization"]; ok || req.Header.Get("Accept") == "" {
+		t.Errorf("expected redacted headers to be left out of replays, got %v", req.Header)
+	}
+}
+
+// Drafts keep their header keys lowercase, they're neither canonicalized when sent nor recorded
+func TestHistoryRedactsDraftHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	filePath := filepath.Join(t.TempDir(), HistoryFileName)
+	history := NewHistory(filePath, 10, false)
+	mgr := NewRequestManager(NewRequestTracker(), nil, nil)
+	mgr.SetHistory(history)
+
+	draft := NewRequestDraft().SetMethod(http.MethodGet).SetUrl(srv.URL)
+	draft.SetHeader("Authorization", "Bearer secret").
+		SetHeader("X-Api-Key", "api-secret").
+		SetHeader("Accept", "application/json")
+	req, err := draft.Finalize()
+	if err != nil {
+		t.Fatalf("failed to finalize the draft: %v", err)
+	}
+	_, updates, _ := mgr.MakeRequestWithContext("ctx", req)
+	<-updates
+	history.Shutdown()
+
+	raw, _ := os.ReadFile(filePath)
+	for _, secret := range []string{"Bearer secret", "api-secret"} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("expected %q not to be written to the history file", secret)
+		}
+	}
+
+	entry, _ := NewHistory(filePath, 10, false).Get(1)
+	if entry == nil {
+		t.Fatal("expected the request to be recorded")
+	}
+	replay, err := entry.Request()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replay.Header.Get("Accept") != "application/json" || replay.Header.Get("Authorization") != "" {
+		t.Errorf("expected canonical, unredacted headers only, got %v", replay.Header)
+	}
+	if _, ok := replay.Header["accept"]; ok {
+		t.Errorf("expected header keys to be canonicalized, got %v", replay.Header)
+	}
+}
@@ -2,13 +2,17 @@ package network
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shubm-quodes/repl-reqs/config"
 	"github.com/shubm-quodes/repl-reqs/util"
 )
 
@@ -16,12 +20,22 @@ type Tracker interface {
 	AddRequest(*TrackerRequest)
 }
 
+type requestOpts struct {
+	trackInContext bool
+	bufferBody     bool
+	download       *DownloadOpts
+}
+
 type RequestManager struct {
 	tracker          *RequestTracker
 	client           *http.Client
+	jar              *CookieJar
 	commonHeaders    http.Header
 	requests         map[string]*util.LRUList[string, *Request]
 	drafts           map[string]*util.LRUList[string, *RequestDraft]
+	draftStore       *DraftStore
+	draftCtx         string // The context whose drafts are persisted in the draft store
+	history          *History
 	lastReceivedResp *http.Response
 	mu               sync.Mutex
 }
@@ -32,7 +46,7 @@ func NewRequestManager(
 	commonHeaders http.Header,
 ) *RequestManager {
 	if client == nil {
-		client = &http.Client{}
+		client = &http.Client{CheckRedirect: newRedirectPolicy(config.DefaultMaxRedirects)}
 	}
 	return &RequestManager{
 		tracker:       tracker,
@@ -43,6 +57,55 @@ func NewRequestManager(
 	}
 }
 
+// Rebuilds the http client as per the supplied transport settings
+func (rm *RequestManager) ApplyTransportCfg(cfg config.TransportCfg) error {
+	client, err := NewHttpClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	if rm.jar != nil {
+		client.Jar = rm.jar
+	}
+	rm.client = client
+	return nil
+}
+
+// Requests tracked in a context are recorded in the history
+func (rm *RequestManager) SetHistory(history *History) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.history = history
+}
+
+func (rm *RequestManager) History() *History {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	return rm.history
+}
+
+// The jar is carried over whenever the client gets rebuilt
+func (rm *RequestManager) SetCookieJar(jar *CookieJar) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.jar = jar
+	rm.client.Jar = jar
+}
+
+func (rm *RequestManager) CookieJar() *CookieJar {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	return rm.jar
+}
+
+func (rm *RequestManager) Client() *http.Client {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	return rm.client
+}
+
 func (rm *RequestManager) AddDraftRequest(context string, draftReq *RequestDraft) {
 	rm.mu.Lock()
 	defer rm.mu.Unlock()
@@ -51,6 +114,70 @@ func (rm *RequestManager) AddDraftRequest(context string, draftReq *RequestDraft
 		rm.drafts[context] = util.NewLRUList[string, *RequestDraft]()
 	}
 	rm.drafts[context].AddOrTouch(draftReq)
+	rm.saveDrafts(context)
+}
+
+// Restores the drafts persisted by the previous session into the context, from then on the
+// drafts of the context are autosaved to the store
+func (rm *RequestManager) RestoreDrafts(context string, store *DraftStore) int {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.draftStore, rm.draftCtx = store, context
+	if _, ok := rm.drafts[context]; !ok {
+		rm.drafts[context] = util.NewLRUList[string, *RequestDraft]()
+	}
+
+	restored := store.Restored()
+	for i := len(restored) - 1; i >= 0; i-- { // Oldest first, so that the order is preserved
+		rm.drafts[context].AddOrTouch(restored[i])
+	}
+	store.stage(rm.drafts[context].GetAll())
+	return len(restored)
+}
+
+// Brings back the drafts discarded in the last n sessions, the most recently discarded one
+// becomes the current draft
+func (rm *RequestManager) RestoreDiscardedDrafts(
+	context string,
+	sessions int,
+) ([]*RequestDraft, error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if rm.draftStore == nil || context != rm.draftCtx {
+		return nil, errors.New("drafts aren't being persisted")
+	}
+
+	if _, ok := rm.drafts[context]; !ok {
+		rm.drafts[context] = util.NewLRUList[string, *RequestDraft]()
+	}
+
+	discarded := rm.draftStore.TakeDiscarded(sessions)
+	for _, draft := range discarded {
+		rm.drafts[context].AddOrTouch(draft)
+	}
+	rm.saveDrafts(context)
+	return discarded, nil
+}
+
+// Drafts are edited in place, cmds that change them let the store know (see DraftStore.stage)
+func (rm *RequestManager) SaveDrafts(context string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.saveDrafts(context)
+}
+
+// Must be called with the lock held
+func (rm *RequestManager) saveDrafts(context string) {
+	if rm.draftStore == nil || context != rm.draftCtx {
+		return
+	}
+
+	if lru, ok := rm.drafts[context]; ok {
+		rm.draftStore.stage(lru.GetAll())
+	}
+	rm.draftStore.triggerSave()
 }
 
 func (rm *RequestManager) AddRequest(context string, req *http.Request) (string, error) {
@@ -101,6 +228,44 @@ func (rm *RequestManager) GetRequestDrafts(context string) []*RequestDraft {
 	return nil
 }
 
+// Makes the draft at the index (0 being the most recent one) the current draft
+func (rm *RequestManager) UseRequestDraft(context string, index int) (*RequestDraft, error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if lru, ok := rm.drafts[context]; ok {
+		if draft, _ := lru.GetAt(index); draft != nil {
+			rm.saveDrafts(context)
+			return draft, nil
+		}
+	}
+	return nil, fmt.Errorf("draft #%d not found", index+1)
+}
+
+// Persisted drafts are moved to the store's discarded drafts, see RestoreDiscardedDrafts
+func (rm *RequestManager) RemoveRequestDraft(context string, id string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	lru, ok := rm.drafts[context]
+	if !ok {
+		return
+	}
+
+	for _, draft := range lru.GetAll() {
+		if draft.GetId() != id {
+			continue
+		}
+
+		lru.Remove(id)
+		if rm.draftStore != nil && context == rm.draftCtx {
+			rm.draftStore.Discard(draft)
+			rm.saveDrafts(context)
+		}
+		return
+	}
+}
+
 func (rm *RequestManager) PeakRequestDraft(context string) *RequestDraft {
 	rm.mu.Lock()
 	defer rm.mu.Unlock()
@@ -133,48 +298,88 @@ func (rm *RequestManager) PeakTrackerRequest(context string) (*TrackerRequest, e
 	return tr, nil
 }
 
+func (rm *RequestManager) GetTrackerRequest(id string) (*TrackerRequest, error) {
+	tr, ok := rm.tracker.GetRequest(id)
+	if !ok {
+		return nil, fmt.Errorf("request id '%s' does not exist in tracker", id)
+	}
+	return tr, nil
+}
+
+// Looks up the tracker request a response (for instance a task's result) belongs to
+func (rm *RequestManager) FindTrackerRequest(resp *http.Response) (*TrackerRequest, error) {
+	if resp == nil {
+		return nil, errors.New("no response to look up")
+	}
+
+	tr, ok := rm.tracker.FindByResponse(resp)
+	if !ok {
+		return nil, errors.New("response wasn't tracked")
+	}
+	return tr, nil
+}
+
 func (rm *RequestManager) MakeRequest(req *http.Request) (string, <-chan Update, error) {
-	return rm.makeRequest("", req, false)
+	return rm.makeRequest("", req, requestOpts{})
 }
 
 func (rm *RequestManager) MakeRequestWithContext(
 	context string,
 	req *http.Request,
 ) (string, <-chan Update, error) {
-	return rm.makeRequest(context, req, true)
+	return rm.makeRequest(context, req, requestOpts{trackInContext: true, bufferBody: true})
+}
+
+// Tracked in context like any other request, but the response body is streamed to a file instead
+// of being buffered in memory.
+func (rm *RequestManager) MakeRequestToFile(
+	context string,
+	req *http.Request,
+	opts DownloadOpts,
+) (string, <-chan Update, error) {
+	if opts.Path == "" {
+		return "", nil, errors.New("download path not specified")
+	}
+	return rm.makeRequest(
+		context,
+		req,
+		// Error responses aren't written to the file, they're buffered instead
+		requestOpts{trackInContext: true, bufferBody: true, download: &opts},
+	)
 }
 
 func (rm *RequestManager) makeRequest(
 	context string,
 	req *http.Request,
-	trackInContext bool,
+	opts requestOpts,
 ) (string, <-chan Update, error) {
 	reqID := uuid.New().String()
-	rm.copyCommonHeaders(req)
-
 	trackerReq := rm.createTrackerRequest(reqID, req)
+	rm.copyCommonHeaders(req)
 	rm.tracker.AddRequest(trackerReq)
 
-	if trackInContext {
+	if opts.trackInContext {
 		// Discard old buffered response if it exists
 		rm.discardOldBufferedResponse(context)
 		rm.addToContext(context, trackerReq.Request)
 	}
 
 	updateChan := make(chan Update)
-	go rm.executeRequest(trackerReq, req, reqID, updateChan, trackInContext)
+	go rm.executeRequest(trackerReq, req, reqID, updateChan, opts)
 
 	return reqID, updateChan, nil
 }
 
+// Must be called before the common headers are copied onto the request
 func (rm *RequestManager) createTrackerRequest(reqID string, req *http.Request) *TrackerRequest {
 	return &TrackerRequest{
 		Request: &Request{
 			ID:          reqID,
 			HttpRequest: req,
 		},
-		Status: StatusProcessing,
-		Done:   make(Done),
+		RequestHeaders: req.Header.Clone(),
+		Status:         StatusProcessing,
+		Done:           make(Done),
 	}
 }
 
@@ -216,15 +421,81 @@ func (rm *RequestManager) executeRequest(
 	req *http.Request,
 	reqID string,
 	updateChan chan Update,
-	bufferBody bool,
+	opts requestOpts,
 ) {
 	defer close(trackerReq.Done)
 
-	start := time.Now()
-	resp, err := rm.client.Do(req)
+	resp, err := rm.roundTrip(trackerReq, req, opts)
+	if opts.trackInContext {
+		rm.addToHistory(trackerReq, resp, err)
+	}
 
-	// Buffer response body ONLY for tracked context requests
-	if bufferBody && err == nil && resp != nil && resp.Body != nil {
+	update := Update{
+		reqId: reqID,
+		resp:  resp,
+		err:   err,
+	}
+
+	rm.lastReceivedResp = resp
+	rm.tracker.applyUpdate(update)
+	updateChan <- update
+}
+
+// Sends the request synchronously without tracking it, the response body is buffered. The
+// returned tracker request can be tracked later on using Track.
+func (rm *RequestManager) Send(req *http.Request) (*http.Response, *TrackerRequest, error) {
+	trackerReq := rm.createTrackerRequest(uuid.New().String(), req)
+	rm.copyCommonHeaders(req)
+	defer close(trackerReq.Done)
+
+	resp, err := rm.roundTrip(trackerReq, req, requestOpts{bufferBody: true})
+	if resp != nil {
+		trackerReq.ResponseHeaders = resp.Header
+		trackerReq.StatusCode = resp.StatusCode
+		trackerReq.FullResponse = resp
+	}
+	return resp, trackerReq, err
+}
+
+// Tracks an already completed request (see Send) in the context, like any other request
+func (rm *RequestManager) Track(context string, trackerReq *TrackerRequest) {
+	rm.tracker.AddRequest(trackerReq)
+	rm.discardOldBufferedResponse(context)
+	rm.addToContext(context, trackerReq.Request)
+	rm.addToHistory(trackerReq, trackerReq.FullResponse, nil)
+
+	if trackerReq.FullResponse != nil {
+		rm.lastReceivedResp = trackerReq.FullResponse
+	}
+}
+
+func (rm *RequestManager) addToHistory(
+	trackerReq *TrackerRequest,
+	resp *http.Response,
+	err error,
+) {
+	if history := rm.History(); history != nil {
+		history.Add(newHistoryEntry(trackerReq, resp, err))
+	}
+}
+
+func (rm *RequestManager) roundTrip(
+	trackerReq *TrackerRequest,
+	req *http.Request,
+	opts requestOpts,
+) (*http.Response, error) {
+	var offset int64
+	if opts.download != nil {
+		offset = prepareDownload(req, opts.download)
+	}
+
+	resp, recorder, err := rm.doWithRetries(trackerReq, req)
+
+	if opts.download != nil && err == nil && isDownloadable(resp, opts.download) {
+		err = streamToFile(resp, opts.download, offset)
+		trackerReq.ResponseBody, resp.Body = http.NoBody, http.NoBody
+	} else if opts.bufferBody && err == nil && resp != nil && resp.Body != nil {
+		// Buffer response body ONLY for tracked context requests
 		bodyBytes, readErr := io.ReadAll(resp.Body)
 		resp.Body.Close()
 
@@ -236,18 +507,100 @@ func (rm *RequestManager) executeRequest(
 		}
 	}
 
+	// Timing is wrapped up once the body's been read (or streamed), so that it covers the download
+	if recorder != nil {
+		trackerReq.Timing = recorder.finish(time.Now())
+	}
+
 	trackerReq.Status = rm.determineStatus(err)
-	trackerReq.RequestTime = time.Since(start)
+	trackerReq.RequestTime = trackerReq.Timing.Total
+	return resp, err
+}
 
-	update := Update{
-		reqId: reqID,
-		resp:  resp,
-		err:   err,
+// Sends the request as many times as the retry policy allows, each attempt is recorded on the
+// tracker request. Redirects reflect the last attempt only, its timing recorder is returned to be
+// finished once the response body has been read.
+func (rm *RequestManager) doWithRetries(
+	trackerReq *TrackerRequest,
+	req *http.Request,
+) (*http.Response, *timingRecorder, error) {
+	ctx := req.Context()
+	policy, err := rm.retryPolicy(ctx)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	rm.lastReceivedResp = resp
-	rm.tracker.updates <- update
-	updateChan <- update
+	var (
+		resp     *http.Response
+		recorder *timingRecorder
+		body     = req.Body
+	)
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		redirects := &redirectRecorder{}
+		recorder = newTimingRecorder(start)
+		attemptCtx := httptrace.WithClientTrace(ctx, recorder.trace())
+		attemptCtx = withRedirectRecorder(attemptCtx, redirects)
+
+		// Every attempt gets its own copy of the headers, the client adds the jar's cookies to them
+		attemptReq := req.Clone(attemptCtx)
+		attemptReq.Body = body
+		resp, err = rm.Client().Do(attemptReq)
+
+		trackerReq.Redirects = redirects.list()
+		record := Attempt{Err: err, Duration: time.Since(start)}
+		if resp != nil {
+			record.StatusCode = resp.StatusCode
+		}
+
+		if attempt >= policy.MaxAttempts || !policy.isRetryable(resp, err) {
+			trackerReq.Attempts = append(trackerReq.Attempts, record)
+			break
+		}
+
+		delay, ok := policy.delay(attempt, resp)
+		switch {
+		case !policy.allowsMethod(req):
+			record.NotRetried = fmt.Sprintf("%s isn't idempotent", req.Method)
+		case !ok:
+			record.NotRetried = "Retry-After exceeds the max delay"
+		case !rewindBody(attemptReq):
+			record.NotRetried = "body not replayable"
+		}
+		body = attemptReq.Body
+
+		if record.NotRetried != "" {
+			trackerReq.Attempts = append(trackerReq.Attempts, record)
+			break
+		}
+
+		record.Delay = delay
+		trackerReq.Attempts = append(trackerReq.Attempts, record)
+		discardBody(resp)
+
+		if sleepErr := sleepCtx(ctx, delay); sleepErr != nil {
+			return nil, nil, sleepErr
+		}
+	}
+
+	if err != nil && len(trackerReq.Attempts) > 1 {
+		err = fmt.Errorf("gave up after %d attempts: %w", len(trackerReq.Attempts), err)
+	}
+	return resp, recorder, err
+}
+
+// Policies set on the request's context take precedence over the configured ones
+func (rm *RequestManager) retryPolicy(ctx context.Context) (*RetryPolicy, error) {
+	if p, ok := retryPolicyFrom(ctx); ok {
+		return p, nil
+	}
+
+	appCfg := config.GetAppCfg()
+	if appCfg == nil {
+		return NoRetry, nil
+	}
+	return NewRetryPolicy(appCfg.GetRetryCfg())
 }
 
 func (rm *RequestManager) bufferResponseBody(resp *http.Response) error {
@@ -98,3 +98,30 @@ func TestCycleRequestsWithRoundRobin(t *testing.T) {
 		}
 	}
 }
+
+func TestRequestDraftSwitching(t *testing.T) {
+	mgr := NewRequestManager(NewRequestTracker(), nil, nil)
+	first := NewRequestDraft().SetUrl("https://example.com/a").SetHeader("X-Orig", "yes")
+	second := NewRequestDraft().SetUrl("https://example.com/b")
+	mgr.AddDraftRequest("ctx", first)
+	mgr.AddDraftRequest("ctx", second)
+
+	draft, err := mgr.UseRequestDraft("ctx", 1)
+	if err != nil || draft != first || mgr.PeakRequestDraft("ctx") != first {
+		t.Fatalf("expected the first draft to become current, got %v", err)
+	}
+
+	clone := first.Clone().SetLabel("copy")
+	clone.Headers["X-Clone"] = "yes"
+	if clone.GetId() == first.GetId() || first.Headers["X-Clone"] != "" {
+		t.Errorf("expected the clone to be independent of the original")
+	}
+
+	mgr.RemoveRequestDraft("ctx", first.GetId())
+	if mgr.PeakRequestDraft("ctx") != second {
+		t.Errorf("expected the remaining draft to become current")
+	}
+	if _, err := mgr.UseRequestDraft("ctx", 1); err == nil {
+		t.Errorf("expected an error for a missing draft")
+	}
+}
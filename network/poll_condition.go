@@ -2,7 +2,6 @@ package network
 
 import (
 	"encoding/json"
-	"encoding/xml"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -13,10 +12,39 @@ import (
 	"github.com/shubm-quodes/repl-reqs/util"
 )
 
+// $<kind>[.path]<operator>[value], for instance '$body.job.progress>=100' or '$header.ETag exists'
 var PollConditionRegex = regexp.MustCompile(
-	`^\$(header|body|status)([^=]*)=(.*)$`,
+	`^\$(header|body|status|length)([^\s=!<>~]*)\s*(!=|>=|<=|~=|=|>|<|\bexists\b|\bcontains\b)\s*(.*)$`,
 )
 
+type Operator string
+
+const (
+	OpEq       Operator = "="
+	OpNotEq    Operator = "!="
+	OpGt       Operator = ">"
+	OpLt       Operator = "<"
+	OpGte      Operator = ">="
+	OpLte      Operator = "<="
+	OpMatches  Operator = "~="
+	OpExists   Operator = "exists"
+	OpContains Operator = "contains"
+)
+
+type LogicalOp string
+
+const (
+	LogicalAnd LogicalOp = "&&"
+	LogicalOr  LogicalOp = "||"
+)
+
+// Logical operators only count between conditions, that is when surrounded by whitespace, so that
+// values like '$body.msg~=a||b' are left alone
+var logicalOpRegexes = map[LogicalOp]*regexp.Regexp{
+	LogicalAnd: regexp.MustCompile(`(?:^|\s+)&&(?:\s+|$)`),
+	LogicalOr:  regexp.MustCompile(`(?:^|\s+)\|\|(?:\s+|$)`),
+}
+
 type Condition interface {
 	Evaluate(resp *http.Response) bool
 	IsMandatory() bool
@@ -24,109 +52,353 @@ type Condition interface {
 
 type BaseCondition struct{}
 
+// Conditions with an empty operator compare for equality
 type StatusCondition struct {
 	*BaseCondition
+	Op       Operator
 	Expected string
 }
 
 type HeaderCondition struct {
 	*BaseCondition
 	Key      string
+	Op       Operator
 	Expected string
 }
 
+// An empty path refers to the whole body
 type BodyCondition struct {
 	*BaseCondition
 	Path     string
+	Op       Operator
+	Expected string
+}
+
+// Compares the number of items of the array (or keys of the object, or characters of the string) at
+// the body path, an empty path refers to the whole body
+type LengthCondition struct {
+	*BaseCondition
+	Path     string
+	Op       Operator
 	Expected string
 }
 
+// Combines conditions with either '&&' or '||'
+type LogicalCondition struct {
+	*BaseCondition
+	Op         LogicalOp
+	Conditions []Condition
+}
+
+// Parses an expression of conditions combined with '&&' and '||', '&&' binds tighter than '||'.
+// For instance '$body.job.progress>=100 && $status=200 || $status=204', operators have to be
+// surrounded by spaces.
+func NewConditionExpr(expr string) (Condition, error) {
+	return parseLogical(expr, LogicalOr)
+}
+
+func parseLogical(expr string, op LogicalOp) (Condition, error) {
+	parts := splitLogical(expr, op)
+	conditions := make([]Condition, 0, len(parts))
+
+	for _, part := range parts {
+		var (
+			c   Condition
+			err error
+		)
+
+		if op == LogicalOr {
+			c, err = parseLogical(part, LogicalAnd)
+		} else {
+			c, err = NewCondition(strings.TrimSpace(part))
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, c)
+	}
+
+	if len(conditions) == 1 {
+		return conditions[0], nil
+	}
+	return &LogicalCondition{Op: op, Conditions: conditions}, nil
+}
+
+func splitLogical(expr string, op LogicalOp) []string {
+	return logicalOpRegexes[op].Split(expr, -1)
+}
+
 func NewCondition(raw string) (Condition, error) {
 	matches := PollConditionRegex.FindStringSubmatch(raw)
 	if matches == nil {
-		return nil, fmt.Errorf("invalid condition format")
+		return nil, fmt.Errorf("invalid condition format '%s'", raw)
 	}
 
-	kind, path, value := matches[1], matches[2], matches[3]
+	kind, path, op, value := matches[1], matches[2], Operator(matches[3]), matches[4]
+	if err := validateOperand(op, value); err != nil {
+		return nil, fmt.Errorf("invalid condition '%s': %w", raw, err)
+	}
 
-	if len(path) < 2 {
+	if path != "" && (len(path) < 2 || path[0] != '.') {
 		return nil, fmt.Errorf("invalid path '%s' for condition", path)
 	}
-
-	path = path[1:]
+	path = strings.TrimPrefix(path, ".")
 
 	switch kind {
 	case "status":
-		return &StatusCondition{Expected: value}, nil
+		if path != "" {
+			return nil, fmt.Errorf("status conditions don't take a path, got '%s'", path)
+		}
+		return &StatusCondition{Op: op, Expected: value}, nil
 	case "header":
-		return &HeaderCondition{Key: path, Expected: value}, nil
+		if path == "" {
+			return nil, fmt.Errorf("please specify the header, for instance '$header.ETag'")
+		}
+		return &HeaderCondition{Key: path, Op: op, Expected: value}, nil
 	case "body":
-		return &BodyCondition{Path: path, Expected: value}, nil
+		return &BodyCondition{Path: path, Op: op, Expected: value}, nil
+	case "length":
+		if _, err := strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("invalid condition '%s': lengths are compared to numbers", raw)
+		}
+		return &LengthCondition{Path: path, Op: op, Expected: value}, nil
 	default:
 		return nil, fmt.Errorf("unknown condition type: %s", kind)
 	}
 }
 
+func validateOperand(op Operator, value string) error {
+	switch op {
+	case OpExists:
+		if value != "" {
+			return fmt.Errorf("'%s' doesn't take a value", op)
+		}
+	case OpContains:
+		if value == "" {
+			return fmt.Errorf("'%s' requires a value", op)
+		}
+	case OpGt, OpLt, OpGte, OpLte:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("'%s' requires a number, got '%s'", op, value)
+		}
+	case OpMatches:
+		if _, err := regexp.Compile(value); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	return nil
+}
+
 func (b *BaseCondition) IsMandatory() bool {
 	return false
 }
 
 func (c *StatusCondition) Evaluate(resp *http.Response) bool {
-	return strconv.Itoa(resp.StatusCode) == c.Expected
+	actual, found := c.actual(resp)
+	return compare(c.Op, c.Expected, actual, found)
+}
+
+func (c *StatusCondition) actual(resp *http.Response) (any, bool) {
+	return resp.StatusCode, true
 }
 
 func (c *HeaderCondition) Evaluate(resp *http.Response) bool {
+	actual, found := c.actual(resp)
+	return compare(c.Op, c.Expected, actual, found)
+}
+
+func (c *HeaderCondition) actual(resp *http.Response) (any, bool) {
 	key := strings.TrimPrefix(c.Key, ".")
-	return resp.Header.Get(key) == c.Expected
+	vals := resp.Header.Values(key)
+	if len(vals) == 0 {
+		return "", false
+	}
+	return vals[0], true
+}
+
+func (c *LogicalCondition) Evaluate(resp *http.Response) bool {
+	return c.evaluateWith(func(cond Condition) bool {
+		return cond.Evaluate(resp)
+	})
+}
+
+func (c *LogicalCondition) evaluateWith(check func(Condition) bool) bool {
+	for _, cond := range c.Conditions {
+		satisfied := check(cond)
+		if c.Op == LogicalOr && satisfied {
+			return true
+		} else if c.Op == LogicalAnd && !satisfied {
+			return false
+		}
+	}
+	return c.Op == LogicalAnd
 }
 
 /*
-* Unmarshal body as per the content type.
-* currently xml & json are supported.. what else???
+* Unmarshal body as per the content type (see DecodeBody).
 * Extract the value specified by the condition.
 * As per the type of the value, perform asertion and compare the val.
+* Plain text bodies can only be matched as a whole, using an empty path.
  */
 func (c *BodyCondition) Evaluate(resp *http.Response) bool {
-	body, err := c.getUnmarshalledBody(resp)
+	raw, err := util.ReadAndResetIoCloser(&resp.Body)
 	if err != nil {
 		return false
 	}
 
-	return c.evaluate(body)
+	body, err := c.getUnmarshalledBody(resp)
+	if err != nil && c.Path != "" {
+		return false
+	}
+
+	return c.evaluate(body, raw)
 }
 
-func (c *BodyCondition) evaluate(body any) bool {
+func (c *BodyCondition) evaluate(body any, raw []byte) bool {
+	actual, found := c.extract(body, raw)
+	return compare(c.Op, c.Expected, actual, found)
+}
+
+func (c *BodyCondition) actual(resp *http.Response) (any, bool) {
+	raw, err := util.ReadAndResetIoCloser(&resp.Body)
+	if err != nil {
+		return nil, false
+	}
+
+	body, err := c.getUnmarshalledBody(resp)
+	if err != nil && c.Path != "" {
+		return nil, false
+	}
+	return c.extract(body, raw)
+}
+
+// The value at the condition's path, undecodable bodies can only be extracted as a whole (as text)
+func (c *BodyCondition) extract(body any, raw []byte) (any, bool) {
+	if c.Path == "" {
+		if body == nil {
+			return string(raw), len(raw) > 0
+		}
+		return body, true
+	}
+
 	val, err := util.ExtractVal(body, c.Path)
 	if err != nil { // We never know.. maybe the property will appear in upcoming responses..
 		log.Debug("failed to extract value from response body for condition's path '%s'", c.Path)
-		return false
+		return nil, false
 	}
+	return val, true
+}
 
-	return util.IsStrEqualToAny(c.Expected, val)
+func (c *LengthCondition) Evaluate(resp *http.Response) bool {
+	actual, found := c.actual(resp)
+	return compare(c.Op, c.Expected, actual, found)
 }
 
-func (c *BodyCondition) getUnmarshalledBody(resp *http.Response) (any, error) {
-	cType := resp.Header.Get("Content-Type")
+// Values without a length (numbers, booleans, null) are treated as missing
+func (c *LengthCondition) actual(resp *http.Response) (any, bool) {
+	val, found := (&BodyCondition{Path: c.Path}).actual(resp)
+	if !found {
+		return nil, false
+	}
+
+	switch v := val.(type) {
+	case []any:
+		return len(v), true
+	case map[string]any:
+		return len(v), true
+	case string:
+		return len([]rune(v)), true
+	default:
+		return nil, false
+	}
+}
 
+// Decodes the body as per it's content type, see DecodeBody
+func (c *BodyCondition) getUnmarshalledBody(resp *http.Response) (any, error) {
 	bodyBytes, err := util.ReadAndResetIoCloser(&resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	var body any
+	return DecodeBody(resp.Header.Get("Content-Type"), bodyBytes)
+}
 
-	switch {
-	case strings.Contains(cType, "application/json"):
-		err = json.Unmarshal(bodyBytes, &body)
-	case strings.Contains(cType, "xml"):
-		err = xml.Unmarshal(bodyBytes, &body)
+// Compares plain values the same way conditions do, as in '5 >= 3' or 'abc ~= ^a'
+func CompareValues(op Operator, actual, expected string) bool {
+	return compare(op, expected, actual, true)
+}
+
+// Compares the actual value against the expected one, found reports whether the value exists at
+// all. Missing values only satisfy '!='.
+func compare(op Operator, expected string, actual any, found bool) bool {
+	if !found {
+		return op == OpNotEq
+	}
+
+	switch op {
+	case "", OpEq:
+		return util.IsStrEqualToAny(expected, actual)
+	case OpNotEq:
+		return !util.IsStrEqualToAny(expected, actual)
+	case OpExists:
+		return true
+	case OpGt, OpLt, OpGte, OpLte:
+		return compareNums(op, expected, actual)
+	case OpMatches:
+		matched, err := regexp.MatchString(expected, stringify(actual))
+		return err == nil && matched
+	case OpContains:
+		if items, ok := actual.([]any); ok {
+			for _, item := range items {
+				if util.IsStrEqualToAny(expected, item) {
+					return true
+				}
+			}
+			return false
+		}
+		return strings.Contains(stringify(actual), expected)
 	default:
-		return nil, fmt.Errorf("unsupported Content-Type: %s", cType)
+		return false
 	}
+}
 
+func compareNums(op Operator, expected string, actual any) bool {
+	want, err := strconv.ParseFloat(expected, 64)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode %s: %w", cType, err)
+		return false
+	}
+
+	got, err := strconv.ParseFloat(stringify(actual), 64)
+	if err != nil {
+		return false
 	}
 
-	return body, nil
+	switch op {
+	case OpGt:
+		return got > want
+	case OpLt:
+		return got < want
+	case OpGte:
+		return got >= want
+	default:
+		return got <= want
+	}
+}
+
+// Objects and arrays are compared by their json representation
+func stringify(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]any, []any:
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(val)
 }
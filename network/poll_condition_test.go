@@ -114,3 +114,130 @@ func TestBodyCondition_Evaluate(t *testing.T) {
 		}
 	})
 }
+
+func TestConditionOperators(t *testing.T) {
+	body := `{"job": {"progress": 75, "state": "running", "tags": ["a", "b"]}, "done": null}`
+	headers := map[string]string{"ETag": "v42"}
+
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{"$status!=404", true},
+		{"$status>=200", true},
+		{"$status<300", true},
+		{"$status>200", false},
+		{"$body.job.progress>=100", false},
+		{"$body.job.progress<=75", true},
+		{"$body.job.state~=^run", true},
+		{"$body.job.state~=^done$", false},
+		{"$body.job.tags contains b", true},
+		{"$body.job.tags contains c", false},
+		{"$body contains running", true},
+		{"$body.job.eta exists", false},
+		{"$body.job exists", true},
+		{"$body.job.eta!=10", true},
+		{"$header.ETag exists", true},
+		{"$header.X-Missing exists", false},
+		{"$header.ETag~=v[0-9]+", true},
+		{"$header.ETag = v42", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			cond, err := NewCondition(tt.raw)
+			if err != nil {
+				t.Fatalf("NewCondition() error = %v", err)
+			}
+
+			resp := mockResponse(200, body, "application/json", headers)
+			if got := cond.Evaluate(resp); got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidConditions(t *testing.T) {
+	tests := []string{
+		"$status.code=200",
+		"$header=x",
+		"$body.progress>=high",
+		"$body.state~=[",
+		"$header.ETag exists v1",
+		"$body.tags contains",
+	}
+
+	for _, raw := range tests {
+		t.Run(raw, func(t *testing.T) {
+			if _, err := NewCondition(raw); err == nil {
+				t.Errorf("expected an error for '%s'", raw)
+			}
+		})
+	}
+}
+
+func TestNewConditionExpr(t *testing.T) {
+	body := `{"job": {"progress": 100}, "op": "a||b", "q": "x&&y"}`
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"$body.job.progress>=100 && $status=200", true},
+		{"$body.job.progress>=100 && $status=201", false},
+		{"$status=201 || $status=200", true},
+		{"$status=201 || $status=202", false},
+		{"$status=201 && $status=200 || $body.job.progress=100", true},
+		{"$status=200 || $status=201 && $body.job.progress=1", true},
+		{"$body.op=a||b", true},
+		{"$body.op=a||b && $status=200", true},
+		{"$body.op=a || $status=201", false},
+		{"$body.q contains x&&y", true},
+		{"$body.q=x&&y && $status=201", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			cond, err := NewConditionExpr(tt.expr)
+			if err != nil {
+				t.Fatalf("NewConditionExpr() error = %v", err)
+			}
+
+			resp := mockResponse(200, body, "application/json", nil)
+			if got := cond.Evaluate(resp); got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewConditionExpr("$status=200 && "); err == nil {
+		t.Errorf("expected an error for a dangling '&&'")
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	tests := []struct {
+		actual   string
+		op       Operator
+		expected string
+		want     bool
+	}{
+		{"admin", OpEq, "admin", true},
+		{"admin", OpNotEq, "admin", false},
+		{"10", OpGt, "9", true},
+		{"10", OpLte, "9", false},
+		{"abc", OpGt, "1", false},
+		{"v1.2.3", OpMatches, `^v1\.`, true},
+		{`["a","b"]`, OpContains, "b", true},
+		{"true", OpEq, "true", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.actual+string(tt.op)+tt.expected, func(t *testing.T) {
+			if got := CompareValues(tt.op, tt.actual, tt.expected); got != tt.want {
+				t.Errorf("CompareValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,67 +1,243 @@
 package network
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
+
+	"github.com/shubm-quodes/repl-reqs/util"
 )
 
-// Poller keeps on polling upto 100 times until all mandatory conditions are satisfied
+const (
+	DefaultPollInterval    = 500 * time.Millisecond
+	DefaultPollMaxAttempts = 100
+
+	maxPollInterval = time.Minute
+)
+
+// Attempts after the first one can't be made unless the request's body can be re-sent
+var errBodyNotRewindable = errors.New("the request's body can't be re-sent")
+
+type Backoff string
+
+const (
+	BackoffNone   Backoff = ""
+	BackoffLinear Backoff = "linear"
+	BackoffExp    Backoff = "exp"
+)
+
+// Poller keeps on polling until all conditions are satisfied, it gives up once the attempts are
+// exhausted or the timeout (if any) elapses.
 type Poller struct {
-	req        *http.Request
-	conditions []Condition
-	delay      int
-	maxRetries int
+	req         *http.Request
+	conditions  []Condition
+	interval    time.Duration
+	maxAttempts int
+	timeout     time.Duration
+	backoff     Backoff
+	onAttempt   func(PollAttempt)
+	send        func(*http.Request) (*http.Response, error)
+}
+
+// Outcome of a single polling attempt, reported through the OnAttempt callback
+type PollAttempt struct {
+	Number      int
+	MaxAttempts int // Zero when attempts aren't limited
+	StatusCode  int
+	Err         error
+	Values      []string // Values the conditions were evaluated against, 'path=value'
+	NextIn      time.Duration
 }
 
 type result struct {
 	resp *http.Response
 	body any
+	raw  []byte
 }
 
 func NewPoller(req *http.Request, c ...Condition) *Poller {
 	return &Poller{
-		req:        req,
-		conditions: c,
-		maxRetries: 100, // Default can be overriden
-		delay:      500, // Default can be overriden
+		req:         req,
+		conditions:  c,
+		maxAttempts: DefaultPollMaxAttempts, // Default can be overriden
+		interval:    DefaultPollInterval,    // Default can be overriden
 	}
 }
 
-func (p *Poller) SetDelay(d int) {
-	p.delay = d
+func (p *Poller) SetInterval(d time.Duration) {
+	p.interval = d
+}
+
+// Zero means no limit, polling is then bounded by the timeout (or the request's context) alone
+func (p *Poller) SetMaxAttempts(m int) {
+	p.maxAttempts = m
+}
+
+// Overall deadline for polling, zero means no deadline
+func (p *Poller) SetTimeout(d time.Duration) {
+	p.timeout = d
+}
+
+func (p *Poller) SetBackoff(b Backoff) {
+	p.backoff = b
 }
 
-func (p *Poller) SetMaxRetries(m int) {
-	p.maxRetries = m
+func (p *Poller) OnAttempt(fn func(PollAttempt)) {
+	p.onAttempt = fn
 }
 
+// Attempts are sent through the sender, for instance to track them. It's required, there's no
+// default client to fall back on as it'd skip the transport settings, cookies & redirect policy.
+func (p *Poller) SetSender(send func(*http.Request) (*http.Response, error)) {
+	p.send = send
+}
+
+func IsValidBackoff(b Backoff) bool {
+	switch b {
+	case BackoffNone, BackoffLinear, BackoffExp:
+		return true
+	default:
+		return false
+	}
+}
+
+// Polling stops as soon as the request's context is cancelled or the timeout elapses
 func (p *Poller) Poll() (*http.Response, error) {
-	for i := 0; i < p.maxRetries; i++ {
-		res, err := p.executeAttempt()
+	if p.send == nil {
+		return nil, errors.New("poller has no sender to send attempts with")
+	}
+
+	var (
+		parent = p.req.Context()
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+
+	if p.timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, p.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(parent)
+	}
+	defer cancel()
+
+	req := p.req.WithContext(ctx)
+	for i := 1; p.maxAttempts == 0 || i <= p.maxAttempts; i++ {
+		res, err := p.executeAttempt(req, i == 1)
+		if ctx.Err() != nil {
+			return nil, p.ctxErr(parent, ctx, i)
+		}
+
+		if errors.Is(err, errBodyNotRewindable) {
+			p.report(i, res, err, 0)
+			return nil, fmt.Errorf("polling failed: %w", err)
+		}
+
 		if err == nil && p.evaluateAll(res) {
+			p.report(i, res, err, 0)
 			return res.resp, nil // Success!
 		}
 
-		time.Sleep(time.Duration(p.delay) * time.Millisecond)
+		if i == p.maxAttempts {
+			p.report(i, res, err, 0)
+			break
+		}
+
+		delay := p.delay(i)
+		p.report(i, res, err, delay)
+
+		select {
+		case <-ctx.Done():
+			return nil, p.ctxErr(parent, ctx, i)
+		case <-time.After(delay):
+		}
+	}
+
+	return nil, fmt.Errorf("polling failed: conditions not met after %d attempts", p.maxAttempts)
+}
+
+func (p *Poller) ctxErr(parent, ctx context.Context, attempts int) error {
+	if parent.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf(
+			"polling timed out after %s: conditions not met after %d attempts",
+			p.timeout,
+			attempts,
+		)
+	}
+	return ctx.Err()
+}
+
+// Delay after the given attempt (starting at 1) as per the backoff strategy, backoffs don't grow
+// beyond a minute (or the interval, if it's longer than that). Attempts are never sent back to back,
+// intervals that aren't positive fall back to the default one.
+func (p *Poller) delay(attempt int) time.Duration {
+	interval := p.interval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	var d time.Duration
+	switch p.backoff {
+	case BackoffLinear:
+		d = interval * time.Duration(attempt)
+	case BackoffExp:
+		d = interval << min(attempt-1, 30)
+	default:
+		d = interval
+	}
+
+	if limit := max(interval, maxPollInterval); d <= 0 || d > limit {
+		return limit
+	}
+	return d
+}
+
+func (p *Poller) report(attempt int, res *result, err error, nextIn time.Duration) {
+	if p.onAttempt == nil {
+		return
 	}
 
-	return nil, fmt.Errorf("polling failed: conditions not met after %d retries", p.maxRetries)
+	a := PollAttempt{
+		Number:      attempt,
+		MaxAttempts: p.maxAttempts,
+		Err:         err,
+		NextIn:      nextIn,
+	}
+
+	if res != nil {
+		a.StatusCode = res.resp.StatusCode
+		for _, cond := range p.conditions {
+			a.Values = append(a.Values, extractedValues(cond, res)...)
+		}
+	}
+	p.onAttempt(a)
 }
 
 // executeAttempt handles the HTTP roundtrip and one-time body parsing
-func (p *Poller) executeAttempt() (*result, error) {
-	client := &http.Client{Timeout: time.Duration(p.delay) * time.Millisecond}
-	resp, err := client.Do(p.req)
+func (p *Poller) executeAttempt(req *http.Request, first bool) (*result, error) {
+	// Each attempt gets a request of it's own, as attempts might be tracked
+	req = req.Clone(req.Context())
+	// Bodies are rewound for the attempts that follow, the first one sends it as it is
+	if !first && !rewindBody(req) {
+		return nil, errBodyNotRewindable
+	}
+
+	resp, err := p.send(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	raw, err := util.ReadAndResetIoCloser(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
 	helper := &BodyCondition{}
 	body, _ := helper.getUnmarshalledBody(resp)
 
-	return &result{resp: resp, body: body}, nil
+	return &result{resp: resp, body: body, raw: raw}, nil
 }
 
 func (p *Poller) evaluateAll(res *result) bool {
@@ -77,10 +253,41 @@ func (p *Poller) evaluateAll(res *result) bool {
 	return true
 }
 
+// Body conditions are checked against the body parsed once per attempt
 func (p *Poller) check(cond Condition, res *result) bool {
-	if bCond, ok := cond.(*BodyCondition); ok {
-		return bCond.evaluate(res.body)
+	switch c := cond.(type) {
+	case *BodyCondition:
+		return c.evaluate(res.body, res.raw)
+	case *LogicalCondition:
+		return c.evaluateWith(func(sub Condition) bool {
+			return p.check(sub, res)
+		})
+	default:
+		return cond.Evaluate(res.resp)
 	}
+}
+
+func extractedValues(cond Condition, res *result) []string {
+	switch c := cond.(type) {
+	case *BodyCondition:
+		if c.Path == "" {
+			return nil
+		}
 
-	return cond.Evaluate(res.resp)
+		val, err := util.ExtractVal(res.body, c.Path)
+		if err != nil {
+			return []string{c.Path + "=<missing>"}
+		}
+		return []string{c.Path + "=" + util.GetTruncatedStrWithWidth(stringify(val), 40)}
+	case *HeaderCondition:
+		return []string{c.Key + "=" + res.resp.Header.Get(c.Key)}
+	case *LogicalCondition:
+		var vals []string
+		for _, sub := range c.Conditions {
+			vals = append(vals, extractedValues(sub, res)...)
+		}
+		return vals
+	default:
+		return nil
+	}
 }
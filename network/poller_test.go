@@ -0,0 +1,249 @@
+package network
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newProgressServer() *httptest.Server {
+	var progress atomic.Int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"job": {"progress": %d}}`, progress.Add(50))
+	}))
+}
+
+// Attempts are sent as they are, without a request manager
+func newTestPoller(req *http.Request, c ...Condition) *Poller {
+	p := NewPoller(req, c...)
+	p.SetSender(http.DefaultClient.Do)
+	return p
+}
+
+func TestPollWithoutSender(t *testing.T) {
+	cond, _ := NewCondition("$status=200")
+	p := NewPoller(createTestRequest(t, http.MethodGet, "http://localhost"), cond)
+	if _, err := p.Poll(); err == nil || !strings.Contains(err.Error(), "no sender") {
+		t.Errorf("expected polling without a sender to fail, got %v", err)
+	}
+}
+
+func TestPollUntilConditionsMet(t *testing.T) {
+	srv := newProgressServer()
+	defer srv.Close()
+
+	cond, err := NewConditionExpr("$body.job.progress>=100 && $status=200")
+	if err != nil {
+		t.Fatalf("failed to parse conditions: %v", err)
+	}
+
+	var attempts []PollAttempt
+	p := newTestPoller(createTestRequest(t, http.MethodGet, srv.URL), cond)
+	p.SetInterval(time.Millisecond)
+	p.OnAttempt(func(a PollAttempt) { attempts = append(attempts, a) })
+
+	resp, err := p.Poll()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	if len(attempts) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(attempts))
+	}
+	if got := strings.Join(attempts[0].Values, ","); got != "job.progress=50" {
+		t.Errorf("expected the extracted value to be reported, got %q", got)
+	}
+	if attempts[0].NextIn != time.Millisecond || attempts[1].NextIn != 0 {
+		t.Errorf("unexpected delays %v, %v", attempts[0].NextIn, attempts[1].NextIn)
+	}
+}
+
+func TestPollGivesUp(t *testing.T) {
+	srv := newProgressServer()
+	defer srv.Close()
+
+	cond, _ := NewCondition("$body.job.progress<0")
+
+	t.Run("max attempts", func(t *testing.T) {
+		p := newTestPoller(createTestRequest(t, http.MethodGet, srv.URL), cond)
+		p.SetInterval(time.Millisecond)
+		p.SetMaxAttempts(3)
+
+		if _, err := p.Poll(); err == nil || !strings.Contains(err.Error(), "3 attempts") {
+			t.Errorf("expected polling to fail after 3 attempts, got %v", err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		p := newTestPoller(createTestRequest(t, http.MethodGet, srv.URL), cond)
+		p.SetInterval(20 * time.Millisecond)
+		p.SetTimeout(50 * time.Millisecond)
+
+		start := time.Now()
+		_, err := p.Poll()
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Errorf("expected a timeout, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("timeout wasn't enforced, polled for %s", elapsed)
+		}
+	})
+
+	t.Run("timeout without an attempt limit", func(t *testing.T) {
+		p := newTestPoller(createTestRequest(t, http.MethodGet, srv.URL), cond)
+		p.SetInterval(time.Microsecond)
+		p.SetMaxAttempts(0)
+		p.SetTimeout(500 * time.Millisecond)
+
+		var attempts int
+		p.OnAttempt(func(a PollAttempt) {
+			attempts = a.Number
+			if a.MaxAttempts != 0 {
+				t.Errorf("expected attempts not to be limited, got %d", a.MaxAttempts)
+			}
+		})
+
+		if _, err := p.Poll(); err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Errorf("expected the deadline to end polling, got %v", err)
+		}
+		if attempts <= DefaultPollMaxAttempts {
+			t.Errorf("expected more than %d attempts, got %d", DefaultPollMaxAttempts, attempts)
+		}
+	})
+}
+
+func TestPollWithBody(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"attempt": %d}`, len(bodies))
+	}))
+	defer srv.Close()
+
+	newReq := func(rewindable bool) *http.Request {
+		req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(`{"id": 1}`))
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		if !rewindable {
+			req.GetBody = nil
+		}
+		return req
+	}
+
+	t.Run("first attempt", func(t *testing.T) {
+		bodies = nil
+		cond, _ := NewCondition("$body.attempt=1")
+
+		if _, err := newTestPoller(newReq(false), cond).Poll(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(bodies) != 1 || bodies[0] != `{"id": 1}` {
+			t.Errorf("expected the body to be sent once, got %q", bodies)
+		}
+	})
+
+	t.Run("re-sent", func(t *testing.T) {
+		bodies = nil
+		cond, _ := NewCondition("$body.attempt=2")
+
+		p := newTestPoller(newReq(true), cond)
+		p.SetInterval(time.Millisecond)
+		if _, err := p.Poll(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(bodies) != 2 || bodies[1] != `{"id": 1}` {
+			t.Errorf("expected the body to be sent with each attempt, got %q", bodies)
+		}
+	})
+
+	t.Run("not rewindable", func(t *testing.T) {
+		bodies = nil
+		cond, _ := NewCondition("$body.attempt=2")
+
+		p := newTestPoller(newReq(false), cond)
+		p.SetInterval(time.Millisecond)
+		if _, err := p.Poll(); err == nil || !strings.Contains(err.Error(), "can't be re-sent") {
+			t.Errorf("expected the second attempt to fail, got %v", err)
+		}
+	})
+}
+
+func TestPollBackoff(t *testing.T) {
+	tests := []struct {
+		backoff Backoff
+		attempt int
+		want    time.Duration
+	}{
+		{BackoffNone, 5, time.Second},
+		{BackoffLinear, 1, time.Second},
+		{BackoffLinear, 3, 3 * time.Second},
+		{BackoffExp, 1, time.Second},
+		{BackoffExp, 4, 8 * time.Second},
+		{BackoffExp, 40, maxPollInterval},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.backoff, tt.attempt), func(t *testing.T) {
+			p := &Poller{interval: time.Second, backoff: tt.backoff}
+			if got := p.delay(tt.attempt); got != tt.want {
+				t.Errorf("delay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Attempts are never sent back to back
+	if got := (&Poller{}).delay(1); got != DefaultPollInterval {
+		t.Errorf("delay() without an interval = %v, want %v", got, DefaultPollInterval)
+	}
+}
+
+func TestPollThroughManager(t *testing.T) {
+	var sawHeader atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sawHeader.Store(r.Header.Get("X-Common") == "yes")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"state": "done"}`))
+	}))
+	defer srv.Close()
+
+	mgr := NewRequestManager(NewRequestTracker(), nil, http.Header{"X-Common": {"yes"}})
+	cond, _ := NewCondition("$body.state=done")
+
+	p := NewPoller(createTestRequest(t, http.MethodGet, srv.URL), cond)
+	p.SetSender(func(req *http.Request) (*http.Response, error) {
+		resp, trackerReq, err := mgr.Send(req)
+		mgr.Track("poll-ctx", trackerReq)
+		return resp, err
+	})
+
+	resp, err := p.Poll()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !sawHeader.Load() {
+		t.Errorf("expected common headers to be applied")
+	}
+
+	trackerReq, err := mgr.PeakTrackerRequest("poll-ctx")
+	if err != nil {
+		t.Fatalf("expected the attempt to be tracked: %v", err)
+	}
+	if trackerReq.FullResponse != resp || trackerReq.StatusCode != http.StatusOK {
+		t.Errorf("tracked request doesn't match the polled response")
+	}
+	if found, err := mgr.FindTrackerRequest(resp); err != nil || found != trackerReq {
+		t.Errorf("expected the response to be looked up, got %v", err)
+	}
+}
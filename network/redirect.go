@@ -0,0 +1,74 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// A redirect response that was followed on the way to the final response
+type RedirectHop struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Location   string
+	SetCookies []string
+}
+
+type redirectRecorderKey struct{}
+
+type redirectRecorder struct {
+	mu   sync.Mutex
+	hops []RedirectHop
+}
+
+func withRedirectRecorder(ctx context.Context, rec *redirectRecorder) context.Context {
+	return context.WithValue(ctx, redirectRecorderKey{}, rec)
+}
+
+func (rr *redirectRecorder) record(req *http.Request) {
+	resp := req.Response
+	if resp == nil || resp.Request == nil {
+		return
+	}
+
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	rr.hops = append(rr.hops, RedirectHop{
+		Method:     resp.Request.Method,
+		URL:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Location:   resp.Header.Get("Location"),
+		SetCookies: resp.Header.Values("Set-Cookie"),
+	})
+}
+
+func (rr *redirectRecorder) list() []RedirectHop {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	return append([]RedirectHop(nil), rr.hops...)
+}
+
+// Builds a http.Client.CheckRedirect func following at most maxRedirects redirects, with 0 the
+// redirect response itself is returned. Hops are recorded on the request's recorder.
+func newRedirectPolicy(maxRedirects int) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if maxRedirects == 0 {
+			return http.ErrUseLastResponse
+		}
+
+		// The hop exceeding the limit is recorded too, its Location is what the chain failed on
+		if rec, ok := req.Context().Value(redirectRecorderKey{}).(*redirectRecorder); ok {
+			rec.record(req)
+		}
+
+		if len(via) > maxRedirects {
+			return fmt.Errorf("stopped after %d redirects: %w", maxRedirects, ErrTooManyRedirects)
+		}
+		return nil
+	}
+}
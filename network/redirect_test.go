@@ -0,0 +1,113 @@
+package network
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shubm-quodes/repl-reqs/config"
+)
+
+func newRedirectingServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "state", Value: "xyz"})
+		http.Redirect(w, r, "/callback", http.StatusFound)
+	})
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/home", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/home", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("home"))
+	})
+	return httptest.NewServer(mux)
+}
+
+func sendWithRedirects(t *testing.T, redirects, url string) (*TrackerRequest, Update) {
+	client, err := NewHttpClient(config.TransportCfg{Redirects: redirects})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	mgr := NewRequestManager(NewRequestTracker(), client, nil)
+	id, updates, err := mgr.MakeRequest(createTestRequest(t, http.MethodGet, url))
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+
+	update := <-updates
+	trackerReq, err := mgr.GetTrackerRequest(id)
+	if err != nil {
+		t.Fatalf("tracker request not found: %v", err)
+	}
+	return trackerReq, update
+}
+
+func TestRedirectChainIsRecorded(t *testing.T) {
+	srv := newRedirectingServer()
+	defer srv.Close()
+
+	trackerReq, update := sendWithRedirects(t, config.RedirectsFollow, srv.URL+"/login")
+	if update.Err() != nil {
+		t.Fatalf("unexpected error: %v", update.Err())
+	}
+	if update.Resp().StatusCode != http.StatusOK {
+		t.Errorf("expected final status 200, got %d", update.Resp().StatusCode)
+	}
+
+	hops := trackerReq.Redirects
+	if len(hops) != 2 {
+		t.Fatalf("expected 2 hops, got %d", len(hops))
+	}
+
+	if hops[0].StatusCode != http.StatusFound || hops[0].Location != "/callback" {
+		t.Errorf("unexpected first hop: %+v", hops[0])
+	}
+	if len(hops[0].SetCookies) != 1 || hops[0].SetCookies[0] != "state=xyz" {
+		t.Errorf("expected the hop's Set-Cookie to be recorded, got %v", hops[0].SetCookies)
+	}
+	if hops[1].StatusCode != http.StatusMovedPermanently || hops[1].URL != srv.URL+"/callback" {
+		t.Errorf("unexpected second hop: %+v", hops[1])
+	}
+}
+
+func TestRedirectLimits(t *testing.T) {
+	srv := newRedirectingServer()
+	defer srv.Close()
+
+	t.Run("none", func(t *testing.T) {
+		trackerReq, update := sendWithRedirects(t, config.RedirectsNone, srv.URL+"/login")
+		if update.Err() != nil {
+			t.Fatalf("unexpected error: %v", update.Err())
+		}
+		if update.Resp().StatusCode != http.StatusFound {
+			t.Errorf("expected the redirect itself to be returned, got %d", update.Resp().StatusCode)
+		}
+		if len(trackerReq.Redirects) != 0 {
+			t.Errorf("expected no hops, got %d", len(trackerReq.Redirects))
+		}
+	})
+
+	t.Run("max", func(t *testing.T) {
+		trackerReq, update := sendWithRedirects(t, "1", srv.URL+"/login")
+		if !errors.Is(update.Err(), ErrTooManyRedirects) {
+			t.Errorf("expected ErrTooManyRedirects, got %v", update.Err())
+		}
+
+		// The hop exceeding the limit is recorded along with its Location
+		hops := trackerReq.Redirects
+		if len(hops) != 2 {
+			t.Fatalf("expected 2 hops, got %d", len(hops))
+		}
+		if hops[1].URL != srv.URL+"/callback" || hops[1].Location != "/home" {
+			t.Errorf("unexpected last hop: %+v", hops[1])
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := NewHttpClient(config.TransportCfg{Redirects: "some"}); err == nil {
+			t.Errorf("expected an error for invalid redirects setting")
+		}
+	})
+}
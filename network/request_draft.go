@@ -1,8 +1,11 @@
 package network
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"net/url"
 	"strings"
@@ -27,13 +30,19 @@ const (
 )
 
 type RequestDraft struct {
-	id          string
-	Url         string            `json:"url"         toml:"url"`
-	Method      HTTPMethod        `json:"method"      toml:"method"`
-	Headers     map[string]string `json:"headers"     toml:"headers"`
-	Cookies     map[string]string `json:"cookies"     toml:"cookies"`
-	QueryParams map[string]string `json:"queryParams" toml:"query_params"` // Different casing for TOML standard
-	Body        string            `json:"body"        toml:"body"`
+	id             string
+	Label          string            `json:"label,omitempty"          toml:"label"`
+	Url            string            `json:"url"                      toml:"url"`
+	Method         HTTPMethod        `json:"method"                   toml:"method"`
+	Headers        map[string]string `json:"headers"                  toml:"headers"`
+	Cookies        map[string]string `json:"cookies"                  toml:"cookies"`
+	QueryParams    map[string]string `json:"queryParams"              toml:"query_params"` // Different casing for TOML standard
+	Body           string            `json:"body"                     toml:"body"`
+	BodyKind       BodyKind          `json:"bodyKind,omitempty"       toml:"body_kind"`
+	Form           map[string]string `json:"form,omitempty"           toml:"form"`
+	Files          map[string]string `json:"files,omitempty"          toml:"files"`
+	BodyFile       string            `json:"bodyFile,omitempty"       toml:"body_file"`
+	ExpandBodyFile bool              `json:"expandBodyFile,omitempty" toml:"expand_body_file"`
 }
 
 type FuncQueryParamHandler func(key, val string)
@@ -48,6 +57,27 @@ func (rd *RequestDraft) GetId() string {
 	return rd.id
 }
 
+func (rd *RequestDraft) GetLabel() string {
+	return rd.Label
+}
+
+func (rd *RequestDraft) SetLabel(label string) *RequestDraft {
+	rd.Label = label
+	return rd
+}
+
+// Deep copy of the draft, with an id of it's own
+func (rd *RequestDraft) Clone() *RequestDraft {
+	clone := *rd
+	clone.id = uuid.NewString()
+	clone.Headers = maps.Clone(rd.Headers)
+	clone.Cookies = maps.Clone(rd.Cookies)
+	clone.QueryParams = maps.Clone(rd.QueryParams)
+	clone.Form = maps.Clone(rd.Form)
+	clone.Files = maps.Clone(rd.Files)
+	return &clone
+}
+
 func (rd *RequestDraft) GetUrl() string {
 	return rd.Url
 }
@@ -75,6 +105,20 @@ func (rd *RequestDraft) GetBody() string {
 	return rd.Body
 }
 
+// Unless explicitly set, the body kind is inferred from the presence of files & form fields
+func (rd *RequestDraft) GetBodyKind() BodyKind {
+	switch {
+	case rd.BodyKind != "":
+		return rd.BodyKind
+	case len(rd.Files) > 0:
+		return BodyKindMultipart
+	case len(rd.Form) > 0:
+		return BodyKindForm
+	default:
+		return BodyKindRaw
+	}
+}
+
 func (rd *RequestDraft) SetUrl(url string) *RequestDraft {
 	rd.Url = url
 	return rd
@@ -116,9 +160,55 @@ func (rd *RequestDraft) SetQueryParam(key, val string) *RequestDraft {
 
 func (rd *RequestDraft) SetBody(body string) *RequestDraft {
 	rd.Body = body
+	rd.BodyFile, rd.ExpandBodyFile = "", false
+	rd.BodyKind = BodyKindRaw
+	return rd
+}
+
+// Form fields are sent url encoded, unless files are attached as well
+func (rd *RequestDraft) SetFormField(key, val string) *RequestDraft {
+	if rd.Form == nil {
+		rd.Form = make(map[string]string)
+	}
+
+	rd.Form[key] = val
+	if rd.GetBodyKind() != BodyKindMultipart {
+		rd.BodyKind = BodyKindForm
+	}
+	return rd
+}
+
+func (rd *RequestDraft) SetFile(field, path string) *RequestDraft {
+	if rd.Files == nil {
+		rd.Files = make(map[string]string)
+	}
+
+	rd.Files[field] = path
+	rd.BodyKind = BodyKindMultipart
 	return rd
 }
 
+// Returns form fields and files (as FormFile) with variables expanded
+func (rd *RequestDraft) FormFields(lookups map[string]string) (map[string]any, error) {
+	fields := make(map[string]any, len(rd.Form)+len(rd.Files))
+	for key, val := range rd.Form {
+		expanded, err := util.ReplaceStrPattern(val, config.VarPattern, lookups)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = expanded
+	}
+
+	for key, path := range rd.Files {
+		expanded, err := util.ReplaceStrPattern(path, config.VarPattern, lookups)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = FormFile(expanded)
+	}
+	return fields, nil
+}
+
 func (rd *RequestDraft) parseToHttpHeader() (http.Header, error) {
 	result, err := rd.getExpandedKeyVals(rd.Headers)
 	if err != nil {
@@ -209,26 +299,81 @@ func (rd *RequestDraft) Finalize() (*http.Request, error) {
 	}
 
 	req.URL.RawQuery = query.Encode()
-	parsedBody, err := util.ReplaceStrPattern(rd.Body, config.VarPattern, lookups)
-	if err != nil {
+	if err := rd.applyBody(req, lookups); err != nil {
 		return nil, err
 	}
 
-	if len(parsedBody) > 0 {
-		req.Body = io.NopCloser(strings.NewReader(parsedBody))
-		req.ContentLength = int64(len(parsedBody))
-	}
-
 	rd.applyCookies(req)
 	return req, nil
 }
 
+func (rd *RequestDraft) applyBody(req *http.Request, lookups map[string]string) error {
+	kind := rd.GetBodyKind()
+	if kind == BodyKindRaw && rd.BodyFile != "" {
+		return rd.applyBodyFile(req, lookups)
+	} else if kind == BodyKindRaw {
+		parsedBody, err := util.ReplaceStrPattern(rd.Body, config.VarPattern, lookups)
+		if err != nil {
+			return err
+		}
+
+		if len(parsedBody) > 0 {
+			setReplayableBody(req, []byte(parsedBody))
+		}
+		return nil
+	}
+
+	fields, err := rd.FormFields(lookups)
+	if err != nil {
+		return err
+	}
+
+	body, contentType, err := EncodeFormBody(kind, fields)
+	if err != nil {
+		return err
+	}
+
+	setReplayableBody(req, body.Bytes())
+	SetContentType(req.Header, contentType)
+	return nil
+}
+
+// GetBody allows the body to be sent again, be it for retries, redirects or polling
+func setReplayableBody(req *http.Request, body []byte) {
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+}
+
+// Draft headers are stored in lower case, this makes sure there's only a single content type
+func SetContentType(header http.Header, contentType string) {
+	delete(header, "content-type")
+	header.Set("Content-Type", contentType)
+}
+
 func (r *RequestDraft) GetKey() string {
 	return r.id
 }
 
 func (r *RequestDraft) EditAsToml() error {
-	return util.EditToml(r, config.GetAppCfg().GetDefaultEditor())
+	if err := util.EditToml(r, config.GetAppCfg().GetDefaultEditor()); err != nil {
+		return err
+	}
+
+	if !r.BodyKind.IsValid() {
+		invalid := r.BodyKind
+		r.BodyKind = ""
+		return fmt.Errorf(
+			"invalid body kind '%s', expected one of %s|%s|%s",
+			invalid,
+			BodyKindRaw,
+			BodyKindForm,
+			BodyKindMultipart,
+		)
+	}
+	return nil
 }
 
 func IsValidHttpVerb(verb HTTPMethod) bool {
@@ -0,0 +1,199 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/shubm-quodes/repl-reqs/config"
+)
+
+const (
+	defaultInitialDelay = 200 * time.Millisecond
+	defaultMaxDelay     = 10 * time.Second
+	defaultJitter       = 0.2
+)
+
+var defaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// Methods that can safely be sent more than once, others are only retried if the policy says so
+var idempotentMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodOptions,
+	http.MethodTrace,
+	http.MethodPut,
+	http.MethodDelete,
+}
+
+type retryPolicyKey struct{}
+
+// RetryPolicy decides whether (and after how long) a failed attempt should be retried. Connection
+// errors are always retryable, responses only if their status code is one of StatusCodes. Requests
+// that aren't idempotent are left alone unless NonIdempotent is set.
+type RetryPolicy struct {
+	MaxAttempts   int
+	StatusCodes   []int
+	InitialDelay  time.Duration
+	MaxDelay      time.Duration
+	Jitter        float64
+	NonIdempotent bool
+}
+
+// A single round trip made while executing a request
+type Attempt struct {
+	StatusCode int
+	Err        error
+	Duration   time.Duration // Time till the response (headers) arrived
+	Delay      time.Duration // Time waited before the next attempt
+	NotRetried string        // Why a retryable attempt wasn't retried, e.g. 'body not replayable'
+}
+
+var NoRetry = &RetryPolicy{MaxAttempts: 1}
+
+func NewRetryPolicy(cfg config.RetryCfg) (*RetryPolicy, error) {
+	initialDelay, err := cfg.GetInitialDelay()
+	if err != nil {
+		return nil, err
+	}
+
+	maxDelay, err := cfg.GetMaxDelay()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &RetryPolicy{
+		MaxAttempts:  max(cfg.MaxAttempts, 1),
+		StatusCodes:  cfg.StatusCodes,
+		InitialDelay: initialDelay,
+		MaxDelay:     maxDelay,
+		Jitter:       defaultJitter,
+	}
+	if cfg.NonIdempotent != nil {
+		p.NonIdempotent = *cfg.NonIdempotent
+	}
+
+	if len(p.StatusCodes) == 0 {
+		p.StatusCodes = defaultRetryStatusCodes
+	}
+	if p.InitialDelay == 0 {
+		p.InitialDelay = defaultInitialDelay
+	}
+	if p.MaxDelay == 0 {
+		p.MaxDelay = defaultMaxDelay
+	}
+	if cfg.Jitter != nil {
+		p.Jitter = min(max(*cfg.Jitter, 0), 1)
+	}
+	return p, nil
+}
+
+// Requests carrying a policy in their context use it instead of the manager's default policy
+func WithRetryPolicy(ctx context.Context, p *RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, p)
+}
+
+func retryPolicyFrom(ctx context.Context) (*RetryPolicy, bool) {
+	p, ok := ctx.Value(retryPolicyKey{}).(*RetryPolicy)
+	return p, ok && p != nil
+}
+
+func (p *RetryPolicy) isRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, ErrTooManyRedirects)
+	}
+	return slices.Contains(p.StatusCodes, resp.StatusCode)
+}
+
+// Like net/http, requests carrying an idempotency key are taken to be idempotent whatever the method
+func (p *RetryPolicy) allowsMethod(req *http.Request) bool {
+	if p.NonIdempotent || slices.Contains(idempotentMethods, req.Method) {
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+// Delay before the given attempt (starting at 1) is retried. 'Retry-After' is honoured when present,
+// returns false if the server asks to wait longer than MaxDelay.
+func (p *RetryPolicy) delay(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return d, d <= p.MaxDelay
+		}
+	}
+
+	d := p.InitialDelay << (attempt - 1)
+	if d <= 0 || d > p.MaxDelay { // <= 0 guards against overflows
+		d = p.MaxDelay
+	}
+
+	if p.Jitter > 0 {
+		spread := float64(d) * p.Jitter
+		d = time.Duration(float64(d) - spread + rand.Float64()*2*spread)
+	}
+	return d, true
+}
+
+// Retry-After can either be a number of seconds or a http date
+func parseRetryAfter(val string) (time.Duration, bool) {
+	if val == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(val); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(val); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// Request bodies have to be rewound before they can be sent again
+func rewindBody(req *http.Request) bool {
+	if req.Body == nil || req.Body == http.NoBody {
+		return true
+	}
+
+	if req.GetBody == nil {
+		return false
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return false
+	}
+	req.Body = body
+	return true
+}
+
+func discardBody(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	resp.Body.Close()
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,293 @@
+package network
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shubm-quodes/repl-reqs/config"
+)
+
+func newRetryingRequest(t *testing.T, url string, policy *RetryPolicy) *http.Request {
+	return newRetryingMethodRequest(t, http.MethodPut, url, policy)
+}
+
+func newRetryingMethodRequest(
+	t *testing.T,
+	method, url string,
+	policy *RetryPolicy,
+) *http.Request {
+	req, err := http.NewRequest(method, url, strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	return req.WithContext(WithRetryPolicy(context.Background(), policy))
+}
+
+func TestRetryUntilSuccess(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("expected the body to be resent, got %q", body)
+		}
+
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	mgr := NewRequestManager(NewRequestTracker(), nil, nil)
+	policy := &RetryPolicy{
+		MaxAttempts:  5,
+		StatusCodes:  []int{http.StatusServiceUnavailable},
+		InitialDelay: time.Millisecond,
+		MaxDelay:     10 * time.Millisecond,
+	}
+
+	id, updates, err := mgr.MakeRequest(newRetryingRequest(t, srv.URL, policy))
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+
+	update := <-updates
+	if update.Err() != nil {
+		t.Fatalf("unexpected error: %v", update.Err())
+	}
+	if update.Resp().StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", update.Resp().StatusCode)
+	}
+
+	trackerReq, err := mgr.GetTrackerRequest(id)
+	if err != nil {
+		t.Fatalf("tracker request not found: %v", err)
+	}
+
+	var codes []int
+	for _, a := range trackerReq.Attempts {
+		codes = append(codes, a.StatusCode)
+	}
+	if len(codes) != 3 || codes[0] != 503 || codes[1] != 503 || codes[2] != 200 {
+		t.Errorf("expected attempts [503 503 200], got %v", codes)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	mgr := NewRequestManager(NewRequestTracker(), nil, nil)
+	policy := &RetryPolicy{
+		MaxAttempts:  2,
+		StatusCodes:  []int{http.StatusBadGateway},
+		InitialDelay: time.Millisecond,
+		MaxDelay:     time.Millisecond,
+	}
+
+	_, updates, err := mgr.MakeRequest(newRetryingRequest(t, srv.URL, policy))
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+
+	// Retryable responses are still returned once attempts are exhausted
+	update := <-updates
+	if update.Err() != nil || update.Resp().StatusCode != http.StatusBadGateway {
+		t.Errorf("expected the last 502 response, got err %v", update.Err())
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 attempts, got %d", calls.Load())
+	}
+}
+
+func TestRetryDoesNotRepeatJarCookies(t *testing.T) {
+	var (
+		calls   atomic.Int32
+		cookies []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookies = append(cookies, strings.Join(r.Header.Values("Cookie"), "; "))
+		if calls.Add(1) < 4 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	env := config.Environment("dev")
+	jar := newTestCookieJar(t, &env)
+	jar.SetCookies(mustParseURL(t, srv.URL), []*http.Cookie{{Name: "sid", Value: "1"}})
+
+	mgr := NewRequestManager(NewRequestTracker(), nil, nil)
+	mgr.SetCookieJar(jar)
+	policy := &RetryPolicy{
+		MaxAttempts:  4,
+		StatusCodes:  []int{http.StatusServiceUnavailable},
+		InitialDelay: time.Millisecond,
+		MaxDelay:     time.Millisecond,
+	}
+
+	req := newRetryingRequest(t, srv.URL, policy)
+	_, updates, err := mgr.MakeRequest(req)
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+	if update := <-updates; update.Err() != nil {
+		t.Fatalf("unexpected error: %v", update.Err())
+	}
+
+	if len(cookies) != 4 {
+		t.Fatalf("expected 4 attempts, got %d", len(cookies))
+	}
+	for i, c := range cookies {
+		if c != "sid=1" {
+			t.Errorf("attempt %d sent Cookie %q, want %q", i+1, c, "sid=1")
+		}
+	}
+	if got := req.Header.Get("Cookie"); got != "" {
+		t.Errorf("expected the original request's headers to be left alone, got Cookie %q", got)
+	}
+}
+
+func TestRetryNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	newPolicy := func(nonIdempotent bool) *RetryPolicy {
+		return &RetryPolicy{
+			MaxAttempts:   3,
+			StatusCodes:   []int{http.StatusServiceUnavailable},
+			InitialDelay:  time.Millisecond,
+			MaxDelay:      time.Millisecond,
+			NonIdempotent: nonIdempotent,
+		}
+	}
+
+	tests := []struct {
+		name           string
+		req            func() *http.Request
+		wantCalls      int32
+		wantNotRetried string
+	}{
+		{
+			name: "non idempotent",
+			req: func() *http.Request {
+				return newRetryingMethodRequest(t, http.MethodPost, srv.URL, newPolicy(false))
+			},
+			wantCalls:      1,
+			wantNotRetried: "POST isn't idempotent",
+		},
+		{
+			name: "non idempotent opted in",
+			req: func() *http.Request {
+				return newRetryingMethodRequest(t, http.MethodPost, srv.URL, newPolicy(true))
+			},
+			wantCalls: 3,
+		},
+		{
+			name: "idempotency key",
+			req: func() *http.Request {
+				req := newRetryingMethodRequest(t, http.MethodPatch, srv.URL, newPolicy(false))
+				req.Header.Set("Idempotency-Key", "abc")
+				return req
+			},
+			wantCalls: 3,
+		},
+		{
+			name: "body not replayable",
+			req: func() *http.Request {
+				req := newRetryingRequest(t, srv.URL, newPolicy(false))
+				req.GetBody = nil
+				return req
+			},
+			wantCalls:      1,
+			wantNotRetried: "body not replayable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			mgr := NewRequestManager(NewRequestTracker(), nil, nil)
+
+			id, updates, err := mgr.MakeRequest(tt.req())
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			<-updates
+
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("expected %d attempts, got %d", tt.wantCalls, calls.Load())
+			}
+
+			trackerReq, err := mgr.GetTrackerRequest(id)
+			if err != nil {
+				t.Fatalf("tracker request not found: %v", err)
+			}
+			last := trackerReq.Attempts[len(trackerReq.Attempts)-1]
+			if last.NotRetried != tt.wantNotRetried {
+				t.Errorf("NotRetried = %q, want %q", last.NotRetried, tt.wantNotRetried)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := &RetryPolicy{
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     time.Second,
+	}
+
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		want       time.Duration
+		wantOk     bool
+	}{
+		{"first attempt", 1, "", 100 * time.Millisecond, true},
+		{"exponential", 3, "", 400 * time.Millisecond, true},
+		{"capped", 10, "", time.Second, true},
+		{"retry after", 1, "1", time.Second, true},
+		{"retry after too long", 1, "120", 120 * time.Second, false},
+		{"invalid retry after", 2, "soon", 200 * time.Millisecond, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+
+			got, ok := policy.delay(tt.attempt, resp)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("delay() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfterDate(t *testing.T) {
+	at := time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat)
+	d, ok := parseRetryAfter(at)
+	if !ok || d <= 20*time.Second || d > 30*time.Second {
+		t.Errorf("expected ~30s for %q, got %v (ok: %v)", at, d, ok)
+	}
+}
@@ -0,0 +1,258 @@
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shubm-quodes/repl-reqs/util"
+)
+
+const (
+	SnapshotsDirName = "snapshots"
+
+	snapshotFileExt = ".json"
+)
+
+// Headers recorded when none are selected explicitly
+var DefaultSnapshotHeaders = []string{"Content-Type"}
+
+// A known good response. Only the selected headers are recorded, bodies are stored decoded (see
+// DecodeBody) so that they can be compared structurally, undecodable bodies are kept as text.
+type Snapshot struct {
+	Name       string            `json:"name"`
+	StatusCode int               `json:"statusCode"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       any               `json:"body,omitempty"`
+	Ignore     []string          `json:"ignore,omitempty"` // Body paths left out of checks
+	SavedAt    time.Time         `json:"savedAt"`
+}
+
+func NewSnapshot(name string, resp *http.Response, headers []string) (*Snapshot, error) {
+	if resp == nil {
+		return nil, errors.New("no response to snapshot")
+	}
+	if len(headers) == 0 {
+		headers = DefaultSnapshotHeaders
+	}
+
+	body, err := decodeSnapshotBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &Snapshot{
+		Name:       name,
+		StatusCode: resp.StatusCode,
+		Headers:    make(map[string]string, len(headers)),
+		Body:       body,
+		SavedAt:    time.Now(),
+	}
+	for _, h := range headers {
+		if val := resp.Header.Get(h); val != "" {
+			snap.Headers[http.CanonicalHeaderKey(h)] = val
+		}
+	}
+	return snap, nil
+}
+
+/*
+Compares the response against the snapshot, changes are prefixed by what they are about: 'status',
+'headers.<name>' or 'body.<path>'. Only the recorded headers are compared. Ignore paths (along with
+the ones saved with the snapshot) apply to the body, '*' matches any key or index, as in
+'items.*.id', and ignoring a path ignores everything under it.
+*/
+func (s *Snapshot) Compare(resp *http.Response, ignore []string) ([]util.Change, error) {
+	if resp == nil {
+		return nil, errors.New("no response to compare")
+	}
+
+	var changes []util.Change
+	if resp.StatusCode != s.StatusCode {
+		changes = append(changes, util.Change{
+			Path: "status",
+			Kind: util.ChangeModified,
+			Old:  float64(s.StatusCode),
+			New:  float64(resp.StatusCode),
+		})
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(s.Headers)) {
+		expected, actual := s.Headers[name], resp.Header.Get(name)
+		switch {
+		case actual == "":
+			changes = append(changes, util.Change{
+				Path: "headers." + name, Kind: util.ChangeRemoved, Old: expected,
+			})
+		case actual != expected:
+			changes = append(changes, util.Change{
+				Path: "headers." + name, Kind: util.ChangeModified, Old: expected, New: actual,
+			})
+		}
+	}
+
+	body, err := decodeSnapshotBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns := append(slices.Clone(s.Ignore), ignore...)
+	for _, c := range util.Diff(s.Body, body) {
+		if isIgnoredPath(c.Path, patterns) {
+			continue
+		}
+
+		if c.Path == "" {
+			c.Path = "body"
+		} else {
+			c.Path = "body." + c.Path
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+func decodeSnapshotBody(resp *http.Response) (any, error) {
+	if resp.Body == nil {
+		return nil, nil
+	}
+
+	raw, err := util.ReadAndResetIoCloser(&resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	body, err := DecodeBody(resp.Header.Get("Content-Type"), raw)
+	if err != nil {
+		return string(raw), nil
+	}
+	return body, nil
+}
+
+// Ignore paths are dotted, jq style paths ('.items[].id' or '.items[0]') are accepted as well
+func normalizeIgnorePath(pattern string) []string {
+	pattern = strings.ReplaceAll(pattern, "[]", ".*")
+	pattern = strings.NewReplacer("[", ".", "]", "").Replace(pattern)
+	return strings.Split(strings.TrimPrefix(pattern, "."), ".")
+}
+
+func isIgnoredPath(path string, patterns []string) bool {
+	if path == "" {
+		return false
+	}
+
+	segments := strings.Split(path, ".")
+	for _, pattern := range patterns {
+		patternSegments := normalizeIgnorePath(pattern)
+		if len(patternSegments) > len(segments) {
+			continue
+		}
+
+		matched := true
+		for i, p := range patternSegments {
+			if p != "*" && p != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshots are stored as one json file each, named after the snapshot
+type SnapshotStore struct {
+	dir string
+}
+
+func NewSnapshotStore(dir string) *SnapshotStore {
+	return &SnapshotStore{dir: dir}
+}
+
+func (s *SnapshotStore) filePath(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid snapshot name '%s'", name)
+	}
+	return filepath.Join(s.dir, name+snapshotFileExt), nil
+}
+
+func (s *SnapshotStore) Save(snap *Snapshot) error {
+	filePath, err := s.filePath(snap.Name)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	return util.WriteFileAtomic(filePath, data, 0600)
+}
+
+func (s *SnapshotStore) Load(name string) (*Snapshot, error) {
+	filePath, err := s.filePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("snapshot '%s' not found", name)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot '%s': %w", name, err)
+	}
+	return &snap, nil
+}
+
+func (s *SnapshotStore) Delete(name string) error {
+	filePath, err := s.filePath(name)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(filePath)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("snapshot '%s' not found", name)
+	}
+	return err
+}
+
+// Names of the saved snapshots, sorted
+func (s *SnapshotStore) List() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), snapshotFileExt); ok && !entry.IsDir() {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
@@ -0,0 +1,91 @@
+package network
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/shubm-quodes/repl-reqs/util"
+)
+
+func newSnapshotTestResp(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"application/json"}, "Date": {"today"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestSnapshotCompare(t *testing.T) {
+	snap, err := NewSnapshot(
+		"user",
+		newSnapshotTestResp(http.StatusOK, `{"id": 1, "name": "John", "meta": {"at": "1"}}`),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, recorded := snap.Headers["Date"]; recorded {
+		t.Errorf("expected only the default headers to be recorded, got %v", snap.Headers)
+	}
+
+	same := newSnapshotTestResp(http.StatusOK, `{"name": "John", "id": 1, "meta": {"at": "2"}}`)
+	changes, err := snap.Compare(same, []string{".meta"})
+	if err != nil || len(changes) != 0 {
+		t.Errorf("expected no drift, got %v (%v)", changes, err)
+	}
+
+	drifted := newSnapshotTestResp(http.StatusCreated, `{"id": 2, "name": "John", "meta": {}}`)
+	drifted.Header.Set("Content-Type", "application/json; charset=utf-8")
+	changes, err = snap.Compare(drifted, []string{"meta.*"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"status", "headers.Content-Type", "body.id"}
+	if len(changes) != len(want) {
+		t.Fatalf("expected changes %v, got %v", want, changes)
+	}
+	for i, c := range changes {
+		if c.Path != want[i] {
+			t.Errorf("expected change %d to be about %s, got %s", i, want[i], c.Path)
+		}
+	}
+	if changes[2].Kind != util.ChangeModified || changes[2].Old != float64(1) {
+		t.Errorf("unexpected body change %#v", changes[2])
+	}
+}
+
+func TestSnapshotStore(t *testing.T) {
+	store := NewSnapshotStore(t.TempDir())
+	snap, _ := NewSnapshot("users", newSnapshotTestResp(http.StatusOK, `[{"id": 1}]`), nil)
+	snap.Ignore = []string{"[].id"}
+
+	if err := store.Save(snap); err != nil {
+		t.Fatalf("failed to save the snapshot: %v", err)
+	}
+	if err := store.Save(&Snapshot{Name: "../escape"}); err == nil {
+		t.Errorf("expected names with path separators to be rejected")
+	}
+
+	loaded, err := store.Load("users")
+	if err != nil {
+		t.Fatalf("failed to load the snapshot: %v", err)
+	}
+
+	changes, err := loaded.Compare(newSnapshotTestResp(http.StatusOK, `[{"id": 7}]`), nil)
+	if err != nil || len(changes) != 0 {
+		t.Errorf("expected saved ignore paths to apply, got %v (%v)", changes, err)
+	}
+
+	if names, _ := store.List(); len(names) != 1 || names[0] != "users" {
+		t.Errorf("unexpected snapshots %v", names)
+	}
+	if err := store.Delete("users"); err != nil {
+		t.Errorf("failed to delete the snapshot: %v", err)
+	}
+	if _, err := store.Load("users"); err == nil {
+		t.Errorf("expected the snapshot to be gone")
+	}
+}
@@ -0,0 +1,90 @@
+package network
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Time spent in each phase of a request. Phases that didn't happen (for instance DNS, connect and
+// TLS when a pooled connection is reused) are left as zero.
+type RequestTiming struct {
+	DNS      time.Duration
+	Connect  time.Duration
+	TLS      time.Duration
+	TTFB     time.Duration // Time from the request being written till the first response byte
+	Download time.Duration
+	Total    time.Duration
+	Reused   bool
+}
+
+type timingRecorder struct {
+	mu        sync.Mutex
+	timing    RequestTiming
+	start     time.Time
+	dnsStart  time.Time
+	connStart time.Time
+	tlsStart  time.Time
+	wrote     time.Time
+	firstByte time.Time
+}
+
+func newTimingRecorder(start time.Time) *timingRecorder {
+	return &timingRecorder{start: start}
+}
+
+func (tr *timingRecorder) record(fn func()) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	fn()
+}
+
+func (tr *timingRecorder) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			tr.record(func() { tr.timing.Reused = info.Reused })
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			tr.record(func() { tr.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			tr.record(func() { tr.timing.DNS = time.Since(tr.dnsStart) })
+		},
+		ConnectStart: func(string, string) {
+			tr.record(func() { tr.connStart = time.Now() })
+		},
+		ConnectDone: func(string, string, error) {
+			tr.record(func() { tr.timing.Connect = time.Since(tr.connStart) })
+		},
+		TLSHandshakeStart: func() {
+			tr.record(func() { tr.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			tr.record(func() { tr.timing.TLS = time.Since(tr.tlsStart) })
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			tr.record(func() { tr.wrote = time.Now() })
+		},
+		GotFirstResponseByte: func() {
+			tr.record(func() {
+				tr.firstByte = time.Now()
+				if !tr.wrote.IsZero() {
+					tr.timing.TTFB = tr.firstByte.Sub(tr.wrote)
+				}
+			})
+		},
+	}
+}
+
+// Wraps up the recording, end is expected to be the point where the response body has been read.
+func (tr *timingRecorder) finish(end time.Time) RequestTiming {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	if !tr.firstByte.IsZero() {
+		tr.timing.Download = end.Sub(tr.firstByte)
+	}
+	tr.timing.Total = end.Sub(tr.start)
+	return tr.timing
+}
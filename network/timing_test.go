@@ -0,0 +1,124 @@
+package network
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+	"time"
+)
+
+func newSlowServer() *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte("second"))
+	}))
+}
+
+func recordTiming(t *testing.T, client *http.Client, url string) (*timingRecorder, RequestTiming) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	recorder := newTimingRecorder(time.Now())
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), recorder.trace()))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	return recorder, recorder.finish(time.Now())
+}
+
+func TestTimingRecorder(t *testing.T) {
+	srv := newSlowServer()
+	defer srv.Close()
+
+	recorder, timing := recordTiming(t, srv.Client(), srv.URL)
+
+	if timing.Reused {
+		t.Errorf("expected a new connection")
+	}
+	if timing.DNS != 0 {
+		t.Errorf("expected no DNS lookup for an IP, got %v", timing.DNS)
+	}
+	if timing.Connect <= 0 || timing.TLS <= 0 {
+		t.Errorf("expected connect & TLS to be timed, got %v & %v", timing.Connect, timing.TLS)
+	}
+	if timing.TTFB < 20*time.Millisecond {
+		t.Errorf("expected TTFB to include the server's delay, got %v", timing.TTFB)
+	}
+	if timing.Download < 10*time.Millisecond {
+		t.Errorf("expected the download to include the server's delay, got %v", timing.Download)
+	}
+
+	phases := timing.Connect + timing.TLS + timing.TTFB + timing.Download
+	if timing.Total < phases {
+		t.Errorf("expected total %v to cover all phases (%v)", timing.Total, phases)
+	}
+
+	order := []time.Time{
+		recorder.start,
+		recorder.connStart,
+		recorder.tlsStart,
+		recorder.wrote,
+		recorder.firstByte,
+	}
+	for i := 1; i < len(order); i++ {
+		if order[i].Before(order[i-1]) {
+			t.Errorf("phase %d started before phase %d", i, i-1)
+		}
+	}
+}
+
+func TestTimingRecorderReusedConn(t *testing.T) {
+	srv := newSlowServer()
+	defer srv.Close()
+
+	client := srv.Client()
+	recordTiming(t, client, srv.URL)
+	_, timing := recordTiming(t, client, srv.URL)
+
+	if !timing.Reused {
+		t.Fatalf("expected the connection to be reused")
+	}
+	if timing.Connect != 0 || timing.TLS != 0 {
+		t.Errorf("expected no connect & TLS, got %v & %v", timing.Connect, timing.TLS)
+	}
+	if timing.TTFB <= 0 || timing.Total <= 0 {
+		t.Errorf("expected TTFB & total to be timed, got %v & %v", timing.TTFB, timing.Total)
+	}
+}
+
+func TestTimingCoversBody(t *testing.T) {
+	srv := newSlowServer()
+	defer srv.Close()
+
+	mgr := NewRequestManager(NewRequestTracker(), srv.Client(), nil)
+
+	_, trackerReq, err := mgr.Send(createTestRequest(t, http.MethodGet, srv.URL))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	if trackerReq.Timing.Download < 10*time.Millisecond {
+		t.Errorf("expected the download to include the delay, got %v", trackerReq.Timing.Download)
+	}
+	if trackerReq.RequestTime < 30*time.Millisecond {
+		t.Errorf("expected the request time to include the body, got %v", trackerReq.RequestTime)
+	}
+	if d := trackerReq.Attempts[0].Duration; d <= 0 || d > trackerReq.RequestTime {
+		t.Errorf("expected the attempt's duration to be within the request time, got %v", d)
+	}
+}
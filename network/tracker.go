@@ -23,6 +23,7 @@ type Done chan struct{}
 
 type TrackerRequest struct {
 	Request         *Request
+	RequestHeaders  http.Header // As set on the request, before the common headers and cookies
 	Status          RequestStatus
 	StatusCode      int
 	ResponseHeaders http.Header
@@ -30,6 +31,9 @@ type TrackerRequest struct {
 	FullResponse    *http.Response
 	Done            Done
 	RequestTime     time.Duration
+	Timing          RequestTiming
+	Attempts        []Attempt
+	Redirects       []RedirectHop
 }
 
 // Request is a wrapper for a http.Request, adding a unique ID.
@@ -42,38 +46,34 @@ type Request struct {
 type RequestTracker struct {
 	mu       sync.Mutex
 	requests map[string]*TrackerRequest
-	updates  chan Update
 }
 
 func NewRequestTracker() *RequestTracker {
-	rt := &RequestTracker{
+	return &RequestTracker{
 		requests: make(map[string]*TrackerRequest),
-		updates:  make(chan Update),
 	}
-	go rt.startListener()
-	return rt
 }
 
-func (rt *RequestTracker) startListener() {
-	for update := range rt.updates {
-		rt.mu.Lock()
-		trackerReq, ok := rt.requests[update.reqId]
-		rt.mu.Unlock()
-
-		if !ok {
-			log.Debug("Tracker could not find request with ID: %s", update.reqId)
-			continue
-		}
+// Applied before the update is handed to whoever made the request, so that cmds run right after a
+// request completes (as in sequences) find its response
+func (rt *RequestTracker) applyUpdate(update Update) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
 
-		if update.resp != nil {
-			trackerReq.ResponseHeaders = update.resp.Header
-			trackerReq.StatusCode = update.resp.StatusCode
-			trackerReq.FullResponse = update.resp
-		}
+	trackerReq, ok := rt.requests[update.reqId]
+	if !ok {
+		log.Debug("Tracker could not find request with ID: %s", update.reqId)
+		return
+	}
 
-		trackerReq.Status = StatusCompleted
-		log.Debug("Tracker updated state for request ID: %s", update.reqId)
+	if update.resp != nil {
+		trackerReq.ResponseHeaders = update.resp.Header
+		trackerReq.StatusCode = update.resp.StatusCode
+		trackerReq.FullResponse = update.resp
 	}
+
+	trackerReq.Status = StatusCompleted
+	log.Debug("Tracker updated state for request ID: %s", update.reqId)
 }
 
 func (rt *RequestTracker) AddRequest(trackerReq *TrackerRequest) {
@@ -92,3 +92,23 @@ func (rt *RequestTracker) AddRequest(trackerReq *TrackerRequest) {
 func (r *Request) GetKey() string {
 	return r.ID
 }
+
+func (rt *RequestTracker) GetRequest(id string) (*TrackerRequest, bool) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	trackerReq, ok := rt.requests[id]
+	return trackerReq, ok
+}
+
+func (rt *RequestTracker) FindByResponse(resp *http.Response) (*TrackerRequest, bool) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	for _, trackerReq := range rt.requests {
+		if trackerReq.FullResponse == resp {
+			return trackerReq, true
+		}
+	}
+	return nil, false
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
 	"syscall"
 
 	"github.com/shubm-quodes/repl-reqs/cmd"
@@ -24,6 +27,7 @@ func main() {
 
 	err := safeRun()
 	config.GetEnvManager().Shutdown()
+	syscmd.Shutdown()
 
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -36,13 +40,22 @@ func setupGracefulShutdown() {
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
-		<-sigChan
+		sig := <-sigChan
 		fmt.Fprintln(os.Stderr, "\nShutting down gracefully...")
 		config.GetEnvManager().Shutdown()
-		os.Exit(0)
+		syscmd.Shutdown()
+		os.Exit(interruptedExitCode(sig))
 	}()
 }
 
+// Like shells, 128 + the signal's number, so that scripts & CI jobs can tell an interrupted run apart
+func interruptedExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
+
 func safeRun() (err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -68,15 +81,123 @@ func run() error {
 		syscmd.RegisterCmds(reg)
 	}
 
-	cmdHandler, err := cmd.NewCmdHandler(cfg, config.NewShellCfg(cfg), reg)
-	if err != nil {
-		return fmt.Errorf("failed to initialize command handler: %w", err)
+	// Switched before the http client's set up, as transport settings may depend on the env
+	if flags.Play != nil && flags.Play.Env != "" {
+		if err := switchEnv(flags.Play.Env); err != nil {
+			return err
+		}
+	}
+
+	var cmdHandler *cmd.ReplCmdHandler
+	if flags.IsHeadless() {
+		cmdHandler = cmd.NewHeadlessCmdHandler(cfg, reg, os.Stdout, os.Stderr)
+	} else {
+		var err error
+		cmdHandler, err = cmd.NewCmdHandler(cfg, config.NewShellCfg(cfg), reg)
+		if err != nil {
+			return fmt.Errorf("failed to initialize command handler: %w", err)
+		}
 	}
 
 	if err := syscmd.InitNetCmds(cfg.RawCfg, cmdHandler); err != nil {
 		return err
 	}
 
+	if flags.IsHeadless() {
+		cmdHandler.BootstrapHeadless(omitSystemCommands == "true")
+		return runHeadless(cmdHandler, flags)
+	}
+
 	cmdHandler.Bootstrap(omitSystemCommands == "true")
 	return nil
 }
+
+// Runs the '-e' cmds, the '-f' script & then plays the sequence, the first failure ends the run
+func runHeadless(cmdHandler *cmd.ReplCmdHandler, flags *config.FlagVal) error {
+	if len(flags.Cmds) > 0 {
+		cmds := strings.NewReader(strings.Join(flags.Cmds, "\n"))
+		if err := cmdHandler.RunScript("-e", cmds); err != nil {
+			return err
+		}
+	}
+
+	if err := runScriptFile(cmdHandler, flags.ScriptPath); err != nil {
+		return err
+	}
+
+	if flags.Play != nil {
+		return playSequence(cmdHandler, flags.Play)
+	}
+	return nil
+}
+
+func runScriptFile(cmdHandler *cmd.ReplCmdHandler, path string) error {
+	switch path {
+	case "":
+		return nil
+	case "-":
+		return cmdHandler.RunScript("stdin", os.Stdin)
+	}
+
+	script, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open script: %w", err)
+	}
+	defer script.Close()
+
+	return cmdHandler.RunScript(path, script)
+}
+
+// Plays the sequence, recording each step for the report (if one's asked for)
+func playSequence(cmdHandler *cmd.ReplCmdHandler, args *config.PlayArgs) error {
+	report := cmd.NewSequenceReport(args.Sequence, config.GetEnvManager().GetActiveEnvName())
+	ctx := cmd.WithSequenceReport(cmdHandler.GetDefaultCtx(), report)
+
+	tokens := []string{cmd.CmdPlayName, args.Sequence}
+	if args.DataPath != "" {
+		tokens = append(tokens, "--data", args.DataPath)
+	}
+
+	_, playErr := cmdHandler.HandleCmd(ctx, tokens)
+	if len(report.Steps) > 0 {
+		fmt.Println(report.Summary())
+	}
+
+	if args.ReportPath != "" {
+		if err := writeReport(report, args.ReportPath); err != nil {
+			return err
+		}
+	}
+	return playErr
+}
+
+func writeReport(report *cmd.SequenceReport, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".xml") {
+		err = report.WriteJUnit(file)
+	} else {
+		err = report.WriteJSON(file)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// Makes env the active one for this run only, the env file (and so the shell) keeps the previously
+// active env
+func switchEnv(env string) error {
+	envMgr := config.GetEnvManager()
+	if !slices.Contains(envMgr.ListEnvs(), config.Environment(env)) {
+		return fmt.Errorf("environment '%s' not found", env)
+	}
+
+	envMgr.OverrideActiveEnv(env)
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/shubm-quodes/repl-reqs/cmd"
+	"github.com/shubm-quodes/repl-reqs/config"
+)
+
+// Records the args it's run with, fails when they're 'fail'
+type stubCmd struct {
+	*cmd.BaseCmd
+	ran []string
+}
+
+func (c *stubCmd) Execute(cmdCtx *cmd.CmdCtx) (context.Context, error) {
+	args := strings.Join(cmdCtx.ExpandedTokens, " ")
+	c.ran = append(c.ran, args)
+	if args == "fail" {
+		return cmdCtx.Ctx, errors.New("stub failed")
+	}
+	return cmdCtx.Ctx, nil
+}
+
+func newStubHandler() (*cmd.ReplCmdHandler, *stubCmd) {
+	stub := &stubCmd{BaseCmd: cmd.NewBaseCmd("stub", "")}
+	reg := cmd.NewCmdRegistry()
+	reg.RegisterCmd(stub)
+
+	var out, errOut bytes.Buffer
+	h := cmd.NewHeadlessCmdHandler(config.NewAppCfg(), reg, &out, &errOut)
+	h.Inject(stub)
+	return h, stub
+}
+
+// Stdin is swapped for a file holding the script
+func withStdin(t *testing.T, script string) {
+	f, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatalf("failed to create stdin: %v", err)
+	}
+	f.WriteString(script)
+	f.Seek(0, 0)
+
+	stdin := os.Stdin
+	os.Stdin = f
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		f.Close()
+	})
+}
+
+func TestRunHeadless(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   *config.FlagVal
+		stdin   string
+		wantRan []string
+		wantErr string
+	}{
+		{
+			name:    "cmds then stdin",
+			flags:   &config.FlagVal{Cmds: []string{"stub a", "stub b"}, ScriptPath: "-"},
+			stdin:   "stub c\n",
+			wantRan: []string{"a", "b", "c"},
+		},
+		{
+			name:    "failing cmd stops the run",
+			flags:   &config.FlagVal{Cmds: []string{"stub a", "stub fail"}, ScriptPath: "-"},
+			stdin:   "stub c\n",
+			wantRan: []string{"a", "fail"},
+			wantErr: "-e:2: stub failed",
+		},
+		{
+			name:    "failing stdin line",
+			flags:   &config.FlagVal{ScriptPath: "-"},
+			stdin:   "# setup\nstub a\nstub fail\n",
+			wantRan: []string{"a", "fail"},
+			wantErr: "stdin:3: stub failed",
+		},
+		{
+			name:    "missing script",
+			flags:   &config.FlagVal{ScriptPath: "does-not-exist.txt"},
+			wantErr: "failed to open script",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withStdin(t, tt.stdin)
+			h, stub := newStubHandler()
+
+			err := runHeadless(h, tt.flags)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+			if !slices.Equal(stub.ran, tt.wantRan) {
+				t.Errorf("ran %q, want %q", stub.ran, tt.wantRan)
+			}
+		})
+	}
+}
+
+func TestInterruptedExitCode(t *testing.T) {
+	if code := interruptedExitCode(os.Interrupt); code != 130 {
+		t.Errorf("expected 130 for SIGINT, got %d", code)
+	}
+	if code := interruptedExitCode(syscall.SIGTERM); code != 143 {
+		t.Errorf("expected 143 for SIGTERM, got %d", code)
+	}
+}
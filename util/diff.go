@@ -0,0 +1,101 @@
+package util
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// A difference between two values, the path is dotted with numeric indices for array elements
+// (as in 'items.0.id') and empty for the values themselves.
+type Change struct {
+	Path string
+	Kind ChangeKind
+	Old  any
+	New  any
+}
+
+// Structural diff of decoded values (maps, slices & scalars), so key order doesn't matter. Array
+// elements are compared index wise. Changes are ordered by path.
+func Diff(a, b any) []Change {
+	var changes []Change
+	diffAt("", a, b, &changes)
+	return changes
+}
+
+func diffAt(path string, a, b any, changes *[]Change) {
+	switch av := a.(type) {
+	case map[string]any:
+		if bv, ok := b.(map[string]any); ok {
+			diffMaps(path, av, bv, changes)
+			return
+		}
+	case []any:
+		if bv, ok := b.([]any); ok {
+			diffSlices(path, av, bv, changes)
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Path: path, Kind: ChangeModified, Old: a, New: b})
+	}
+}
+
+func diffMaps(path string, a, b map[string]any, changes *[]Change) {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		av, inA := a[key]
+		bv, inB := b[key]
+		keyPath := joinDiffPath(path, key)
+
+		switch {
+		case !inB:
+			*changes = append(*changes, Change{Path: keyPath, Kind: ChangeRemoved, Old: av})
+		case !inA:
+			*changes = append(*changes, Change{Path: keyPath, Kind: ChangeAdded, New: bv})
+		default:
+			diffAt(keyPath, av, bv, changes)
+		}
+	}
+}
+
+func diffSlices(path string, a, b []any, changes *[]Change) {
+	for i := range max(len(a), len(b)) {
+		idxPath := joinDiffPath(path, strconv.Itoa(i))
+
+		switch {
+		case i >= len(b):
+			*changes = append(*changes, Change{Path: idxPath, Kind: ChangeRemoved, Old: a[i]})
+		case i >= len(a):
+			*changes = append(*changes, Change{Path: idxPath, Kind: ChangeAdded, New: b[i]})
+		default:
+			diffAt(idxPath, a[i], b[i], changes)
+		}
+	}
+}
+
+func joinDiffPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,44 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := map[string]any{
+		"id":   float64(1),
+		"name": "John",
+		"tags": []any{"a", "b"},
+		"meta": map[string]any{"version": "1"},
+	}
+	b := map[string]any{
+		"meta": map[string]any{"version": "2"},
+		"tags": []any{"a", "b", "c"},
+		"name": "John",
+		"age":  float64(30),
+	}
+
+	want := []Change{
+		{Path: "age", Kind: ChangeAdded, New: float64(30)},
+		{Path: "id", Kind: ChangeRemoved, Old: float64(1)},
+		{Path: "meta.version", Kind: ChangeModified, Old: "1", New: "2"},
+		{Path: "tags.2", Kind: ChangeAdded, New: "c"},
+	}
+
+	if got := Diff(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %#v, want %#v", got, want)
+	}
+}
+
+func TestDiffIdenticalAndMismatchedTypes(t *testing.T) {
+	same := map[string]any{"items": []any{map[string]any{"id": "1"}}}
+	if changes := Diff(same, map[string]any{"items": []any{map[string]any{"id": "1"}}}); changes != nil {
+		t.Errorf("expected no changes, got %#v", changes)
+	}
+
+	changes := Diff(map[string]any{"a": "1"}, []any{"1"})
+	if len(changes) != 1 || changes[0].Path != "" || changes[0].Kind != ChangeModified {
+		t.Errorf("expected the whole value to be modified, got %#v", changes)
+	}
+}
@@ -1,5 +1,7 @@
 package util
 
+import "fmt"
+
 type Number interface {
 	uint | ~int8 | int | int64 | float32 | float64
 }
@@ -15,3 +17,18 @@ func IsGreaterThan[n Number](num1, num2 n) bool {
 func IsSmallerThan[n Number](num1, num2 n) bool {
 	return num1 < num2
 }
+
+// Formats byte counts in human readable binary units, for instance 1.5 MiB
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for q := n / unit; q >= unit; q /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
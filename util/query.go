@@ -0,0 +1,1442 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A compiled query, queries are a subset of jq: paths (.items[0].id, .items[].id), slices
+// (.[2:5]), pipes, comparisons, arithmetic, 'and'/'or', '//' alternatives, array construction
+// and builtins such as select(), map(), length & keys.
+type Query struct {
+	expr string
+	run  queryFn
+}
+
+// Every expression yields zero or more outputs for an input, like in jq
+type queryFn func(input any) ([]any, error)
+
+type queryFunc struct {
+	arity int
+	build func(args []queryFn) queryFn
+}
+
+var queryFuncs = builtinQueryFuncs()
+
+func CompileQuery(expr string) (*Query, error) {
+	tokens, err := lexQuery(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query '%s': %w", expr, err)
+	}
+
+	p := &queryParser{tokens: tokens}
+	run, err := p.parsePipe()
+	if err == nil && !p.done() {
+		err = fmt.Errorf("unexpected '%s'", p.peek().val)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid query '%s': %w", expr, err)
+	}
+	return &Query{expr: expr, run: run}, nil
+}
+
+func (q *Query) Run(data any) ([]any, error) {
+	return q.run(data)
+}
+
+func (q *Query) String() string {
+	return q.expr
+}
+
+// Plain paths like 'user.name' or 'items.0.id' (without any of the query syntax) are resolved the
+// way they always were, see ExtractVal
+func IsPlainPath(expr string) bool {
+	if _, isFunc := queryFuncs[expr]; isFunc {
+		return false
+	}
+	return !strings.HasPrefix(expr, ".") && !strings.ContainsAny(expr, "[]|()!<>, ") &&
+		!strings.Contains(expr, "==")
+}
+
+// Evaluates either a query or a plain path against the data
+func QueryVal(data any, expr string) ([]any, error) {
+	expr = strings.TrimSpace(expr)
+	if IsPlainPath(expr) {
+		val, err := ExtractVal(data, expr)
+		if err != nil {
+			return nil, err
+		}
+		return []any{val}, nil
+	}
+
+	q, err := CompileQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	return q.Run(data)
+}
+
+// Strings are kept as they are, other values are json encoded. Multiple results are joined by ','.
+func StringifyQueryResults(results []any) string {
+	strs := make([]string, 0, len(results))
+	for _, r := range results {
+		switch v := r.(type) {
+		case string:
+			strs = append(strs, v)
+		case float64:
+			strs = append(strs, strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				strs = append(strs, fmt.Sprint(v))
+			} else {
+				strs = append(strs, string(b))
+			}
+		}
+	}
+	return strings.Join(strs, ",")
+}
+
+/*
+* Lexer
+ */
+
+type queryTokenKind int
+
+const (
+	qtPunct queryTokenKind = iota
+	qtIdent
+	qtString
+	qtNumber
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	val  string
+	num  float64
+}
+
+// Longest ones first
+var queryPuncts = []string{
+	"==", "!=", "<=", ">=", "//",
+	".", "[", "]", "(", ")", "|", ",", ":", ";", "?", "<", ">", "+", "-", "*", "/",
+}
+
+func lexQuery(expr string) ([]queryToken, error) {
+	var (
+		tokens []queryToken
+		runes  = []rune(expr)
+	)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"':
+			end := i + 1
+			for ; end < len(runes) && runes[end] != '"'; end++ {
+				if runes[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(runes) {
+				return nil, errors.New("unterminated string")
+			}
+
+			str, err := strconv.Unquote(string(runes[i : end+1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %s", string(runes[i:end+1]))
+			}
+			tokens = append(tokens, queryToken{kind: qtString, val: str})
+			i = end + 1
+		case unicode.IsDigit(r):
+			end := i
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				// '.0.id' style paths, a dot is only part of the number if a digit follows it
+				if runes[end] == '.' && (end+1 >= len(runes) || !unicode.IsDigit(runes[end+1]) ||
+					(len(tokens) > 0 && tokens[len(tokens)-1].val == ".")) {
+					break
+				}
+				end++
+			}
+
+			num, err := strconv.ParseFloat(string(runes[i:end]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %s", string(runes[i:end]))
+			}
+			tokens = append(tokens, queryToken{kind: qtNumber, val: string(runes[i:end]), num: num})
+			i = end
+		case r == '_' || unicode.IsLetter(r):
+			end := i
+			for end < len(runes) && (runes[end] == '_' || unicode.IsLetter(runes[end]) ||
+				unicode.IsDigit(runes[end])) {
+				end++
+			}
+			tokens = append(tokens, queryToken{kind: qtIdent, val: string(runes[i:end])})
+			i = end
+		default:
+			punct := ""
+			for _, p := range queryPuncts {
+				if strings.HasPrefix(string(runes[i:]), p) {
+					punct = p
+					break
+				}
+			}
+			if punct == "" {
+				return nil, fmt.Errorf("unexpected character '%c'", r)
+			}
+			tokens = append(tokens, queryToken{kind: qtPunct, val: punct})
+			i += len([]rune(punct))
+		}
+	}
+	return tokens, nil
+}
+
+/*
+* Parser, builds the query out of closures. Precedence (lowest first):
+* '|', ',', '//', 'or', 'and', comparisons, '+' '-', '*' '/', postfix terms.
+ */
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *queryParser) peek() queryToken {
+	if p.done() {
+		return queryToken{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) isPunct(val string) bool {
+	t := p.peek()
+	return !p.done() && t.kind == qtPunct && t.val == val
+}
+
+func (p *queryParser) isIdent(val string) bool {
+	t := p.peek()
+	return !p.done() && t.kind == qtIdent && t.val == val
+}
+
+func (p *queryParser) expect(val string) error {
+	if !p.isPunct(val) {
+		if p.done() {
+			return fmt.Errorf("expected '%s' but the query ended", val)
+		}
+		return fmt.Errorf("expected '%s' but got '%s'", val, p.peek().val)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *queryParser) parsePipe() (queryFn, error) {
+	left, err := p.parseComma()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isPunct("|") {
+		p.pos++
+		right, err := p.parseComma()
+		if err != nil {
+			return nil, err
+		}
+		left = pipeQuery(left, right)
+	}
+	return left, nil
+}
+
+func pipeQuery(left, right queryFn) queryFn {
+	return func(input any) ([]any, error) {
+		inputs, err := left(input)
+		if err != nil {
+			return nil, err
+		}
+
+		var outputs []any
+		for _, in := range inputs {
+			out, err := right(in)
+			if err != nil {
+				return nil, err
+			}
+			outputs = append(outputs, out...)
+		}
+		return outputs, nil
+	}
+}
+
+func (p *queryParser) parseComma() (queryFn, error) {
+	left, err := p.parseAlternative()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isPunct(",") {
+		p.pos++
+		right, err := p.parseAlternative()
+		if err != nil {
+			return nil, err
+		}
+
+		l := left
+		left = func(input any) ([]any, error) {
+			lOut, err := l(input)
+			if err != nil {
+				return nil, err
+			}
+			rOut, err := right(input)
+			if err != nil {
+				return nil, err
+			}
+			return append(lOut, rOut...), nil
+		}
+	}
+	return left, nil
+}
+
+// 'a // b' yields the truthy outputs of a, or the outputs of b if there are none
+func (p *queryParser) parseAlternative() (queryFn, error) {
+	left, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isPunct("//") {
+		p.pos++
+		right, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		l := left
+		left = func(input any) ([]any, error) {
+			lOut, _ := l(input)
+			var truthy []any
+			for _, v := range lOut {
+				if isTruthy(v) {
+					truthy = append(truthy, v)
+				}
+			}
+
+			if len(truthy) > 0 {
+				return truthy, nil
+			}
+			return right(input)
+		}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseOr() (queryFn, error) {
+	return p.parseLogical("or", p.parseAnd)
+}
+
+func (p *queryParser) parseAnd() (queryFn, error) {
+	return p.parseLogical("and", p.parseComparison)
+}
+
+func (p *queryParser) parseLogical(op string, next func() (queryFn, error)) (queryFn, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isIdent(op) {
+		p.pos++
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+
+		l := left
+		left = func(input any) ([]any, error) {
+			lOut, err := l(input)
+			if err != nil {
+				return nil, err
+			}
+
+			var outputs []any
+			for _, lv := range lOut {
+				// Short circuit
+				if op == "or" && isTruthy(lv) || op == "and" && !isTruthy(lv) {
+					outputs = append(outputs, op == "or")
+					continue
+				}
+
+				rOut, err := right(input)
+				if err != nil {
+					return nil, err
+				}
+				for _, rv := range rOut {
+					outputs = append(outputs, isTruthy(rv))
+				}
+			}
+			return outputs, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseComparison() (queryFn, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if !p.isPunct(op) {
+			continue
+		}
+
+		p.pos++
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+
+		return binaryQuery(left, right, func(l, r any) (any, error) {
+			return compareOp(op, l, r), nil
+		}), nil
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAdditive() (queryFn, error) {
+	return p.parseArithmetic([]string{"+", "-"}, p.parseMultiplicative)
+}
+
+func (p *queryParser) parseMultiplicative() (queryFn, error) {
+	return p.parseArithmetic([]string{"*", "/"}, p.parsePostfix)
+}
+
+func (p *queryParser) parseArithmetic(
+	ops []string,
+	next func() (queryFn, error),
+) (queryFn, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op := ""
+		for _, o := range ops {
+			if p.isPunct(o) {
+				op = o
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+
+		p.pos++
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryQuery(left, right, func(l, r any) (any, error) {
+			return arithmetic(op, l, r)
+		})
+	}
+}
+
+// Applies the operator to every combination of the outputs of both sides
+func binaryQuery(left, right queryFn, op func(l, r any) (any, error)) queryFn {
+	return func(input any) ([]any, error) {
+		lOut, err := left(input)
+		if err != nil {
+			return nil, err
+		}
+		rOut, err := right(input)
+		if err != nil {
+			return nil, err
+		}
+
+		var outputs []any
+		for _, lv := range lOut {
+			for _, rv := range rOut {
+				v, err := op(lv, rv)
+				if err != nil {
+					return nil, err
+				}
+				outputs = append(outputs, v)
+			}
+		}
+		return outputs, nil
+	}
+}
+
+func (p *queryParser) parsePostfix() (queryFn, error) {
+	term, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch {
+		case p.isPunct(".") && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].kind != qtPunct:
+			p.pos++
+			key, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			term = pipeQuery(term, key)
+		case p.isPunct(".") && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].val == "[":
+			p.pos++ // '.[0]' after a term is the same as '[0]'
+		case p.isPunct("["):
+			suffix, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			term = pipeQuery(term, suffix)
+		case p.isPunct("?"):
+			p.pos++
+			t := term
+			term = func(input any) ([]any, error) {
+				out, err := t(input)
+				if err != nil {
+					return nil, nil
+				}
+				return out, nil
+			}
+		default:
+			return term, nil
+		}
+	}
+}
+
+func (p *queryParser) parseTerm() (queryFn, error) {
+	if p.done() {
+		return nil, errors.New("unexpected end of query")
+	}
+
+	t := p.peek()
+	switch {
+	case t.kind == qtPunct && t.val == ".":
+		p.pos++
+		if next := p.peek(); !p.done() && next.kind != qtPunct {
+			return p.parseKey()
+		}
+		return identityQuery, nil
+	case t.kind == qtString:
+		p.pos++
+		return literalQuery(t.val), nil
+	case t.kind == qtNumber:
+		p.pos++
+		return literalQuery(t.num), nil
+	case t.kind == qtPunct && t.val == "-":
+		p.pos++
+		operand, err := p.parsePostfix()
+		if err != nil {
+			return nil, err
+		}
+		return binaryQuery(literalQuery(float64(0)), operand, func(l, r any) (any, error) {
+			return arithmetic("-", l, r)
+		}), nil
+	case t.kind == qtPunct && t.val == "(":
+		p.pos++
+		inner, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	case t.kind == qtPunct && t.val == "[":
+		return p.parseArrayConstruction()
+	case t.kind == qtIdent:
+		return p.parseIdent()
+	default:
+		return nil, fmt.Errorf("unexpected '%s'", t.val)
+	}
+}
+
+// The key following a '.', as in '.name', '."some key"' or '.0'
+func (p *queryParser) parseKey() (queryFn, error) {
+	t := p.peek()
+	p.pos++
+
+	switch t.kind {
+	case qtIdent, qtString:
+		return fieldQuery(t.val), nil
+	case qtNumber:
+		if t.num != math.Trunc(t.num) {
+			return nil, fmt.Errorf("invalid index '%s'", t.val)
+		}
+		return indexQuery(literalQuery(t.num)), nil
+	default:
+		return nil, fmt.Errorf("unexpected '%s' after '.'", t.val)
+	}
+}
+
+// '[]', '[<index|key>]' or '[<from>:<to>]'
+func (p *queryParser) parseBracket() (queryFn, error) {
+	p.pos++ // '['
+	if p.isPunct("]") {
+		p.pos++
+		return iterateQuery, nil
+	}
+
+	var from, to queryFn
+	if !p.isPunct(":") {
+		idx, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isPunct(":") {
+			return indexQuery(idx), p.expect("]")
+		}
+		from = idx
+	}
+
+	p.pos++ // ':'
+	if !p.isPunct("]") {
+		idx, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		to = idx
+	}
+	return sliceQuery(from, to), p.expect("]")
+}
+
+func (p *queryParser) parseArrayConstruction() (queryFn, error) {
+	p.pos++ // '['
+	if p.isPunct("]") {
+		p.pos++
+		return literalQuery([]any{}), nil
+	}
+
+	inner, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(input any) ([]any, error) {
+		out, err := inner(input)
+		if err != nil {
+			return nil, err
+		}
+		if out == nil {
+			out = []any{}
+		}
+		return []any{out}, nil
+	}, p.expect("]")
+}
+
+func (p *queryParser) parseIdent() (queryFn, error) {
+	name := p.peek().val
+	p.pos++
+
+	switch name {
+	case "true":
+		return literalQuery(true), nil
+	case "false":
+		return literalQuery(false), nil
+	case "null":
+		return literalQuery(nil), nil
+	}
+
+	var args []queryFn
+	if p.isPunct("(") {
+		p.pos++
+		for {
+			arg, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+
+			if !p.isPunct(";") {
+				break
+			}
+			p.pos++
+		}
+
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, fn := range queryFuncs[name] {
+		if fn.arity == len(args) {
+			return fn.build(args), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown function '%s/%d'", name, len(args))
+}
+
+/*
+* Building blocks
+ */
+
+func identityQuery(input any) ([]any, error) {
+	return []any{input}, nil
+}
+
+func literalQuery(val any) queryFn {
+	return func(any) ([]any, error) {
+		return []any{val}, nil
+	}
+}
+
+// Missing keys & fields of null yield null, like in jq
+func fieldQuery(key string) queryFn {
+	return func(input any) ([]any, error) {
+		switch v := input.(type) {
+		case nil:
+			return []any{nil}, nil
+		case map[string]any:
+			return []any{v[key]}, nil
+		default:
+			return nil, fmt.Errorf("cannot index %s with \"%s\"", queryTypeOf(input), key)
+		}
+	}
+}
+
+func indexQuery(idx queryFn) queryFn {
+	return func(input any) ([]any, error) {
+		keys, err := idx(input)
+		if err != nil {
+			return nil, err
+		}
+
+		outputs := make([]any, 0, len(keys))
+		for _, key := range keys {
+			if s, ok := key.(string); ok {
+				out, err := fieldQuery(s)(input)
+				if err != nil {
+					return nil, err
+				}
+				outputs = append(outputs, out...)
+				continue
+			}
+
+			n, ok := key.(float64)
+			if !ok {
+				return nil, fmt.Errorf("cannot index with %s", queryTypeOf(key))
+			}
+
+			switch v := input.(type) {
+			case nil:
+				outputs = append(outputs, nil)
+			case []any:
+				i := int(n)
+				if i < 0 {
+					i += len(v)
+				}
+				if i < 0 || i >= len(v) {
+					outputs = append(outputs, nil)
+				} else {
+					outputs = append(outputs, v[i])
+				}
+			default:
+				return nil, fmt.Errorf("cannot index %s with a number", queryTypeOf(input))
+			}
+		}
+		return outputs, nil
+	}
+}
+
+// Object values are iterated in the order of their keys
+func iterateQuery(input any) ([]any, error) {
+	switch v := input.(type) {
+	case []any:
+		return v, nil
+	case map[string]any:
+		keys := sortedKeys(v)
+		values := make([]any, 0, len(keys))
+		for _, k := range keys {
+			values = append(values, v[k])
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("cannot iterate over %s", queryTypeOf(input))
+	}
+}
+
+func sliceQuery(from, to queryFn) queryFn {
+	bound := func(input any, fn queryFn, def int) (int, error) {
+		if fn == nil {
+			return def, nil
+		}
+
+		out, err := fn(input)
+		if err != nil {
+			return 0, err
+		}
+		if len(out) != 1 {
+			return 0, errors.New("slice bounds must be single numbers")
+		}
+
+		n, ok := out[0].(float64)
+		if !ok {
+			return 0, fmt.Errorf("slice bounds must be numbers, got %s", queryTypeOf(out[0]))
+		}
+		return int(n), nil
+	}
+
+	return func(input any) ([]any, error) {
+		var length int
+		switch v := input.(type) {
+		case nil:
+			return []any{nil}, nil
+		case []any:
+			length = len(v)
+		case string:
+			length = len([]rune(v))
+		default:
+			return nil, fmt.Errorf("cannot slice %s", queryTypeOf(input))
+		}
+
+		start, err := bound(input, from, 0)
+		if err != nil {
+			return nil, err
+		}
+		end, err := bound(input, to, length)
+		if err != nil {
+			return nil, err
+		}
+		start, end = clampSliceBound(start, length), clampSliceBound(end, length)
+		end = max(start, end)
+
+		if s, ok := input.(string); ok {
+			return []any{string([]rune(s)[start:end])}, nil
+		}
+		return []any{input.([]any)[start:end]}, nil
+	}
+}
+
+func clampSliceBound(i, length int) int {
+	if i < 0 {
+		i += length
+	}
+	return min(max(i, 0), length)
+}
+
+/*
+* Values
+ */
+
+func isTruthy(v any) bool {
+	return v != nil && v != false
+}
+
+func queryTypeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64, int, int64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func toQueryNum(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// Numeric strings (as in xml bodies) are compared as numbers when the other side is a number
+func compareOp(op string, l, r any) bool {
+	ln, lIsNum := toQueryNum(l)
+	rn, rIsNum := toQueryNum(r)
+	if lIsNum != rIsNum {
+		if s, ok := l.(string); ok {
+			ln, lIsNum = parseNumStr(s)
+		} else if s, ok := r.(string); ok {
+			rn, rIsNum = parseNumStr(s)
+		}
+	}
+
+	var cmp int
+	if lIsNum && rIsNum {
+		cmp = compareNums(ln, rn)
+	} else {
+		cmp = compareQueryVals(l, r)
+	}
+
+	switch op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+func parseNumStr(s string) (float64, bool) {
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return n, err == nil
+}
+
+func compareNums(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Orders values the way jq does: null < false < true < numbers < strings < arrays < objects
+func compareQueryVals(a, b any) int {
+	rank := func(v any) int {
+		switch v {
+		case nil:
+			return 0
+		case false:
+			return 1
+		case true:
+			return 2
+		}
+		switch v.(type) {
+		case string:
+			return 4
+		case []any:
+			return 5
+		case map[string]any:
+			return 6
+		default:
+			return 3
+		}
+	}
+
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return ra - rb
+	}
+
+	switch av := a.(type) {
+	case string:
+		return strings.Compare(av, b.(string))
+	case []any:
+		bv := b.([]any)
+		for i := range min(len(av), len(bv)) {
+			if c := compareQueryVals(av[i], bv[i]); c != 0 {
+				return c
+			}
+		}
+		return len(av) - len(bv)
+	case map[string]any:
+		aj, _ := json.Marshal(av)
+		bj, _ := json.Marshal(b)
+		return strings.Compare(string(aj), string(bj))
+	}
+
+	an, _ := toQueryNum(a)
+	bn, _ := toQueryNum(b)
+	return compareNums(an, bn)
+}
+
+func arithmetic(op string, l, r any) (any, error) {
+	ln, lIsNum := toQueryNum(l)
+	rn, rIsNum := toQueryNum(r)
+	if lIsNum && rIsNum {
+		switch op {
+		case "+":
+			return ln + rn, nil
+		case "-":
+			return ln - rn, nil
+		case "*":
+			return ln * rn, nil
+		default:
+			if rn == 0 {
+				return nil, errors.New("division by zero")
+			}
+			return ln / rn, nil
+		}
+	}
+
+	if op == "+" {
+		switch lv := l.(type) {
+		case nil:
+			return r, nil
+		case string:
+			if rv, ok := r.(string); ok {
+				return lv + rv, nil
+			}
+		case []any:
+			if rv, ok := r.([]any); ok {
+				return append(slices.Clone(lv), rv...), nil
+			}
+		case map[string]any:
+			if rv, ok := r.(map[string]any); ok {
+				merged := make(map[string]any, len(lv)+len(rv))
+				CopyMap(merged, lv)
+				return CopyMap(merged, rv), nil
+			}
+		}
+		if r == nil {
+			return l, nil
+		}
+	}
+
+	if op == "-" {
+		lv, lOk := l.([]any)
+		rv, rOk := r.([]any)
+		if lOk && rOk {
+			return slices.DeleteFunc(slices.Clone(lv), func(item any) bool {
+				return slices.ContainsFunc(rv, func(other any) bool {
+					return compareQueryVals(item, other) == 0
+				})
+			}), nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot apply '%s' to %s and %s", op, queryTypeOf(l), queryTypeOf(r))
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+/*
+* Builtins
+ */
+
+// Builtins taking no arguments, applied to every input
+func simpleQueryFunc(fn func(input any) (any, error)) queryFunc {
+	return queryFunc{arity: 0, build: func([]queryFn) queryFn {
+		return func(input any) ([]any, error) {
+			out, err := fn(input)
+			if err != nil {
+				return nil, err
+			}
+			return []any{out}, nil
+		}
+	}}
+}
+
+// Builtins taking a single value argument, the argument is evaluated against the input
+func valueArgQueryFunc(fn func(input, arg any) (any, error)) queryFunc {
+	return queryFunc{arity: 1, build: func(args []queryFn) queryFn {
+		return binaryQuery(identityQuery, args[0], fn)
+	}}
+}
+
+func builtinQueryFuncs() map[string][]queryFunc {
+	return map[string][]queryFunc{
+		"length": {simpleQueryFunc(queryLength)},
+		"keys":   {simpleQueryFunc(queryKeys)},
+		"type": {simpleQueryFunc(func(input any) (any, error) {
+			return queryTypeOf(input), nil
+		})},
+		"not": {simpleQueryFunc(func(input any) (any, error) {
+			return !isTruthy(input), nil
+		})},
+		"first": {simpleQueryFunc(func(input any) (any, error) {
+			return indexOrNull(input, 0)
+		})},
+		"last": {simpleQueryFunc(func(input any) (any, error) {
+			return indexOrNull(input, -1)
+		})},
+		"reverse": {simpleQueryFunc(func(input any) (any, error) {
+			if s, ok := input.(string); ok {
+				r := []rune(s)
+				slices.Reverse(r)
+				return string(r), nil
+			}
+			arr, err := queryArray(input, "reverse")
+			if err != nil {
+				return nil, err
+			}
+			reversed := slices.Clone(arr)
+			slices.Reverse(reversed)
+			return reversed, nil
+		})},
+		"sort": {simpleQueryFunc(func(input any) (any, error) {
+			arr, err := queryArray(input, "sort")
+			if err != nil {
+				return nil, err
+			}
+			return slices.SortedStableFunc(slices.Values(arr), compareQueryVals), nil
+		})},
+		"unique": {simpleQueryFunc(func(input any) (any, error) {
+			arr, err := queryArray(input, "unique")
+			if err != nil {
+				return nil, err
+			}
+			sorted := slices.SortedStableFunc(slices.Values(arr), compareQueryVals)
+			return slices.CompactFunc(sorted, func(a, b any) bool {
+				return compareQueryVals(a, b) == 0
+			}), nil
+		})},
+		"min": {simpleQueryFunc(func(input any) (any, error) {
+			return queryExtreme(input, -1)
+		})},
+		"max": {simpleQueryFunc(func(input any) (any, error) {
+			return queryExtreme(input, 1)
+		})},
+		"add": {simpleQueryFunc(func(input any) (any, error) {
+			arr, err := queryArray(input, "add")
+			if err != nil {
+				return nil, err
+			}
+			var sum any
+			for _, item := range arr {
+				if sum, err = arithmetic("+", sum, item); err != nil {
+					return nil, err
+				}
+			}
+			return sum, nil
+		})},
+		"flatten": {simpleQueryFunc(func(input any) (any, error) {
+			arr, err := queryArray(input, "flatten")
+			if err != nil {
+				return nil, err
+			}
+			return flattenQueryArr(arr), nil
+		})},
+		"tostring": {simpleQueryFunc(func(input any) (any, error) {
+			return StringifyQueryResults([]any{input}), nil
+		})},
+		"tonumber": {simpleQueryFunc(func(input any) (any, error) {
+			if n, ok := toQueryNum(input); ok {
+				return n, nil
+			}
+			if s, ok := input.(string); ok {
+				if n, ok := parseNumStr(s); ok {
+					return n, nil
+				}
+			}
+			return nil, fmt.Errorf("cannot parse %s as a number", StringifyQueryResults([]any{input}))
+		})},
+		"ascii_downcase": {simpleQueryFunc(func(input any) (any, error) {
+			s, ok := input.(string)
+			if !ok {
+				return nil, fmt.Errorf("ascii_downcase expects a string, got %s", queryTypeOf(input))
+			}
+			return strings.ToLower(s), nil
+		})},
+		"ascii_upcase": {simpleQueryFunc(func(input any) (any, error) {
+			s, ok := input.(string)
+			if !ok {
+				return nil, fmt.Errorf("ascii_upcase expects a string, got %s", queryTypeOf(input))
+			}
+			return strings.ToUpper(s), nil
+		})},
+		"to_entries": {simpleQueryFunc(func(input any) (any, error) {
+			obj, ok := input.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("to_entries expects an object, got %s", queryTypeOf(input))
+			}
+			entries := make([]any, 0, len(obj))
+			for _, k := range sortedKeys(obj) {
+				entries = append(entries, map[string]any{"key": k, "value": obj[k]})
+			}
+			return entries, nil
+		})},
+		"empty": {{arity: 0, build: func([]queryFn) queryFn {
+			return func(any) ([]any, error) { return nil, nil }
+		}}},
+		"any": {simpleQueryFunc(func(input any) (any, error) {
+			arr, err := queryArray(input, "any")
+			return slices.ContainsFunc(arr, isTruthy), err
+		}), {arity: 1, build: func(args []queryFn) queryFn {
+			return quantifierQuery(args[0], true)
+		}}},
+		"all": {simpleQueryFunc(func(input any) (any, error) {
+			arr, err := queryArray(input, "all")
+			return !slices.ContainsFunc(arr, func(v any) bool { return !isTruthy(v) }), err
+		}), {arity: 1, build: func(args []queryFn) queryFn {
+			return quantifierQuery(args[0], false)
+		}}},
+		"select": {{arity: 1, build: func(args []queryFn) queryFn {
+			return func(input any) ([]any, error) {
+				out, err := args[0](input)
+				if err != nil {
+					return nil, err
+				}
+
+				var outputs []any
+				for _, v := range out {
+					if isTruthy(v) {
+						outputs = append(outputs, input)
+					}
+				}
+				return outputs, nil
+			}
+		}}},
+		"map": {{arity: 1, build: func(args []queryFn) queryFn {
+			mapped := pipeQuery(iterateQuery, args[0])
+			return func(input any) ([]any, error) {
+				out, err := mapped(input)
+				if err != nil {
+					return nil, err
+				}
+				if out == nil {
+					out = []any{}
+				}
+				return []any{out}, nil
+			}
+		}}},
+		"sort_by": {{arity: 1, build: func(args []queryFn) queryFn {
+			return func(input any) ([]any, error) {
+				arr, err := queryArray(input, "sort_by")
+				if err != nil {
+					return nil, err
+				}
+
+				keys := make(map[int]any, len(arr))
+				for i, item := range arr {
+					out, err := args[0](item)
+					if err != nil {
+						return nil, err
+					}
+					keys[i] = out
+				}
+
+				indices := make([]int, len(arr))
+				for i := range indices {
+					indices[i] = i
+				}
+				slices.SortStableFunc(indices, func(a, b int) int {
+					return compareQueryVals(keys[a], keys[b])
+				})
+
+				sorted := make([]any, 0, len(arr))
+				for _, i := range indices {
+					sorted = append(sorted, arr[i])
+				}
+				return []any{sorted}, nil
+			}
+		}}},
+		"has": {valueArgQueryFunc(func(input, key any) (any, error) {
+			switch v := input.(type) {
+			case map[string]any:
+				k, ok := key.(string)
+				if !ok {
+					return nil, fmt.Errorf("cannot check whether an object has a %s key", queryTypeOf(key))
+				}
+				_, exists := v[k]
+				return exists, nil
+			case []any:
+				n, ok := key.(float64)
+				return ok && n >= 0 && int(n) < len(v), nil
+			default:
+				return nil, fmt.Errorf("cannot check whether %s has a key", queryTypeOf(input))
+			}
+		})},
+		"contains": {valueArgQueryFunc(func(input, elem any) (any, error) {
+			switch v := input.(type) {
+			case string:
+				s, ok := elem.(string)
+				return ok && strings.Contains(v, s), nil
+			case []any:
+				return slices.ContainsFunc(v, func(item any) bool {
+					return compareQueryVals(item, elem) == 0
+				}), nil
+			default:
+				return nil, fmt.Errorf("contains isn't supported for %s", queryTypeOf(input))
+			}
+		})},
+		"join": {valueArgQueryFunc(func(input, sep any) (any, error) {
+			arr, err := queryArray(input, "join")
+			if err != nil {
+				return nil, err
+			}
+			s, ok := sep.(string)
+			if !ok {
+				return nil, errors.New("join expects a string separator")
+			}
+			strs := make([]string, len(arr))
+			for i, item := range arr {
+				if item != nil {
+					strs[i] = StringifyQueryResults([]any{item})
+				}
+			}
+			return strings.Join(strs, s), nil
+		})},
+		"split": {valueArgQueryFunc(func(input, sep any) (any, error) {
+			s, sOk := input.(string)
+			sepStr, sepOk := sep.(string)
+			if !sOk || !sepOk {
+				return nil, errors.New("split expects a string input & separator")
+			}
+
+			parts := strings.Split(s, sepStr)
+			out := make([]any, len(parts))
+			for i, part := range parts {
+				out[i] = part
+			}
+			return out, nil
+		})},
+		"test": {valueArgQueryFunc(func(input, pattern any) (any, error) {
+			s, sOk := input.(string)
+			p, pOk := pattern.(string)
+			if !sOk || !pOk {
+				return nil, errors.New("test expects a string input & pattern")
+			}
+
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern: %w", err)
+			}
+			return re.MatchString(s), nil
+		})},
+		"startswith": {valueArgQueryFunc(func(input, prefix any) (any, error) {
+			s, sOk := input.(string)
+			p, pOk := prefix.(string)
+			if !sOk || !pOk {
+				return nil, errors.New("startswith expects strings")
+			}
+			return strings.HasPrefix(s, p), nil
+		})},
+		"endswith": {valueArgQueryFunc(func(input, suffix any) (any, error) {
+			s, sOk := input.(string)
+			p, pOk := suffix.(string)
+			if !sOk || !pOk {
+				return nil, errors.New("endswith expects strings")
+			}
+			return strings.HasSuffix(s, p), nil
+		})},
+	}
+}
+
+func queryArray(input any, fnName string) ([]any, error) {
+	arr, ok := input.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s expects an array, got %s", fnName, queryTypeOf(input))
+	}
+	return arr, nil
+}
+
+func queryLength(input any) (any, error) {
+	switch v := input.(type) {
+	case nil:
+		return float64(0), nil
+	case string:
+		return float64(len([]rune(v))), nil
+	case []any:
+		return float64(len(v)), nil
+	case map[string]any:
+		return float64(len(v)), nil
+	}
+
+	if n, ok := toQueryNum(input); ok {
+		return math.Abs(n), nil
+	}
+	return nil, fmt.Errorf("%s has no length", queryTypeOf(input))
+}
+
+// Object keys are sorted, arrays yield their indices
+func queryKeys(input any) (any, error) {
+	switch v := input.(type) {
+	case map[string]any:
+		keys := sortedKeys(v)
+		out := make([]any, len(keys))
+		for i, k := range keys {
+			out[i] = k
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i := range v {
+			out[i] = float64(i)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("%s has no keys", queryTypeOf(input))
+	}
+}
+
+func indexOrNull(input any, i int) (any, error) {
+	out, err := indexQuery(literalQuery(float64(i)))(input)
+	if err != nil {
+		return nil, err
+	}
+	return out[0], nil
+}
+
+// The smallest (dir -1) or largest (dir 1) element, null for empty arrays
+func queryExtreme(input any, dir int) (any, error) {
+	arr, err := queryArray(input, map[int]string{-1: "min", 1: "max"}[dir])
+	if err != nil || len(arr) == 0 {
+		return nil, err
+	}
+
+	extreme := arr[0]
+	for _, item := range arr[1:] {
+		if compareQueryVals(item, extreme)*dir > 0 {
+			extreme = item
+		}
+	}
+	return extreme, nil
+}
+
+func flattenQueryArr(arr []any) []any {
+	flat := make([]any, 0, len(arr))
+	for _, item := range arr {
+		if nested, ok := item.([]any); ok {
+			flat = append(flat, flattenQueryArr(nested)...)
+		} else {
+			flat = append(flat, item)
+		}
+	}
+	return flat
+}
+
+// any(cond) & all(cond) over the elements of the input array
+func quantifierQuery(cond queryFn, isAny bool) queryFn {
+	return func(input any) ([]any, error) {
+		arr, err := queryArray(input, map[bool]string{true: "any", false: "all"}[isAny])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range arr {
+			out, err := cond(item)
+			if err != nil {
+				return nil, err
+			}
+			for _, v := range out {
+				if isTruthy(v) == isAny {
+					return []any{isAny}, nil
+				}
+			}
+		}
+		return []any{!isAny}, nil
+	}
+}
@@ -0,0 +1,239 @@
+package util
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const queryTestBody = `{
+	"page": {"total": 3},
+	"items": [
+		{"id": 1, "name": "John", "age": 31, "tags": ["admin"]},
+		{"id": 2, "name": "Jane", "age": 25, "tags": []},
+		{"id": 3, "name": "Jim", "age": 40, "tags": ["ops", "admin"]}
+	]
+}`
+
+func TestQuery(t *testing.T) {
+	var data any
+	if err := json.Unmarshal([]byte(queryTestBody), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expr string
+		want []any
+	}{
+		{".page.total", []any{float64(3)}},
+		{".items[0].name", []any{"John"}},
+		{".items[-1].id", []any{float64(3)}},
+		{".items.1.name", []any{"Jane"}},
+		{".items[].id", []any{float64(1), float64(2), float64(3)}},
+		{".items[1:].id?", nil},
+		{".items[1:] | length", []any{float64(2)}},
+		{".items[] | select(.age > 30) | .name", []any{"John", "Jim"}},
+		{`.items[] | select(.name == "Jane" or .id == 3) | .id`, []any{float64(2), float64(3)}},
+		{`.items[] | select(.tags | contains("admin")) | .id`, []any{float64(1), float64(3)}},
+		{"[.items[].age] | max", []any{float64(40)}},
+		{".items | map(.age) | add / length", []any{float64(32)}},
+		{".items | sort_by(.age) | first | .name", []any{"Jane"}},
+		{".page | keys", []any{[]any{"total"}}},
+		{`.missing // "n/a"`, []any{"n/a"}},
+		{`.items | map(.name) | join(", ")`, []any{"John, Jane, Jim"}},
+		{`.items[0].name | test("^J.*n$")`, []any{true}},
+		{`.items[0].name[1:3]`, []any{"oh"}},
+		{".page.total, .items[0].id", []any{float64(3), float64(1)}},
+		{"items.1.name", []any{"Jane"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := QueryVal(data, tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("QueryVal(%q) = %#v, want %#v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryNumericStrings(t *testing.T) {
+	data := map[string]any{"items": []any{
+		map[string]any{"price": "12.5"},
+		map[string]any{"price": "7"},
+	}}
+
+	got, err := QueryVal(data, ".items[] | select(.price < 10) | .price")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, []any{"7"}) {
+		t.Errorf("expected numeric strings to be compared as numbers, got %#v", got)
+	}
+}
+
+func TestQueryPrecedence(t *testing.T) {
+	var data any
+	if err := json.Unmarshal([]byte(queryTestBody), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expr string
+		want []any
+	}{
+		// '//' binds looser than comparisons and 'and'/'or'
+		{".missing // .page.total > 2", []any{true}},
+		{".missing // .page.total == 3 and .items[0].id == 2", []any{false}},
+		{`null // false // "x"`, []any{"x"}},
+		{`.items[].tags[0] // "none"`, []any{"admin", "ops"}},
+
+		// 'and' binds tighter than 'or'
+		{"true or false and false", []any{true}},
+		{"false and true or true", []any{true}},
+		{"false and (true or true)", []any{false}},
+		{".page.total == 3 and .items[0].id == 1", []any{true}},
+
+		// Arithmetic before comparisons, left to right within a level
+		{"1 + 2 * 3", []any{float64(7)}},
+		{"10 - 4 - 3", []any{float64(3)}},
+		{"12 / 2 / 3", []any{float64(2)}},
+		{"(1 + 2) * 3", []any{float64(9)}},
+		{".page.total - -1", []any{float64(4)}},
+		{".page.total + 1 > 3", []any{true}},
+
+		// Comparisons and pipes inside map
+		{".items | map(.age > 30)", []any{[]any{true, false, true}}},
+		{".items | map(select(.age >= 31) | .id)", []any{[]any{float64(1), float64(3)}}},
+		{`.items | map(.name != "Jane" and .age < 40)`, []any{[]any{true, false, false}}},
+		{".items | length > 2 and (.[0].age < 40)", []any{true}},
+
+		// ',' binds looser than '|' on its right
+		{".items[] | .id, .age", []any{float64(1), float64(31), float64(2), float64(25), float64(3), float64(40)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := QueryVal(data, tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("QueryVal(%q) = %#v, want %#v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuerySlicesOutOfRange(t *testing.T) {
+	var data any
+	if err := json.Unmarshal([]byte(queryTestBody), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expr string
+		want []any
+	}{
+		{".items[5:]", []any{[]any{}}},
+		{".items[2:1]", []any{[]any{}}},
+		{".items[-10:1] | length", []any{float64(1)}},
+		{".items[:10] | length", []any{float64(3)}},
+		{".items[:-1] | length", []any{float64(2)}},
+		{".items[10]", []any{nil}},
+		{".items[-10]", []any{nil}},
+		{".items[0].name[5:]", []any{""}},
+		{".items[0].name[-10:2]", []any{"Jo"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := QueryVal(data, tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("QueryVal(%q) = %#v, want %#v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	compileErrs := []struct {
+		expr    string
+		wantErr string
+	}{
+		{".items[", "unexpected end of query"},
+		{".items | nope", "unknown function 'nope/0'"},
+		{`.name == "unterminated`, ""},
+		{"select()", ""},
+		{"map(", "unexpected end of query"},
+		{".a ==", "unexpected end of query"},
+		{"1 +", "unexpected end of query"},
+		{"| .a", "unexpected '|'"},
+		{".items[] |", "unexpected end of query"},
+		{"[1, 2", "expected ']'"},
+		{".items[1:2:3]", "expected ']'"},
+		{"()", "unexpected ')'"},
+		{".age > 30 == true", "unexpected '=='"},
+		{"length(1)", "unknown function 'length/1'"},
+		{"select(.a; .b)", "unknown function 'select/2'"},
+
+		// Not part of the supported subset
+		{"{name: .name}", "unexpected character '{'"},
+		{"..", "unexpected '.'"},
+		{".age % 2", "unexpected character '%'"},
+		{". as $x | $x", "unexpected character '$'"},
+		{`index("a")`, "unknown function 'index/1'"},
+	}
+
+	for _, tt := range compileErrs {
+		_, err := CompileQuery(tt.expr)
+		if err == nil {
+			t.Errorf("expected %q not to compile", tt.expr)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("CompileQuery(%q) error = %q, want it to contain %q", tt.expr, err, tt.wantErr)
+		}
+	}
+
+	var data any
+	if err := json.Unmarshal([]byte(queryTestBody), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	runtimeErrs := []struct {
+		expr    string
+		wantErr string
+	}{
+		{".items[0].name[]", "cannot iterate over string"},
+		{".page.total[]", "cannot iterate over number"},
+		{".items[0].name.first", `cannot index string with "first"`},
+		{`.page.total + "a"`, "cannot apply '+' to number and string"},
+		{"1 / 0", "division by zero"},
+	}
+
+	for _, tt := range runtimeErrs {
+		got, err := QueryVal(data, tt.expr)
+		if err == nil {
+			t.Errorf("expected %q to fail, got %#v", tt.expr, got)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("QueryVal(%q) error = %q, want it to contain %q", tt.expr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestStringifyQueryResults(t *testing.T) {
+	got := StringifyQueryResults([]any{"a", float64(2), map[string]any{"b": true}, nil})
+	if want := `a,2,{"b":true},null`; got != want {
+		t.Errorf("StringifyQueryResults() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package util
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/shubm-quodes/repl-reqs/log"
+)
+
+// How long saves are held back for, changes made in the meantime are saved along
+const SaveDebounce = 500 * time.Millisecond
+
+/*
+Runs debounced saves in the background, a final save is performed on shutdown. Saves are all run
+on the saver's own goroutine, one after the other, so they never overlap (they'd otherwise race
+for the same temp file, see WriteFileAtomic).
+*/
+type DebouncedSaver struct {
+	name         string
+	delay        time.Duration
+	save         func() error
+	after        func(time.Duration) <-chan time.Time // time.After, swapped in tests
+	saveChan     chan struct{}
+	shutdownChan chan struct{}
+	shutdownOnce sync.Once
+	wg           sync.WaitGroup
+}
+
+func NewDebouncedSaver(name string, delay time.Duration, save func() error) *DebouncedSaver {
+	return newDebouncedSaver(name, delay, save, time.After)
+}
+
+func newDebouncedSaver(
+	name string,
+	delay time.Duration,
+	save func() error,
+	after func(time.Duration) <-chan time.Time,
+) *DebouncedSaver {
+	s := &DebouncedSaver{
+		name:         name,
+		delay:        delay,
+		save:         save,
+		after:        after,
+		saveChan:     make(chan struct{}, 1),
+		shutdownChan: make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+func (s *DebouncedSaver) Trigger() {
+	select {
+	case s.saveChan <- struct{}{}:
+	default:
+		// Channel already has a pending save signal
+	}
+}
+
+func (s *DebouncedSaver) run() {
+	defer s.wg.Done()
+
+	// Every trigger pushes the save back, only the latest deadline counts
+	var deadline <-chan time.Time
+	for {
+		select {
+		case <-s.saveChan:
+			deadline = s.after(s.delay)
+
+		case <-deadline:
+			deadline = nil
+			if err := s.save(); err != nil {
+				log.Debug("%s: background saver attempt failed %s", s.name, err.Error())
+			}
+
+		case <-s.shutdownChan:
+			// Perform final save
+			if err := s.save(); err != nil {
+				log.Debug("%s: final save failed %s", s.name, err.Error())
+			}
+			return
+		}
+	}
+}
+
+// gracefully stops the background saver and ensures final save
+func (s *DebouncedSaver) Shutdown() {
+	s.shutdownOnce.Do(func() {
+		close(s.shutdownChan)
+		s.wg.Wait()
+	})
+}
+
+// Write to temp file first, then rename for atomic operation
+func WriteFileAtomic(filePath string, data []byte, perm os.FileMode) error {
+	tempFile := filePath + ".tmp"
+	if err := os.WriteFile(tempFile, data, perm); err != nil {
+		return err
+	}
+	return os.Rename(tempFile, filePath)
+}
@@ -0,0 +1,90 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+// Saves block until released, so that the test decides when each of them finishes
+type testSaves struct {
+	started chan struct{}
+	release chan struct{}
+	running int
+	overlap bool
+}
+
+func newTestSaves() *testSaves {
+	return &testSaves{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (ts *testSaves) save() error {
+	ts.running++
+	if ts.running > 1 {
+		ts.overlap = true
+	}
+	ts.started <- struct{}{}
+	<-ts.release
+	ts.running--
+	return nil
+}
+
+func (ts *testSaves) finishNext(t *testing.T) {
+	select {
+	case <-ts.started:
+		ts.release <- struct{}{}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a save to start")
+	}
+}
+
+func (ts *testSaves) expectNone(t *testing.T) {
+	select {
+	case <-ts.started:
+		t.Fatal("expected no save to start")
+	default:
+	}
+}
+
+func TestDebouncedSaver(t *testing.T) {
+	saves := newTestSaves()
+	deadlines := make(chan chan time.Time)
+	s := newDebouncedSaver("test", time.Second, saves.save, func(time.Duration) <-chan time.Time {
+		deadline := make(chan time.Time, 1)
+		deadlines <- deadline
+		return deadline
+	})
+
+	// A trigger within the delay pushes the save back, the earlier deadline is ignored
+	s.Trigger()
+	first := <-deadlines
+	s.Trigger()
+	second := <-deadlines
+
+	first <- time.Now()
+	second <- time.Now()
+	saves.finishNext(t)
+	if len(first) != 1 {
+		t.Errorf("expected the earlier deadline to be ignored")
+	}
+
+	// Shutdown waits for a save that's underway, then saves once more
+	s.Trigger()
+	(<-deadlines) <- time.Now()
+	<-saves.started
+
+	done := make(chan struct{})
+	go func() {
+		s.Shutdown()
+		s.Shutdown()
+		close(done)
+	}()
+
+	saves.release <- struct{}{}
+	saves.finishNext(t)
+	<-done
+
+	saves.expectNone(t)
+	if saves.overlap {
+		t.Errorf("expected saves not to overlap")
+	}
+}